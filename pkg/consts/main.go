@@ -99,6 +99,9 @@ const (
 	// ChaosMeshValuesFile is the values file for chaos mesh.
 	ChaosMeshValuesFile = ManifestsRoot + "/chaos-mesh-operator/values.yaml"
 
+	// CustomAlertsYaml is the VMRule with custom alerts added on top of the chart default rules.
+	CustomAlertsYaml = ManifestsRoot + "/custom-alerts.yaml"
+
 	// LicenseSecretName is the name of the secret containing the license key.
 	LicenseSecretName = "vm-license"
 
@@ -187,6 +190,7 @@ var (
 	licenseFile             string
 	distributedRegion       string
 	distributedZones        string
+	updateGolden            bool
 )
 
 // Setters
@@ -387,6 +391,13 @@ func SetLicenseFile(val string) {
 	licenseFile = val
 }
 
+// SetUpdateGolden sets whether golden files are regenerated instead of compared.
+func SetUpdateGolden(val bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	updateGolden = val
+}
+
 // Getters
 
 // ReportLocation returns the configured report location.
@@ -507,6 +518,20 @@ func AlertManagerHost(namespace string) string {
 	return fmt.Sprintf("alert-%s.%s.nip.io", namespace, host)
 }
 
+// VMAlertHost returns the hostname for VMAlert in the given namespace.
+func VMAlertHost(namespace string) string {
+	mu.Lock()
+	host := nginxHost
+	mu.Unlock()
+	if host == "" {
+		return ""
+	}
+	if namespace == "" {
+		return fmt.Sprintf("vmalert.%s.nip.io", host)
+	}
+	return fmt.Sprintf("vmalert-%s.%s.nip.io", namespace, host)
+}
+
 // VMGatherHost returns the hostname for VMGather.
 func VMGatherHost() string {
 	mu.Lock()
@@ -722,6 +747,13 @@ func DistributedZones() string {
 	return distributedZones
 }
 
+// UpdateGolden returns whether golden files are regenerated instead of compared.
+func UpdateGolden() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return updateGolden
+}
+
 // PrepareLicenseSecret creates a Secret manifest for the license key.
 func PrepareLicenseSecret(namespace string) (string, error) {
 	if LicenseFile() == "" {
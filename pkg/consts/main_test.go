@@ -17,6 +17,13 @@ func TestReportLocation(t *testing.T) {
 	assert.Equal(t, testValue, result, "ReportLocation should return the set value")
 }
 
+func TestUpdateGolden(t *testing.T) {
+	defer SetUpdateGolden(false)
+
+	SetUpdateGolden(true)
+	assert.True(t, UpdateGolden(), "UpdateGolden should return the set value")
+}
+
 func TestEnvK8SDistro(t *testing.T) {
 	testValue := "test-distro"
 
@@ -97,6 +104,13 @@ func TestVMSelectHostWithoutNamespace(t *testing.T) {
 	assert.Equal(t, expectedHost, result)
 }
 
+func TestVMAlertHost(t *testing.T) {
+	SetNginxHost("198.51.100.1")
+
+	assert.Equal(t, "vmalert-prod.198.51.100.1.nip.io", VMAlertHost("prod"))
+	assert.Equal(t, "vmalert.198.51.100.1.nip.io", VMAlertHost(""))
+}
+
 func TestVMHostsWithEmptyNginxHost(t *testing.T) {
 	// Reset nginx host to empty
 	SetNginxHost("")
@@ -0,0 +1,170 @@
+package datadog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// Compression is the Content-Encoding of request bodies.
+type Compression string
+
+const (
+	CompressionNone    Compression = ""
+	CompressionGzip    Compression = "gzip"
+	CompressionDeflate Compression = "deflate"
+	CompressionZstd    Compression = "zstd"
+)
+
+// VMSingleURL returns the Datadog API base URL of a VMSingle instance at host.
+func VMSingleURL(host string) string {
+	return fmt.Sprintf("http://%s/datadog", host)
+}
+
+// VMAgentURL returns the Datadog API base URL of a VMAgent instance at host.
+func VMAgentURL(host string) string {
+	return fmt.Sprintf("http://%s/datadog", host)
+}
+
+// VMInsertURL returns the Datadog API base URL of vminsert at host for the given tenant.
+func VMInsertURL(host string, tenantID int) string {
+	return fmt.Sprintf("http://%s/insert/%d/datadog", host, tenantID)
+}
+
+// Options configures how the Client sends data.
+type Options struct {
+	// Compression of request bodies. Defaults to no compression.
+	Compression Compression
+	// APIKey is sent as the DD-API-KEY header if not empty.
+	APIKey string
+	// Headers are added to every request, e.g. Authorization.
+	Headers http.Header
+}
+
+// Client submits data using the Datadog agent API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	opts       Options
+}
+
+// NewClient creates a new Client sending data to the Datadog API at baseURL, e.g. VMSingleURL(host).
+func NewClient(httpClient *http.Client, baseURL string, opts Options) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		opts:       opts,
+	}
+}
+
+// SubmitSeriesV1 sends metrics to /api/v1/series and returns the response status code.
+func (c *Client) SubmitSeriesV1(ctx context.Context, metrics ...Metric) (int, error) {
+	body, err := EncodeV1(metrics)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode datadog v1 series: %w", err)
+	}
+	return c.post(ctx, "/api/v1/series", "application/json", body)
+}
+
+// SubmitSeriesV2 sends metrics to /api/v2/series and returns the response status code.
+func (c *Client) SubmitSeriesV2(ctx context.Context, metrics ...Metric) (int, error) {
+	body, err := EncodeV2(metrics)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode datadog v2 series: %w", err)
+	}
+	return c.post(ctx, "/api/v2/series", "application/json", body)
+}
+
+// SubmitSketches sends distribution sketches to /api/beta/sketches and returns the response status code.
+func (c *Client) SubmitSketches(ctx context.Context, sketches ...Sketch) (int, error) {
+	return c.post(ctx, "/api/beta/sketches", "application/x-protobuf", EncodeSketches(sketches))
+}
+
+// SubmitCheckRuns sends service checks to /api/v1/check_run and returns the response status code.
+func (c *Client) SubmitCheckRuns(ctx context.Context, checks ...CheckRun) (int, error) {
+	body, err := EncodeCheckRuns(checks)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode datadog check runs: %w", err)
+	}
+	return c.post(ctx, "/api/v1/check_run", "application/json", body)
+}
+
+// post sends body to path and returns the response status code, or 0 if no response was received.
+// A non-2xx response is returned as an error.
+func (c *Client) post(ctx context.Context, path, contentType string, body []byte) (int, error) {
+	body, err := compress(body, c.opts.Compression)
+	if err != nil {
+		return 0, err
+	}
+
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create datadog request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.opts.Compression != CompressionNone {
+		req.Header.Set("Content-Encoding", string(c.opts.Compression))
+	}
+	if c.opts.APIKey != "" {
+		req.Header.Set("DD-API-KEY", c.opts.APIKey)
+	}
+	for name, values := range c.opts.Headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, ingest.MaxErrorBodySize))
+		return resp.StatusCode, fmt.Errorf("datadog request to %s failed with status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func compress(data []byte, compression Compression) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionDeflate:
+		w = zlib.NewWriter(&buf)
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create zstd writer: %w", err)
+		}
+		w = zw
+	default:
+		return nil, fmt.Errorf("unsupported datadog compression %q", compression)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("cannot compress datadog payload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("cannot compress datadog payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
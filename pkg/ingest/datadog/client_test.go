@@ -0,0 +1,226 @@
+package datadog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var testMetric = Metric{
+	Name:     "system.load.1",
+	Type:     MetricTypeGauge,
+	Points:   []Point{{Time: time.Unix(1700000000, 0), Value: 1.5}},
+	Tags:     []string{"env:prod"},
+	Host:     "web-1",
+	Interval: 10,
+}
+
+func TestEncodeV1(t *testing.T) {
+	t.Parallel()
+	data, err := EncodeV1([]Metric{testMetric})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"series":[{
+		"metric":"system.load.1","points":[[1700000000,1.5]],"tags":["env:prod"],
+		"host":"web-1","type":"gauge","interval":10
+	}]}`, string(data))
+}
+
+func TestEncodeV2(t *testing.T) {
+	t.Parallel()
+	m := testMetric
+	m.Type = MetricTypeCount
+	m.Device = "sda"
+	data, err := EncodeV2([]Metric{m})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"series":[{
+		"metric":"system.load.1","type":1,"points":[{"timestamp":1700000000,"value":1.5}],"tags":["env:prod"],
+		"resources":[{"name":"web-1","type":"host"},{"name":"sda","type":"device"}],"interval":10
+	}]}`, string(data))
+}
+
+func TestEncodeCheckRuns(t *testing.T) {
+	t.Parallel()
+	data, err := EncodeCheckRuns([]CheckRun{{
+		Check:   "app.ok",
+		Host:    "web-1",
+		Status:  CheckStatusCritical,
+		Time:    time.Unix(1700000000, 0),
+		Message: "down",
+		Tags:    []string{"env:prod"},
+	}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"check":"app.ok","host_name":"web-1","status":2,"timestamp":1700000000,"message":"down","tags":["env:prod"]}]`, string(data))
+}
+
+func TestEncodeSketches(t *testing.T) {
+	t.Parallel()
+	data := EncodeSketches([]Sketch{{
+		Name:   "latency",
+		Host:   "web-1",
+		Tags:   []string{"env:prod", "canary"},
+		Time:   time.Unix(1700000000, 0),
+		Values: []float64{0, 1, 1, -2},
+	}})
+
+	sketches := protoFields(t, data)
+	require.Len(t, sketches[sketchPayloadSketches], 1)
+	sketch := protoFields(t, sketches[sketchPayloadSketches][0])
+	assert.Equal(t, "latency", string(sketch[sketchMetric][0]))
+	assert.Equal(t, "web-1", string(sketch[sketchHost][0]))
+	assert.Equal(t, []string{"env:prod", "canary"}, []string{string(sketch[sketchTags][0]), string(sketch[sketchTags][1])})
+	require.Len(t, sketch[sketchDogsketches], 1)
+
+	ds := protoFields(t, sketch[sketchDogsketches][0])
+	ts, _ := protowire.ConsumeVarint(ds[dogsketchTs][0])
+	assert.EqualValues(t, 1700000000, ts)
+	cnt, _ := protowire.ConsumeVarint(ds[dogsketchCnt][0])
+	assert.EqualValues(t, 4, cnt)
+	for num, want := range map[protowire.Number]float64{dogsketchMin: -2, dogsketchMax: 1, dogsketchAvg: 0, dogsketchSum: 0} {
+		v, _ := protowire.ConsumeFixed64(ds[num][0])
+		assert.Equal(t, want, math.Float64frombits(v), "field %d", num)
+	}
+
+	var keys []int32
+	for b := ds[dogsketchK][0]; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		keys = append(keys, int32(protowire.DecodeZigZag(v)))
+		b = b[n:]
+	}
+	var counts []uint64
+	for b := ds[dogsketchN][0]; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		counts = append(counts, v)
+		b = b[n:]
+	}
+	assert.Equal(t, []int32{-sketchKey(2), 0, sketchKey(1)}, keys)
+	assert.Equal(t, []uint64{1, 1, 2}, counts)
+}
+
+func TestSketchKey(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, int32(0), sketchKey(0))
+	assert.Equal(t, int32(0), sketchKey(1e-12))
+	assert.Equal(t, -sketchKey(5), sketchKey(-5))
+	assert.Less(t, sketchKey(1), sketchKey(1.1), "Values further apart than the relative accuracy should get different bins")
+	assert.Equal(t, sketchKey(100), sketchKey(100.1))
+}
+
+// protoFields returns the raw values of top-level fields of a protobuf message by field number.
+func protoFields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	t.Helper()
+	fields := map[protowire.Number][][]byte{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			v = b[:n]
+		}
+		require.GreaterOrEqual(t, n, 0)
+		fields[num] = append(fields[num], v)
+		b = b[n:]
+	}
+	return fields
+}
+
+func TestClientSubmit(t *testing.T) {
+	t.Parallel()
+	type request struct {
+		path, contentType, encoding, apiKey string
+		body                                []byte
+	}
+	requests := make(chan request, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- request{r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"), r.Header.Get("DD-API-KEY"), body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	host := server.Listener.Addr().String()
+
+	client := NewClient(server.Client(), VMInsertURL(host, 2), Options{Compression: CompressionZstd, APIKey: "secret"})
+	status, err := client.SubmitSeriesV2(ctx, testMetric)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, status)
+	req := <-requests
+	assert.Equal(t, "/insert/2/datadog/api/v2/series", req.path)
+	assert.Equal(t, "application/json", req.contentType)
+	assert.Equal(t, "zstd", req.encoding)
+	assert.Equal(t, "secret", req.apiKey)
+	zr, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	body, err := zr.DecodeAll(req.body, nil)
+	require.NoError(t, err)
+	want, err := EncodeV2([]Metric{testMetric})
+	require.NoError(t, err)
+	assert.Equal(t, want, body)
+
+	client = NewClient(server.Client(), VMSingleURL(host), Options{Compression: CompressionDeflate})
+	_, err = client.SubmitSeriesV1(ctx, testMetric)
+	require.NoError(t, err)
+	req = <-requests
+	assert.Equal(t, "/datadog/api/v1/series", req.path)
+	assert.Equal(t, "deflate", req.encoding)
+	assert.Empty(t, req.apiKey)
+	zlr, err := zlib.NewReader(bytes.NewReader(req.body))
+	require.NoError(t, err)
+	body, err = io.ReadAll(zlr)
+	require.NoError(t, err)
+	var v1 v1Series
+	require.NoError(t, json.Unmarshal(body, &v1))
+	assert.Equal(t, "system.load.1", v1.Series[0].Metric)
+
+	client = NewClient(server.Client(), VMAgentURL(host), Options{})
+	_, err = client.SubmitSketches(ctx, Sketch{Name: "s", Values: []float64{1}})
+	require.NoError(t, err)
+	req = <-requests
+	assert.Equal(t, "/datadog/api/beta/sketches", req.path)
+	assert.Equal(t, "application/x-protobuf", req.contentType)
+	assert.Empty(t, req.encoding)
+
+	_, err = client.SubmitCheckRuns(ctx, CheckRun{Check: "c", Status: CheckStatusOK})
+	require.NoError(t, err)
+	req = <-requests
+	assert.Equal(t, "/datadog/api/v1/check_run", req.path)
+}
+
+func TestClientSubmitError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "cannot parse series", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleURL(server.Listener.Addr().String()), Options{})
+	status, err := client.SubmitSeriesV1(context.Background(), testMetric)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, err.Error(), "status 400")
+	assert.Contains(t, err.Error(), "cannot parse series")
+
+	client = NewClient(server.Client(), VMSingleURL(server.Listener.Addr().String()), Options{Compression: "brotli"})
+	status, err = client.SubmitSeriesV1(context.Background(), testMetric)
+	require.Error(t, err)
+	assert.Zero(t, status)
+	assert.Contains(t, err.Error(), "unsupported datadog compression")
+}
@@ -0,0 +1,181 @@
+package datadog
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// MetricType is the Datadog metric type.
+type MetricType string
+
+const (
+	MetricTypeGauge MetricType = "gauge"
+	MetricTypeCount MetricType = "count"
+	MetricTypeRate  MetricType = "rate"
+)
+
+// v2 returns the metric type enum used by the v2 series API.
+func (t MetricType) v2() int {
+	switch t {
+	case MetricTypeCount:
+		return 1
+	case MetricTypeRate:
+		return 2
+	case MetricTypeGauge:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Point is a single metric value.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Metric is a Datadog metric submitted via the v1 or v2 series API.
+type Metric struct {
+	Name   string
+	Type   MetricType
+	Points []Point
+	// Tags are Datadog tags in the key:value form. Tags without a value are allowed.
+	Tags   []string
+	Host   string
+	Device string
+	// Interval is the interval in seconds for count and rate metrics.
+	Interval int64
+}
+
+// Sketch is a distribution metric submitted via the sketches API.
+// Values are compressed into a single dogsketch.
+type Sketch struct {
+	Name   string
+	Host   string
+	Tags   []string
+	Time   time.Time
+	Values []float64
+}
+
+// CheckStatus is the status of a service check.
+type CheckStatus int
+
+const (
+	CheckStatusOK       CheckStatus = 0
+	CheckStatusWarning  CheckStatus = 1
+	CheckStatusCritical CheckStatus = 2
+	CheckStatusUnknown  CheckStatus = 3
+)
+
+// CheckRun is a service check result.
+type CheckRun struct {
+	Check   string
+	Host    string
+	Status  CheckStatus
+	Time    time.Time
+	Message string
+	Tags    []string
+}
+
+// NoLabelValue is the label value VictoriaMetrics uses for tags without a value.
+const NoLabelValue = "no_label_value"
+
+var (
+	unsupportedNameChars = regexp.MustCompile(`[^0-9a-zA-Z_.]+`)
+	multipleUnderscores  = regexp.MustCompile(`_+`)
+	underscoresAroundDot = regexp.MustCompile(`_?\._?`)
+)
+
+// SanitizeMetricName applies the Datadog metric naming rules VictoriaMetrics follows
+// with the default -datadog.sanitizeMetricName=true: unsupported characters are replaced with underscores,
+// repeated underscores are collapsed and underscores around dots are removed.
+func SanitizeMetricName(name string) string {
+	name = unsupportedNameChars.ReplaceAllString(name, "_")
+	name = multipleUnderscores.ReplaceAllString(name, "_")
+	return underscoresAroundDot.ReplaceAllString(name, ".")
+}
+
+// SplitTag splits a Datadog tag into a label name and value.
+// Tags without a value get NoLabelValue.
+func SplitTag(tag string) (string, string) {
+	name, value, ok := strings.Cut(tag, ":")
+	if !ok {
+		return tag, NoLabelValue
+	}
+	return name, value
+}
+
+// sketchQuantiles are the quantiles VictoriaMetrics calculates for every sketch.
+var sketchQuantiles = []string{"0.5", "0.75", "0.9", "0.95", "0.99"}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for metrics submitted via the v1 or v2 series API.
+func ExpectedSeries(metrics []Metric) []ingest.Series {
+	var series []ingest.Series
+	for _, m := range metrics {
+		if len(m.Points) == 0 {
+			continue
+		}
+		labels := tagLabels(m.Tags, m.Host)
+		if m.Device != "" {
+			labels["device"] = m.Device
+		}
+		series = append(series, ingest.Series{
+			Name:   SanitizeMetricName(m.Name),
+			Labels: labels,
+			Value:  latest(m.Points),
+		})
+	}
+	return series
+}
+
+// ExpectedSketchSeries returns the series VictoriaMetrics creates for sketches:
+// <name>_sum, <name>_count and <name>{quantile="..."}.
+// Quantile values depend on sketch bin interpolation, so their Value is NaN.
+func ExpectedSketchSeries(sketches []Sketch) []ingest.Series {
+	var series []ingest.Series
+	for _, s := range sketches {
+		name := SanitizeMetricName(s.Name)
+		ds := newDogsketch(s.Values)
+		series = append(series,
+			ingest.Series{Name: name + "_sum", Labels: tagLabels(s.Tags, s.Host), Value: ds.sum},
+			ingest.Series{Name: name + "_count", Labels: tagLabels(s.Tags, s.Host), Value: float64(ds.count)},
+		)
+		for _, q := range sketchQuantiles {
+			labels := tagLabels(s.Tags, s.Host)
+			labels["quantile"] = q
+			series = append(series, ingest.Series{Name: name, Labels: labels, Value: math.NaN()})
+		}
+	}
+	return series
+}
+
+// tagLabels converts tags to labels. A host tag is renamed to exported_host,
+// since the host label is reserved for the submitting host.
+func tagLabels(tags []string, host string) map[string]string {
+	labels := make(map[string]string, len(tags)+1)
+	for _, tag := range tags {
+		name, value := SplitTag(tag)
+		if name == "host" {
+			name = "exported_host"
+		}
+		labels[name] = value
+	}
+	if host != "" {
+		labels["host"] = host
+	}
+	return labels
+}
+
+func latest(points []Point) float64 {
+	last := points[0]
+	for _, p := range points[1:] {
+		if !p.Time.Before(last.Time) {
+			last = p
+		}
+	}
+	return last.Value
+}
@@ -0,0 +1,78 @@
+package datadog
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeMetricName(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{
+		"system.load.1":        "system.load.1",
+		"my-metric name":       "my_metric_name",
+		"foo__bar":             "foo_bar",
+		"foo_.bar":             "foo.bar",
+		"foo._bar":             "foo.bar",
+		"http.requests{total}": "http.requests_total_",
+	} {
+		assert.Equal(t, want, SanitizeMetricName(name), name)
+	}
+}
+
+func TestSplitTag(t *testing.T) {
+	t.Parallel()
+	name, value := SplitTag("env:prod")
+	assert.Equal(t, "env", name)
+	assert.Equal(t, "prod", value)
+
+	name, value = SplitTag("url:http://foo")
+	assert.Equal(t, "url", name)
+	assert.Equal(t, "http://foo", value, "Only the first colon should separate the value")
+
+	name, value = SplitTag("canary")
+	assert.Equal(t, "canary", name)
+	assert.Equal(t, NoLabelValue, value)
+}
+
+func TestExpectedSeries(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	series := ExpectedSeries([]Metric{
+		{
+			Name:   "app.requests-total",
+			Type:   MetricTypeCount,
+			Points: []Point{{Time: now, Value: 5}, {Time: now.Add(-time.Minute), Value: 3}},
+			Tags:   []string{"env:prod", "canary", "host:lb"},
+			Host:   "web-1",
+			Device: "eth0",
+		},
+		{Name: "empty"},
+	})
+	require.Len(t, series, 1)
+	assert.Equal(t, "app.requests_total", series[0].Name)
+	assert.Equal(t, map[string]string{"env": "prod", "canary": NoLabelValue, "exported_host": "lb", "host": "web-1", "device": "eth0"}, series[0].Labels)
+	assert.Equal(t, 5.0, series[0].Value, "The latest point should be expected")
+}
+
+func TestExpectedSketchSeries(t *testing.T) {
+	t.Parallel()
+	series := ExpectedSketchSeries([]Sketch{{Name: "latency", Host: "h", Tags: []string{"env:e2e"}, Values: []float64{1, 2, 3}}})
+	require.Len(t, series, 2+len(sketchQuantiles))
+
+	assert.Equal(t, "latency_sum", series[0].Name)
+	assert.Equal(t, 6.0, series[0].Value)
+	assert.Equal(t, "latency_count", series[1].Name)
+	assert.Equal(t, 3.0, series[1].Value)
+	assert.Equal(t, map[string]string{"env": "e2e", "host": "h"}, series[1].Labels)
+
+	for i, q := range sketchQuantiles {
+		s := series[2+i]
+		assert.Equal(t, "latency", s.Name)
+		assert.Equal(t, q, s.Labels["quantile"])
+		assert.True(t, math.IsNaN(s.Value))
+	}
+}
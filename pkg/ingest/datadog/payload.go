@@ -0,0 +1,243 @@
+package datadog
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type v1Series struct {
+	Series []v1Metric `json:"series"`
+}
+
+type v1Metric struct {
+	Metric   string       `json:"metric"`
+	Points   [][2]float64 `json:"points"`
+	Tags     []string     `json:"tags,omitempty"`
+	Host     string       `json:"host,omitempty"`
+	Device   string       `json:"device,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Interval int64        `json:"interval,omitempty"`
+}
+
+// EncodeV1 encodes metrics as a /api/v1/series JSON payload.
+func EncodeV1(metrics []Metric) ([]byte, error) {
+	payload := v1Series{Series: make([]v1Metric, 0, len(metrics))}
+	for _, m := range metrics {
+		points := make([][2]float64, 0, len(m.Points))
+		for _, p := range m.Points {
+			points = append(points, [2]float64{float64(p.Time.Unix()), p.Value})
+		}
+		payload.Series = append(payload.Series, v1Metric{
+			Metric:   m.Name,
+			Points:   points,
+			Tags:     m.Tags,
+			Host:     m.Host,
+			Device:   m.Device,
+			Type:     string(m.Type),
+			Interval: m.Interval,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+type v2Series struct {
+	Series []v2Metric `json:"series"`
+}
+
+type v2Metric struct {
+	Metric    string       `json:"metric"`
+	Type      int          `json:"type"`
+	Points    []v2Point    `json:"points"`
+	Tags      []string     `json:"tags,omitempty"`
+	Resources []v2Resource `json:"resources,omitempty"`
+	Interval  int64        `json:"interval,omitempty"`
+}
+
+type v2Point struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type v2Resource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// EncodeV2 encodes metrics as a /api/v2/series JSON payload. Host and device are sent as resources.
+func EncodeV2(metrics []Metric) ([]byte, error) {
+	payload := v2Series{Series: make([]v2Metric, 0, len(metrics))}
+	for _, m := range metrics {
+		points := make([]v2Point, 0, len(m.Points))
+		for _, p := range m.Points {
+			points = append(points, v2Point{Timestamp: p.Time.Unix(), Value: p.Value})
+		}
+		var resources []v2Resource
+		if m.Host != "" {
+			resources = append(resources, v2Resource{Name: m.Host, Type: "host"})
+		}
+		if m.Device != "" {
+			resources = append(resources, v2Resource{Name: m.Device, Type: "device"})
+		}
+		payload.Series = append(payload.Series, v2Metric{
+			Metric:    m.Name,
+			Type:      m.Type.v2(),
+			Points:    points,
+			Tags:      m.Tags,
+			Resources: resources,
+			Interval:  m.Interval,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+type checkRun struct {
+	Check     string   `json:"check"`
+	HostName  string   `json:"host_name"`
+	Status    int      `json:"status"`
+	Timestamp int64    `json:"timestamp"`
+	Message   string   `json:"message,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// EncodeCheckRuns encodes service checks as a /api/v1/check_run JSON payload.
+func EncodeCheckRuns(checks []CheckRun) ([]byte, error) {
+	payload := make([]checkRun, 0, len(checks))
+	for _, c := range checks {
+		payload = append(payload, checkRun{
+			Check:     c.Check,
+			HostName:  c.Host,
+			Status:    int(c.Status),
+			Timestamp: c.Time.Unix(),
+			Message:   c.Message,
+			Tags:      c.Tags,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+// Field numbers of the datadog.agentpayload.SketchPayload message and its children.
+// See https://github.com/DataDog/agent-payload/blob/master/proto/metrics/agent_payload.proto
+const (
+	sketchPayloadSketches = 1
+
+	sketchMetric      = 1
+	sketchHost        = 2
+	sketchTags        = 4
+	sketchDogsketches = 7
+
+	dogsketchTs  = 1
+	dogsketchCnt = 2
+	dogsketchMin = 3
+	dogsketchMax = 4
+	dogsketchAvg = 5
+	dogsketchSum = 6
+	dogsketchK   = 7
+	dogsketchN   = 8
+)
+
+// EncodeSketches encodes sketches as a /api/beta/sketches protobuf payload.
+func EncodeSketches(sketches []Sketch) []byte {
+	var payload []byte
+	for _, s := range sketches {
+		var sketch []byte
+		sketch = protowire.AppendTag(sketch, sketchMetric, protowire.BytesType)
+		sketch = protowire.AppendString(sketch, s.Name)
+		if s.Host != "" {
+			sketch = protowire.AppendTag(sketch, sketchHost, protowire.BytesType)
+			sketch = protowire.AppendString(sketch, s.Host)
+		}
+		for _, tag := range s.Tags {
+			sketch = protowire.AppendTag(sketch, sketchTags, protowire.BytesType)
+			sketch = protowire.AppendString(sketch, tag)
+		}
+		sketch = protowire.AppendTag(sketch, sketchDogsketches, protowire.BytesType)
+		sketch = protowire.AppendBytes(sketch, newDogsketch(s.Values).marshal(s.Time.Unix()))
+
+		payload = protowire.AppendTag(payload, sketchPayloadSketches, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, sketch)
+	}
+	return payload
+}
+
+// DDSketch parameters used by the Datadog agent: 1/128 relative accuracy and the minimal indexable value.
+const (
+	sketchRelativeAccuracy = 1.0 / 128
+	sketchMinValue         = 1e-9
+)
+
+var (
+	sketchGammaLn = math.Log((1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy))
+	sketchBias    = 1 - int32(math.Floor(math.Log(sketchMinValue)/sketchGammaLn))
+)
+
+// dogsketch is a DDSketch of values in the Datadog agent wire format.
+type dogsketch struct {
+	count              int64
+	min, max, avg, sum float64
+	keys               []int32
+	counts             []uint32
+}
+
+func newDogsketch(values []float64) dogsketch {
+	ds := dogsketch{count: int64(len(values))}
+	if len(values) == 0 {
+		return ds
+	}
+	ds.min, ds.max = math.Inf(1), math.Inf(-1)
+	bins := map[int32]uint32{}
+	for _, v := range values {
+		ds.sum += v
+		ds.min = math.Min(ds.min, v)
+		ds.max = math.Max(ds.max, v)
+		bins[sketchKey(v)]++
+	}
+	ds.avg = ds.sum / float64(len(values))
+	for k := range bins {
+		ds.keys = append(ds.keys, k)
+	}
+	sort.Slice(ds.keys, func(i, j int) bool { return ds.keys[i] < ds.keys[j] })
+	for _, k := range ds.keys {
+		ds.counts = append(ds.counts, bins[k])
+	}
+	return ds
+}
+
+// sketchKey returns the DDSketch bin of v. Values close to zero share the zero bin.
+func sketchKey(v float64) int32 {
+	switch {
+	case v < 0:
+		return -sketchKey(-v)
+	case v < sketchMinValue:
+		return 0
+	default:
+		return int32(math.Ceil(math.Log(v)/sketchGammaLn)) + sketchBias
+	}
+}
+
+func (ds dogsketch) marshal(ts int64) []byte {
+	var dst []byte
+	dst = protowire.AppendTag(dst, dogsketchTs, protowire.VarintType)
+	dst = protowire.AppendVarint(dst, uint64(ts))
+	dst = protowire.AppendTag(dst, dogsketchCnt, protowire.VarintType)
+	dst = protowire.AppendVarint(dst, uint64(ds.count))
+	for _, f := range []struct {
+		num protowire.Number
+		v   float64
+	}{{dogsketchMin, ds.min}, {dogsketchMax, ds.max}, {dogsketchAvg, ds.avg}, {dogsketchSum, ds.sum}} {
+		dst = protowire.AppendTag(dst, f.num, protowire.Fixed64Type)
+		dst = protowire.AppendFixed64(dst, math.Float64bits(f.v))
+	}
+
+	var keys, counts []byte
+	for i := range ds.keys {
+		keys = protowire.AppendVarint(keys, protowire.EncodeZigZag(int64(ds.keys[i])))
+		counts = protowire.AppendVarint(counts, uint64(ds.counts[i]))
+	}
+	dst = protowire.AppendTag(dst, dogsketchK, protowire.BytesType)
+	dst = protowire.AppendBytes(dst, keys)
+	dst = protowire.AppendTag(dst, dogsketchN, protowire.BytesType)
+	return protowire.AppendBytes(dst, counts)
+}
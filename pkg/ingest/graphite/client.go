@@ -0,0 +1,118 @@
+package graphite
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// DefaultPort is the port of the Graphite plaintext protocol.
+const DefaultPort = 2003
+
+// Metric is a single Graphite plaintext sample. Tags turn it into a tagged series.
+type Metric struct {
+	Path  string
+	Tags  map[string]string
+	Value float64
+	// Time is sent with second precision. Zero time is omitted, so the server uses the receive time.
+	Time time.Time
+}
+
+// AppendLine appends the plaintext line of the metric, e.g. "foo.bar;env=prod 1.5 1700000000\n".
+func (m Metric) AppendLine(dst []byte) ([]byte, error) {
+	if m.Path == "" || strings.ContainsAny(m.Path, " ;\n") {
+		return dst, fmt.Errorf("invalid graphite path %q", m.Path)
+	}
+	dst = append(dst, m.Path...)
+	for _, k := range ingest.SortedKeys(m.Tags) {
+		v := m.Tags[k]
+		if k == "" || strings.ContainsAny(k, " ;=\n") || v == "" || strings.ContainsAny(v, " ;~\n") {
+			return dst, fmt.Errorf("invalid graphite tag %q=%q", k, v)
+		}
+		dst = append(dst, ';')
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst = append(dst, v...)
+	}
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, m.Value, 'g', -1, 64)
+	if !m.Time.IsZero() {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, m.Time.Unix(), 10)
+	}
+	return append(dst, '\n'), nil
+}
+
+// Encode encodes metrics as plaintext lines.
+func Encode(metrics []Metric) ([]byte, error) {
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = m.AppendLine(buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// Client sends metrics using the Graphite plaintext protocol over TCP or UDP.
+type Client struct {
+	network string
+	addr    string
+	dialer  net.Dialer
+}
+
+// NewClient creates a new Client sending metrics to addr over network, which is "tcp" or "udp".
+func NewClient(network, addr string) *Client {
+	return &Client{
+		network: network,
+		addr:    addr,
+	}
+}
+
+// Send opens a connection, writes metrics and closes it.
+// Over UDP every call is a single datagram, so keep batches small.
+func (c *Client) Send(ctx context.Context, metrics ...Metric) error {
+	data, err := Encode(metrics)
+	if err != nil {
+		return err
+	}
+	return c.SendRaw(ctx, data)
+}
+
+// SendRaw writes already encoded lines, e.g. to check how malformed lines are handled.
+func (c *Client) SendRaw(ctx context.Context, data []byte) error {
+	conn, err := c.dialer.DialContext(ctx, c.network, c.addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to graphite listener at %s: %w", c.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	if _, err := conn.Write(data); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("cannot write to graphite listener at %s: %w", c.addr, err)
+	}
+	return conn.Close()
+}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for metrics: the path becomes
+// the metric name as is and tags become labels. For repeated series the latest value wins.
+func ExpectedSeries(metrics []Metric) []ingest.Series {
+	series := make([]ingest.Series, 0, len(metrics))
+	times := make([]time.Time, 0, len(metrics))
+	for _, m := range metrics {
+		s := ingest.Series{Name: m.Path, Labels: make(map[string]string, len(m.Tags)), Value: m.Value}
+		for k, v := range m.Tags {
+			s.Labels[k] = v
+		}
+		series = append(series, s)
+		times = append(times, m.Time)
+	}
+	return ingest.LatestSeries(series, times)
+}
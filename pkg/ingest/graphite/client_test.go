@@ -0,0 +1,102 @@
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	data, err := Encode([]Metric{
+		{Path: "servers.web1.cpu", Value: 1.5, Time: time.Unix(1700000000, 0)},
+		{Path: "disk.used", Tags: map[string]string{"host": "web1", "dc": "eu"}, Value: 42},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "servers.web1.cpu 1.5 1700000000\ndisk.used;dc=eu;host=web1 42\n", string(data))
+
+	_, err = Encode([]Metric{{Path: "bad path"}})
+	require.Error(t, err)
+	_, err = Encode([]Metric{{Path: "ok", Tags: map[string]string{"k": "a;b"}}})
+	require.Error(t, err)
+}
+
+func TestExpectedSeries(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	series := ExpectedSeries([]Metric{
+		{Path: "a.b", Tags: map[string]string{"env": "e2e"}, Value: 1, Time: now},
+		{Path: "a.b", Tags: map[string]string{"env": "e2e"}, Value: 2, Time: now.Add(time.Second)},
+		{Path: "a.b", Value: 3, Time: now},
+	})
+	require.Len(t, series, 2)
+	assert.Equal(t, "a.b", series[0].Name)
+	assert.Equal(t, 2.0, series[0].Value, "The latest value should win")
+	assert.Equal(t, `{__name__="a.b",env="e2e"}`, series[0].Selector())
+	assert.Equal(t, 3.0, series[1].Value)
+}
+
+func TestClientSendTCP(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+
+	client := NewClient("tcp", ln.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Send(ctx, Metric{Path: "a", Value: 1}, Metric{Path: "b", Value: 2}))
+
+	var got []string
+	for l := range lines {
+		got = append(got, l)
+	}
+	assert.Equal(t, []string{"a 1", "b 2"}, got)
+}
+
+func TestClientSendUDP(t *testing.T) {
+	t.Parallel()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := NewClient("udp", conn.LocalAddr().String())
+	require.NoError(t, client.Send(context.Background(), Metric{Path: "a", Tags: map[string]string{"x": "y"}, Value: 1}))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "a;x=y 1\n", string(buf[:n]))
+}
+
+func TestClientSendUnreachable(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	err = NewClient("tcp", addr).Send(context.Background(), Metric{Path: "a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot connect to graphite listener")
+}
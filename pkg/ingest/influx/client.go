@@ -0,0 +1,192 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// VMSingleURL returns the line protocol write URL of a VMSingle instance at host.
+func VMSingleURL(host string) string {
+	return fmt.Sprintf("http://%s/write", host)
+}
+
+// VMAgentURL returns the line protocol write URL of a VMAgent instance at host.
+func VMAgentURL(host string) string {
+	return fmt.Sprintf("http://%s/write", host)
+}
+
+// VMInsertURL returns the line protocol write URL of vminsert at host for the given tenant.
+func VMInsertURL(host string, tenantID int) string {
+	return fmt.Sprintf("http://%s/insert/%d/influx/write", host, tenantID)
+}
+
+// Options configures how the Client encodes and sends points.
+type Options struct {
+	// Precision of point timestamps. Defaults to nanoseconds.
+	Precision Precision
+	// Database is sent as the db query parameter. VictoriaMetrics stores it as the db label.
+	Database string
+	// ExtraLabels are sent as extra_label query parameters and added to every ingested series.
+	ExtraLabels map[string]string
+	// Gzip compresses request bodies.
+	Gzip bool
+	// Headers are added to every request, e.g. Authorization.
+	Headers http.Header
+}
+
+// Client writes points using the InfluxDB line protocol.
+type Client struct {
+	httpClient *http.Client
+	url        string
+	opts       Options
+}
+
+// NewClient creates a new Client sending points to writeURL.
+func NewClient(httpClient *http.Client, writeURL string, opts Options) *Client {
+	return &Client{
+		httpClient: httpClient,
+		url:        writeURL,
+		opts:       opts,
+	}
+}
+
+// Encode encodes points as newline-separated line protocol lines.
+func Encode(points []Point, precision Precision) ([]byte, error) {
+	var buf []byte
+	for _, p := range points {
+		var err error
+		if buf, err = p.AppendLine(buf, precision); err != nil {
+			return nil, err
+		}
+		buf = append(buf, '\n')
+	}
+	return buf, nil
+}
+
+// Write sends points in a single request and returns the response status code.
+// A non-2xx response is returned as an error.
+func (c *Client) Write(ctx context.Context, points ...Point) (int, error) {
+	body, err := Encode(points, c.opts.Precision)
+	if err != nil {
+		return 0, err
+	}
+	return c.WriteRaw(ctx, body)
+}
+
+// WriteRaw sends already encoded line protocol data, e.g. to check how malformed lines are handled,
+// and returns the response status code. The status code is 0 if no response was received.
+func (c *Client) WriteRaw(ctx context.Context, data []byte) (int, error) {
+	writeURL, err := c.writeURL()
+	if err != nil {
+		return 0, err
+	}
+
+	if c.opts.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return 0, fmt.Errorf("cannot compress influx payload: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return 0, fmt.Errorf("cannot compress influx payload: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, writeURL, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create influx write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for name, values := range c.opts.Headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, ingest.MaxErrorBodySize))
+		return resp.StatusCode, fmt.Errorf("influx write to %s failed with status %d: %s", c.url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func (c *Client) writeURL() (string, error) {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid influx write URL: %w", err)
+	}
+	q := u.Query()
+	if c.opts.Precision != "" {
+		q.Set("precision", string(c.opts.Precision))
+	}
+	if c.opts.Database != "" {
+		q.Set("db", c.opts.Database)
+	}
+	for _, k := range ingest.SortedKeys(c.opts.ExtraLabels) {
+		q.Add("extra_label", k+"="+c.opts.ExtraLabels[k])
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for the points with default flags:
+// one series named <measurement>_<field> per numeric or boolean field, labeled with point tags,
+// the db label and extra labels. String fields are not stored.
+func ExpectedSeries(points []Point, opts Options) []ingest.Series {
+	var series []ingest.Series
+	for _, p := range points {
+		for _, field := range ingest.SortedKeys(p.Fields) {
+			value, ok := numericValue(p.Fields[field])
+			if !ok {
+				continue
+			}
+			labels := make(map[string]string, len(p.Tags)+len(opts.ExtraLabels)+1)
+			for k, v := range p.Tags {
+				labels[k] = v
+			}
+			if opts.Database != "" {
+				labels["db"] = opts.Database
+			}
+			for k, v := range opts.ExtraLabels {
+				labels[k] = v
+			}
+			series = append(series, ingest.Series{
+				Name:   SeriesName(p.Measurement, field),
+				Labels: labels,
+				Value:  value,
+			})
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+// SeriesName returns the metric name VictoriaMetrics uses for the measurement field.
+func SeriesName(measurement, field string) string {
+	if measurement == "" {
+		return field
+	}
+	return measurement + "_" + field
+}
@@ -0,0 +1,122 @@
+package influx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	ts := time.Unix(1700000000, 123456789)
+	points := []Point{
+		{
+			Measurement: "cpu load",
+			Tags:        map[string]string{"host": "a,b", "region": "eu=1"},
+			Fields: map[string]any{
+				"value": 1.5,
+				"count": int64(3),
+				"total": uint64(7),
+				"ok":    true,
+				"note":  `say "hi"`,
+			},
+			Time: ts,
+		},
+		{Measurement: "mem", Fields: map[string]any{"used": 42}},
+	}
+
+	data, err := Encode(points, PrecisionMillisecond)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`cpu\ load,host=a\,b,region=eu\=1 count=3i,note="say \"hi\"",ok=true,total=7u,value=1.5 1700000000123`+"\n"+
+			"mem used=42i\n",
+		string(data))
+
+	data, err = Encode(points[:1], "")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), " 1700000000123456789\n", "Nanosecond precision should be the default")
+
+	_, err = Encode([]Point{{Measurement: "empty"}}, "")
+	require.Error(t, err)
+	_, err = Encode([]Point{{Measurement: "bad", Fields: map[string]any{"v": []int{1}}}}, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported field type")
+}
+
+func TestClientWrite(t *testing.T) {
+	t.Parallel()
+	point := Point{Measurement: "m", Tags: map[string]string{"foo": "bar"}, Fields: map[string]any{"value": 1.0}, Time: time.Unix(10, 0)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/insert/3/influx/write", r.URL.Path)
+		assert.Equal(t, "s", r.URL.Query().Get("precision"))
+		assert.Equal(t, "telegraf", r.URL.Query().Get("db"))
+		assert.Equal(t, []string{"env=e2e", "team=vm"}, r.URL.Query()["extra_label"])
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		zr, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		body, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Equal(t, "m,foo=bar value=1 10\n", string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	client := NewClient(server.Client(), VMInsertURL(host, 3), Options{
+		Precision:   PrecisionSecond,
+		Database:    "telegraf",
+		ExtraLabels: map[string]string{"team": "vm", "env": "e2e"},
+		Gzip:        true,
+		Headers:     http.Header{"Authorization": {"Bearer token"}},
+	})
+	status, err := client.Write(context.Background(), point)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestClientWrite_Error(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "cannot parse line", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleURL(server.Listener.Addr().String()), Options{})
+	status, err := client.WriteRaw(context.Background(), []byte("garbage"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, err.Error(), "status 400")
+	assert.Contains(t, err.Error(), "cannot parse line")
+}
+
+func TestExpectedSeries(t *testing.T) {
+	t.Parallel()
+	points := []Point{{
+		Measurement: "disk",
+		Tags:        map[string]string{"host": "a"},
+		Fields:      map[string]any{"used": int64(5), "healthy": false, "label": "text"},
+	}}
+	series := ExpectedSeries(points, Options{Database: "db1", ExtraLabels: map[string]string{"env": "e2e"}})
+
+	require.Len(t, series, 2, "String fields are not stored")
+	assert.Equal(t, "disk_healthy", series[0].Name)
+	assert.Equal(t, float64(0), series[0].Value)
+	assert.Equal(t, "disk_used", series[1].Name)
+	assert.Equal(t, float64(5), series[1].Value)
+	assert.Equal(t, map[string]string{"host": "a", "db": "db1", "env": "e2e"}, series[1].Labels)
+	assert.Equal(t, `{__name__="disk_used",db="db1",env="e2e",host="a"}`, series[1].Selector())
+
+	assert.Equal(t, "value", SeriesName("", "value"))
+}
@@ -0,0 +1,143 @@
+package influx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// Precision is the unit of point timestamps.
+type Precision string
+
+const (
+	PrecisionNanosecond  Precision = "ns"
+	PrecisionMicrosecond Precision = "us"
+	PrecisionMillisecond Precision = "ms"
+	PrecisionSecond      Precision = "s"
+	PrecisionMinute      Precision = "m"
+	PrecisionHour        Precision = "h"
+)
+
+func (p Precision) unit() time.Duration {
+	switch p {
+	case PrecisionMicrosecond:
+		return time.Microsecond
+	case PrecisionMillisecond:
+		return time.Millisecond
+	case PrecisionSecond:
+		return time.Second
+	case PrecisionMinute:
+		return time.Minute
+	case PrecisionHour:
+		return time.Hour
+	default:
+		return time.Nanosecond
+	}
+}
+
+// Point is a single InfluxDB line protocol point.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	// Fields maps field keys to values. Supported types are float64, float32, int, int64, uint64, bool and string.
+	Fields map[string]any
+	// Time is the point timestamp. If zero, the server assigns the current time.
+	Time time.Time
+}
+
+// AppendLine appends the point encoded as a line protocol line, without a trailing newline.
+func (p Point) AppendLine(dst []byte, precision Precision) ([]byte, error) {
+	if len(p.Fields) == 0 {
+		return dst, fmt.Errorf("point %q has no fields", p.Measurement)
+	}
+	dst = append(dst, escape(p.Measurement, ", ")...)
+	for _, k := range ingest.SortedKeys(p.Tags) {
+		dst = append(dst, ',')
+		dst = append(dst, escape(k, ",= ")...)
+		dst = append(dst, '=')
+		dst = append(dst, escape(p.Tags[k], ",= ")...)
+	}
+	for i, k := range ingest.SortedKeys(p.Fields) {
+		if i == 0 {
+			dst = append(dst, ' ')
+		} else {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, escape(k, ",= ")...)
+		dst = append(dst, '=')
+		var err error
+		if dst, err = appendFieldValue(dst, p.Fields[k]); err != nil {
+			return dst, fmt.Errorf("field %q of point %q: %w", k, p.Measurement, err)
+		}
+	}
+	if !p.Time.IsZero() {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, p.Time.UnixNano()/int64(precision.unit()), 10)
+	}
+	return dst, nil
+}
+
+func appendFieldValue(dst []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case float64:
+		return strconv.AppendFloat(dst, v, 'g', -1, 64), nil
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), 'g', -1, 32), nil
+	case int:
+		return append(strconv.AppendInt(dst, int64(v), 10), 'i'), nil
+	case int64:
+		return append(strconv.AppendInt(dst, v, 10), 'i'), nil
+	case uint64:
+		return append(strconv.AppendUint(dst, v, 10), 'u'), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case string:
+		dst = append(dst, '"')
+		dst = append(dst, escape(v, `"\`)...)
+		return append(dst, '"'), nil
+	default:
+		return dst, fmt.Errorf("unsupported field type %T", v)
+	}
+}
+
+// numericValue returns the value VictoriaMetrics stores for the field.
+// String fields are not stored.
+func numericValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return math.NaN(), false
+	}
+}
+
+func escape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
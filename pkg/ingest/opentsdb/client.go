@@ -0,0 +1,181 @@
+package opentsdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// DefaultTelnetPort is the port of the OpenTSDB telnet put protocol.
+const DefaultTelnetPort = 4242
+
+// HTTPURL returns the /api/put URL of an OpenTSDB HTTP listener at host.
+func HTTPURL(host string) string {
+	return fmt.Sprintf("http://%s/api/put", host)
+}
+
+// VMInsertHTTPURL returns the /api/put URL of a vminsert OpenTSDB HTTP listener at host for the given tenant.
+func VMInsertHTTPURL(host string, tenantID int) string {
+	return fmt.Sprintf("http://%s/insert/%d/opentsdb/api/put", host, tenantID)
+}
+
+// Point is a single OpenTSDB data point.
+type Point struct {
+	Metric string
+	Tags   map[string]string
+	Value  float64
+	// Time is sent with second precision.
+	Time time.Time
+}
+
+// AppendPut appends the telnet put command of the point, e.g. "put sys.cpu 1700000000 1.5 host=a\n".
+func (p Point) AppendPut(dst []byte) ([]byte, error) {
+	if p.Metric == "" || strings.ContainsAny(p.Metric, " \n") {
+		return dst, fmt.Errorf("invalid opentsdb metric %q", p.Metric)
+	}
+	dst = append(dst, "put "...)
+	dst = append(dst, p.Metric...)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, p.Time.Unix(), 10)
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, p.Value, 'g', -1, 64)
+	for _, k := range ingest.SortedKeys(p.Tags) {
+		v := p.Tags[k]
+		if k == "" || strings.ContainsAny(k, " =\n") || v == "" || strings.ContainsAny(v, " \n") {
+			return dst, fmt.Errorf("invalid opentsdb tag %q=%q", k, v)
+		}
+		dst = append(dst, ' ')
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst = append(dst, v...)
+	}
+	return append(dst, '\n'), nil
+}
+
+// EncodeTelnet encodes points as telnet put commands.
+func EncodeTelnet(points []Point) ([]byte, error) {
+	var buf []byte
+	for _, p := range points {
+		var err error
+		if buf, err = p.AppendPut(buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+type httpPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp int64             `json:"timestamp"`
+	Value     float64           `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// EncodeHTTP encodes points as an /api/put JSON array.
+func EncodeHTTP(points []Point) ([]byte, error) {
+	payload := make([]httpPoint, 0, len(points))
+	for _, p := range points {
+		tags := p.Tags
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		payload = append(payload, httpPoint{Metric: p.Metric, Timestamp: p.Time.Unix(), Value: p.Value, Tags: tags})
+	}
+	return json.Marshal(payload)
+}
+
+// TelnetClient sends points using the OpenTSDB telnet put protocol.
+type TelnetClient struct {
+	addr   string
+	dialer net.Dialer
+}
+
+// NewTelnetClient creates a new TelnetClient sending points to the TCP address addr.
+func NewTelnetClient(addr string) *TelnetClient {
+	return &TelnetClient{addr: addr}
+}
+
+// Put opens a connection, writes put commands for points and closes it.
+func (c *TelnetClient) Put(ctx context.Context, points ...Point) error {
+	data, err := EncodeTelnet(points)
+	if err != nil {
+		return err
+	}
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to opentsdb listener at %s: %w", c.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	if _, err := conn.Write(data); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("cannot write to opentsdb listener at %s: %w", c.addr, err)
+	}
+	return conn.Close()
+}
+
+// HTTPClient sends points to the OpenTSDB /api/put HTTP endpoint.
+type HTTPClient struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewHTTPClient creates a new HTTPClient sending points to putURL.
+func NewHTTPClient(httpClient *http.Client, putURL string) *HTTPClient {
+	return &HTTPClient{
+		httpClient: httpClient,
+		url:        putURL,
+	}
+}
+
+// Put sends points in a single request. A non-2xx response is returned as an error.
+func (c *HTTPClient) Put(ctx context.Context, points ...Point) error {
+	body, err := EncodeHTTP(points)
+	if err != nil {
+		return fmt.Errorf("cannot encode opentsdb points: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create opentsdb request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, ingest.MaxErrorBodySize))
+		return fmt.Errorf("opentsdb put to %s failed with status %d: %s", c.url, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for points: the metric becomes
+// the metric name as is and tags become labels. For repeated series the latest value wins.
+func ExpectedSeries(points []Point) []ingest.Series {
+	series := make([]ingest.Series, 0, len(points))
+	times := make([]time.Time, 0, len(points))
+	for _, p := range points {
+		s := ingest.Series{Name: p.Metric, Labels: make(map[string]string, len(p.Tags)), Value: p.Value}
+		for k, v := range p.Tags {
+			s.Labels[k] = v
+		}
+		series = append(series, s)
+		times = append(times, p.Time)
+	}
+	return ingest.LatestSeries(series, times)
+}
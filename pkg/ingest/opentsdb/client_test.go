@@ -0,0 +1,100 @@
+package opentsdb
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Unix(1700000000, 0)
+
+func TestEncodeTelnet(t *testing.T) {
+	t.Parallel()
+	data, err := EncodeTelnet([]Point{
+		{Metric: "sys.cpu.user", Tags: map[string]string{"host": "web1", "cpu": "0"}, Value: 42.5, Time: testTime},
+		{Metric: "sys.up", Value: 1, Time: testTime},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "put sys.cpu.user 1700000000 42.5 cpu=0 host=web1\nput sys.up 1700000000 1\n", string(data))
+
+	_, err = EncodeTelnet([]Point{{Metric: "m", Tags: map[string]string{"a=b": "c"}}})
+	require.Error(t, err)
+}
+
+func TestEncodeHTTP(t *testing.T) {
+	t.Parallel()
+	data, err := EncodeHTTP([]Point{{Metric: "sys.up", Value: 1, Time: testTime}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"metric":"sys.up","timestamp":1700000000,"value":1,"tags":{}}]`, string(data))
+}
+
+func TestTelnetClientPut(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+
+	require.NoError(t, NewTelnetClient(ln.Addr().String()).Put(context.Background(), Point{Metric: "m", Value: 1, Time: testTime}))
+	var got []string
+	for l := range lines {
+		got = append(got, l)
+	}
+	assert.Equal(t, []string{"put m 1700000000 1"}, got)
+}
+
+func TestHTTPClientPut(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/insert/1/opentsdb/api/put" {
+			http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"metric":"m","timestamp":1700000000,"value":2,"tags":{"a":"b"}}]`, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	point := Point{Metric: "m", Tags: map[string]string{"a": "b"}, Value: 2, Time: testTime}
+	require.NoError(t, NewHTTPClient(server.Client(), VMInsertHTTPURL(host, 1)).Put(context.Background(), point))
+
+	err := NewHTTPClient(server.Client(), HTTPURL(host)).Put(context.Background(), point)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
+
+func TestExpectedSeries(t *testing.T) {
+	t.Parallel()
+	series := ExpectedSeries([]Point{
+		{Metric: "sys.cpu", Tags: map[string]string{"host": "a"}, Value: 1, Time: testTime.Add(time.Second)},
+		{Metric: "sys.cpu", Tags: map[string]string{"host": "a"}, Value: 2, Time: testTime},
+	})
+	require.Len(t, series, 1)
+	assert.Equal(t, 1.0, series[0].Value, "An older point should not override the latest value")
+	assert.Equal(t, `{__name__="sys.cpu",host="a"}`, series[0].Selector())
+}
@@ -0,0 +1,272 @@
+package otlp
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
+)
+
+// Builder builds an OTLP metrics export request with a single resource and instrumentation scope.
+type Builder struct {
+	resourceAttrs map[string]string
+	scopeName     string
+	scopeVersion  string
+	scopeAttrs    map[string]string
+	start         time.Time
+	time          time.Time
+	metrics       []*metricspb.Metric
+}
+
+// NewBuilder creates a new Builder. Data points are timestamped with the current time unless WithTime is used.
+func NewBuilder() *Builder {
+	now := time.Now()
+	return &Builder{
+		resourceAttrs: map[string]string{},
+		scopeAttrs:    map[string]string{},
+		start:         now.Add(-time.Minute),
+		time:          now,
+	}
+}
+
+// WithResourceAttribute adds a resource attribute, e.g. service.name.
+func (b *Builder) WithResourceAttribute(key, value string) *Builder {
+	b.resourceAttrs[key] = value
+	return b
+}
+
+// WithScope sets the instrumentation scope name and version.
+func (b *Builder) WithScope(name, version string) *Builder {
+	b.scopeName = name
+	b.scopeVersion = version
+	return b
+}
+
+// WithScopeAttribute adds an instrumentation scope attribute.
+func (b *Builder) WithScopeAttribute(key, value string) *Builder {
+	b.scopeAttrs[key] = value
+	return b
+}
+
+// WithTime sets the timestamp of data points added after the call.
+// Cumulative points start a minute earlier.
+func (b *Builder) WithTime(t time.Time) *Builder {
+	b.time = t
+	b.start = t.Add(-time.Minute)
+	return b
+}
+
+// AddGauge adds a gauge with a single data point.
+func (b *Builder) AddGauge(name, unit string, value float64, attrs map[string]string) *Builder {
+	b.metrics = append(b.metrics, &metricspb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: []*metricspb.NumberDataPoint{b.numberPoint(value, attrs)},
+		}},
+	})
+	return b
+}
+
+// AddSum adds a cumulative sum with a single data point.
+// Monotonic sums are counters, non-monotonic sums are up-down counters.
+func (b *Builder) AddSum(name, unit string, value float64, monotonic bool, attrs map[string]string) *Builder {
+	p := b.numberPoint(value, attrs)
+	p.StartTimeUnixNano = uint64(b.start.UnixNano())
+	b.metrics = append(b.metrics, &metricspb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			DataPoints:             []*metricspb.NumberDataPoint{p},
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            monotonic,
+		}},
+	})
+	return b
+}
+
+// AddHistogram adds a cumulative explicit bucket histogram of d.
+func (b *Builder) AddHistogram(name, unit string, d remotewrite.Distribution, attrs map[string]string) *Builder {
+	cumulative := d.BucketCounts()
+	counts := make([]uint64, len(cumulative))
+	var prev float64
+	for i, c := range cumulative {
+		counts[i] = uint64(c - prev)
+		prev = c
+	}
+	sum := d.Sum()
+	p := &metricspb.HistogramDataPoint{
+		Attributes:        keyValues(attrs),
+		StartTimeUnixNano: uint64(b.start.UnixNano()),
+		TimeUnixNano:      uint64(b.time.UnixNano()),
+		Count:             uint64(len(d.Observations)),
+		Sum:               &sum,
+		BucketCounts:      counts,
+		ExplicitBounds:    d.Buckets,
+	}
+	if len(d.Observations) > 0 {
+		minV, maxV := minMax(d.Observations)
+		p.Min, p.Max = &minV, &maxV
+	}
+	b.metrics = append(b.metrics, &metricspb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			DataPoints:             []*metricspb.HistogramDataPoint{p},
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}},
+	})
+	return b
+}
+
+// AddExponentialHistogram adds a cumulative exponential histogram of the observations of d with the given scale.
+// Buckets of d are ignored.
+func (b *Builder) AddExponentialHistogram(name, unit string, scale int32, d remotewrite.Distribution, attrs map[string]string) *Builder {
+	var (
+		zeroCount          uint64
+		positive, negative = map[int32]uint64{}, map[int32]uint64{}
+	)
+	for _, v := range d.Observations {
+		switch {
+		case v > 0:
+			positive[ExponentialBucketIndex(v, scale)]++
+		case v < 0:
+			negative[ExponentialBucketIndex(-v, scale)]++
+		default:
+			zeroCount++
+		}
+	}
+	sum := d.Sum()
+	p := &metricspb.ExponentialHistogramDataPoint{
+		Attributes:        keyValues(attrs),
+		StartTimeUnixNano: uint64(b.start.UnixNano()),
+		TimeUnixNano:      uint64(b.time.UnixNano()),
+		Count:             uint64(len(d.Observations)),
+		Sum:               &sum,
+		Scale:             scale,
+		ZeroCount:         zeroCount,
+		Positive:          exponentialBuckets(positive),
+		Negative:          exponentialBuckets(negative),
+	}
+	if len(d.Observations) > 0 {
+		minV, maxV := minMax(d.Observations)
+		p.Min, p.Max = &minV, &maxV
+	}
+	b.metrics = append(b.metrics, &metricspb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+			DataPoints:             []*metricspb.ExponentialHistogramDataPoint{p},
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}},
+	})
+	return b
+}
+
+// AddSummary adds a summary of d with the given quantiles computed with d.Quantile.
+func (b *Builder) AddSummary(name, unit string, quantiles []float64, d remotewrite.Distribution, attrs map[string]string) *Builder {
+	p := &metricspb.SummaryDataPoint{
+		Attributes:        keyValues(attrs),
+		StartTimeUnixNano: uint64(b.start.UnixNano()),
+		TimeUnixNano:      uint64(b.time.UnixNano()),
+		Count:             uint64(len(d.Observations)),
+		Sum:               d.Sum(),
+	}
+	for _, q := range quantiles {
+		p.QuantileValues = append(p.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{Quantile: q, Value: d.Quantile(q)})
+	}
+	b.metrics = append(b.metrics, &metricspb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+			DataPoints: []*metricspb.SummaryDataPoint{p},
+		}},
+	})
+	return b
+}
+
+// Build returns the export request.
+func (b *Builder) Build() *colmetricspb.ExportMetricsServiceRequest {
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: &resourcepb.Resource{Attributes: keyValues(b.resourceAttrs)},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope: &commonpb.InstrumentationScope{
+					Name:       b.scopeName,
+					Version:    b.scopeVersion,
+					Attributes: keyValues(b.scopeAttrs),
+				},
+				Metrics: b.metrics,
+			}},
+		}},
+	}
+}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for the built request.
+func (b *Builder) ExpectedSeries(opts NamingOptions) []ingest.Series {
+	return ExpectedSeries(b.Build(), opts)
+}
+
+func (b *Builder) numberPoint(value float64, attrs map[string]string) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:   keyValues(attrs),
+		TimeUnixNano: uint64(b.time.UnixNano()),
+		Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// ExponentialBucketIndex returns the index of the exponential histogram bucket (base^i, base^(i+1)]
+// containing the positive value v, where base = 2^(2^-scale).
+func ExponentialBucketIndex(v float64, scale int32) int32 {
+	return int32(math.Ceil(math.Log2(v)*math.Exp2(float64(scale)))) - 1
+}
+
+// exponentialBuckets converts sparse bucket counts into a dense OTLP bucket range.
+func exponentialBuckets(counts map[int32]uint64) *metricspb.ExponentialHistogramDataPoint_Buckets {
+	if len(counts) == 0 {
+		return nil
+	}
+	indexes := make([]int32, 0, len(counts))
+	for i := range counts {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	offset := indexes[0]
+	dense := make([]uint64, indexes[len(indexes)-1]-offset+1)
+	for i, c := range counts {
+		dense[i-offset] = c
+	}
+	return &metricspb.ExponentialHistogramDataPoint_Buckets{Offset: offset, BucketCounts: dense}
+}
+
+func keyValues(attrs map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &commonpb.KeyValue{
+			Key:   k,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: attrs[k]}},
+		})
+	}
+	return kvs
+}
+
+func minMax(values []float64) (float64, float64) {
+	minV, maxV := values[0], values[0]
+	for _, v := range values[1:] {
+		minV = math.Min(minV, v)
+		maxV = math.Max(maxV, v)
+	}
+	return minV, maxV
+}
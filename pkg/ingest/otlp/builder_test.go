@@ -0,0 +1,72 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
+)
+
+func TestExponentialBucketIndex(t *testing.T) {
+	t.Parallel()
+	// With scale 0 buckets are (2^i, 2^(i+1)].
+	assert.Equal(t, int32(-1), ExponentialBucketIndex(1, 0))
+	assert.Equal(t, int32(0), ExponentialBucketIndex(1.5, 0))
+	assert.Equal(t, int32(0), ExponentialBucketIndex(2, 0))
+	assert.Equal(t, int32(1), ExponentialBucketIndex(3, 0))
+	// With scale 1 the base is sqrt(2).
+	assert.Equal(t, int32(1), ExponentialBucketIndex(2, 1))
+	assert.Equal(t, int32(2), ExponentialBucketIndex(2.5, 1))
+}
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+	ts := time.Unix(1700000000, 0)
+	d := remotewrite.Distribution{Buckets: []float64{1, 5}, Observations: []float64{0.5, 2, 3, 10}}
+	req := NewBuilder().
+		WithResourceAttribute("service.name", "checkout").
+		WithScope("e2e", "1.0").
+		WithScopeAttribute("library", "otel-go").
+		WithTime(ts).
+		AddGauge("temperature", "Cel", 21.5, map[string]string{"room": "a"}).
+		AddHistogram("latency", "s", d, nil).
+		AddExponentialHistogram("size", "By", 0, remotewrite.Distribution{Observations: []float64{0, 1.5, 3, 3, -1.5}}, nil).
+		AddSummary("duration", "s", []float64{0.5, 0.99}, d, nil).
+		Build()
+
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	assert.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	sm := rm.ScopeMetrics[0]
+	assert.Equal(t, "e2e", sm.Scope.Name)
+	assert.Equal(t, "1.0", sm.Scope.Version)
+	assert.Equal(t, "library", sm.Scope.Attributes[0].Key)
+	require.Len(t, sm.Metrics, 4)
+
+	gauge := sm.Metrics[0].GetGauge().DataPoints[0]
+	assert.Equal(t, 21.5, gauge.GetAsDouble())
+	assert.EqualValues(t, ts.UnixNano(), gauge.TimeUnixNano)
+
+	hist := sm.Metrics[1].GetHistogram().DataPoints[0]
+	assert.Equal(t, []uint64{1, 2, 1}, hist.BucketCounts, "Bucket counts should not be cumulative")
+	assert.Equal(t, []float64{1, 5}, hist.ExplicitBounds)
+	assert.EqualValues(t, 4, hist.Count)
+	assert.Equal(t, 15.5, hist.GetSum())
+	assert.Equal(t, 0.5, hist.GetMin())
+	assert.Equal(t, 10.0, hist.GetMax())
+	assert.EqualValues(t, ts.Add(-time.Minute).UnixNano(), hist.StartTimeUnixNano)
+
+	exp := sm.Metrics[2].GetExponentialHistogram().DataPoints[0]
+	assert.EqualValues(t, 1, exp.ZeroCount)
+	assert.EqualValues(t, 0, exp.Positive.Offset)
+	assert.Equal(t, []uint64{1, 2}, exp.Positive.BucketCounts)
+	assert.Equal(t, []uint64{1}, exp.Negative.BucketCounts)
+
+	summary := sm.Metrics[3].GetSummary().DataPoints[0]
+	require.Len(t, summary.QuantileValues, 2)
+	assert.Equal(t, d.Quantile(0.5), summary.QuantileValues[0].Value)
+	assert.Equal(t, 10.0, summary.QuantileValues[1].Value)
+}
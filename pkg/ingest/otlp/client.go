@@ -0,0 +1,129 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// Encoding is the OTLP/HTTP request body encoding.
+type Encoding string
+
+const (
+	EncodingProtobuf Encoding = "protobuf"
+	EncodingJSON     Encoding = "json"
+)
+
+// VMSingleURL returns the OTLP metrics URL of a VMSingle instance at host.
+func VMSingleURL(host string) string {
+	return fmt.Sprintf("http://%s/opentelemetry/v1/metrics", host)
+}
+
+// VMAgentURL returns the OTLP metrics URL of a VMAgent instance at host.
+func VMAgentURL(host string) string {
+	return fmt.Sprintf("http://%s/opentelemetry/v1/metrics", host)
+}
+
+// VMInsertURL returns the OTLP metrics URL of vminsert at host for the given tenant.
+func VMInsertURL(host string, tenantID int) string {
+	return fmt.Sprintf("http://%s/insert/%d/opentelemetry/v1/metrics", host, tenantID)
+}
+
+// Options configures how the Client encodes and sends requests.
+type Options struct {
+	// Encoding of request bodies. Defaults to protobuf.
+	Encoding Encoding
+	// Gzip compresses request bodies.
+	Gzip bool
+	// Headers are added to every request, e.g. Authorization.
+	Headers http.Header
+}
+
+// Client sends OTLP metrics over HTTP.
+type Client struct {
+	httpClient *http.Client
+	url        string
+	opts       Options
+}
+
+// NewClient creates a new Client sending requests to url.
+func NewClient(httpClient *http.Client, url string, opts Options) *Client {
+	return &Client{
+		httpClient: httpClient,
+		url:        url,
+		opts:       opts,
+	}
+}
+
+// Encode encodes req with the given encoding.
+// JSON follows the OTLP/HTTP JSON mapping: camelCase field names and enums as numbers.
+func Encode(req *colmetricspb.ExportMetricsServiceRequest, encoding Encoding) ([]byte, string, error) {
+	switch encoding {
+	case "", EncodingProtobuf:
+		data, err := proto.Marshal(req)
+		return data, "application/x-protobuf", err
+	case EncodingJSON:
+		data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+		return data, "application/json", err
+	default:
+		return nil, "", fmt.Errorf("unsupported otlp encoding %q", encoding)
+	}
+}
+
+// Send encodes and sends req. A non-2xx response is returned as an error.
+func (c *Client) Send(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	data, contentType, err := Encode(req, c.opts.Encoding)
+	if err != nil {
+		return fmt.Errorf("cannot encode otlp request: %w", err)
+	}
+
+	if c.opts.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("cannot compress otlp payload: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("cannot compress otlp payload: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create otlp request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	if c.opts.Gzip {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	for name, values := range c.opts.Headers {
+		httpReq.Header.Del(name)
+		for _, v := range values {
+			httpReq.Header.Add(name, v)
+		}
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, ingest.MaxErrorBodySize))
+		return fmt.Errorf("otlp request to %s failed with status %d: %s", c.url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,83 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	req := NewBuilder().WithTime(time.Unix(1, 0)).AddSum("requests", "", 3, true, nil).Build()
+
+	data, contentType, err := Encode(req, "")
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-protobuf", contentType)
+	var decoded colmetricspb.ExportMetricsServiceRequest
+	require.NoError(t, proto.Unmarshal(data, &decoded))
+	assert.True(t, proto.Equal(req, &decoded))
+
+	data, contentType, err = Encode(req, EncodingJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	assert.Contains(t, string(data), `"resourceMetrics"`)
+	assert.Contains(t, string(data), `"aggregationTemporality":2`, "Enums should be encoded as numbers")
+	decoded.Reset()
+	require.NoError(t, protojson.Unmarshal(data, &decoded))
+	assert.True(t, proto.Equal(req, &decoded))
+
+	_, _, err = Encode(req, "yaml")
+	require.Error(t, err)
+}
+
+func TestClientSend(t *testing.T) {
+	t.Parallel()
+	req := NewBuilder().AddGauge("g", "", 1, map[string]string{"foo": "bar"}).Build()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/insert/1/opentelemetry/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "1", r.Header.Get("X-Test"))
+
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		var decoded colmetricspb.ExportMetricsServiceRequest
+		require.NoError(t, protojson.Unmarshal(body, &decoded))
+		assert.True(t, proto.Equal(req, &decoded))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMInsertURL(server.Listener.Addr().String(), 1), Options{
+		Encoding: EncodingJSON,
+		Gzip:     true,
+		Headers:  http.Header{"X-Test": {"1"}},
+	})
+	require.NoError(t, client.Send(context.Background(), req))
+}
+
+func TestClientSendError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleURL(server.Listener.Addr().String()), Options{})
+	err := client.Send(context.Background(), NewBuilder().Build())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 415")
+	assert.Contains(t, err.Error(), "unsupported content type")
+}
@@ -0,0 +1,272 @@
+package otlp
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// NamingOptions describes how VictoriaMetrics converts OTLP names.
+type NamingOptions struct {
+	// PrometheusNaming corresponds to -opentelemetry.usePrometheusNaming:
+	// names are sanitized and get unit and _total suffixes.
+	PrometheusNaming bool
+}
+
+// ExpectedSeries returns the series VictoriaMetrics creates for req.
+// Resource attributes and data point attributes become labels. A scope adds scope.name and scope.version
+// labels, "unknown" if empty, and a scope.attributes.<key> label per scope attribute.
+// Attributes with array, bytes or key-value list values are not supported.
+// Histograms produce _bucket, _sum and _count series, summaries produce quantile, _sum and _count series.
+// Exponential histograms produce _sum and _count only, since their vmrange buckets depend on VictoriaMetrics
+// bucket boundaries.
+func ExpectedSeries(req *colmetricspb.ExportMetricsServiceRequest, opts NamingOptions) []ingest.Series {
+	var series []ingest.Series
+	for _, rm := range req.GetResourceMetrics() {
+		resource := attributeLabels(nil, "", rm.GetResource().GetAttributes(), opts)
+		for _, sm := range rm.GetScopeMetrics() {
+			base := resource
+			if scope := sm.GetScope(); scope != nil {
+				base = scopeLabels(resource, scope, opts)
+			}
+			for _, m := range sm.GetMetrics() {
+				series = append(series, metricSeries(m, base, opts)...)
+			}
+		}
+	}
+	return series
+}
+
+// scopeLabels returns base with the labels VictoriaMetrics adds for an instrumentation scope.
+func scopeLabels(base map[string]string, scope *commonpb.InstrumentationScope, opts NamingOptions) map[string]string {
+	name, version := scope.GetName(), scope.GetVersion()
+	if name == "" {
+		name = "unknown"
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	labels := attributeLabels(base, "", []*commonpb.KeyValue{
+		{Key: "scope.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: name}}},
+		{Key: "scope.version", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: version}}},
+	}, opts)
+	return attributeLabels(labels, "scope.attributes.", scope.GetAttributes(), opts)
+}
+
+func metricSeries(m *metricspb.Metric, base map[string]string, opts NamingOptions) []ingest.Series {
+	name := MetricName(m, opts)
+	var series []ingest.Series
+	add := func(suffix string, attrs []*commonpb.KeyValue, value float64, extra ...string) {
+		labels := attributeLabels(base, "", attrs, opts)
+		for i := 0; i+1 < len(extra); i += 2 {
+			labels[extra[i]] = extra[i+1]
+		}
+		series = append(series, ingest.Series{Name: name + suffix, Labels: labels, Value: value})
+	}
+
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for _, p := range data.Gauge.GetDataPoints() {
+			add("", p.GetAttributes(), numberValue(p))
+		}
+	case *metricspb.Metric_Sum:
+		for _, p := range data.Sum.GetDataPoints() {
+			add("", p.GetAttributes(), numberValue(p))
+		}
+	case *metricspb.Metric_Histogram:
+		for _, p := range data.Histogram.GetDataPoints() {
+			add("_count", p.GetAttributes(), float64(p.GetCount()))
+			if p.Sum != nil {
+				add("_sum", p.GetAttributes(), p.GetSum())
+			}
+			var cumulative uint64
+			for i, c := range p.GetBucketCounts() {
+				cumulative += c
+				le := math.Inf(1)
+				if i < len(p.GetExplicitBounds()) {
+					le = p.GetExplicitBounds()[i]
+				}
+				add("_bucket", p.GetAttributes(), float64(cumulative), "le", formatFloat(le))
+			}
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		for _, p := range data.ExponentialHistogram.GetDataPoints() {
+			add("_count", p.GetAttributes(), float64(p.GetCount()))
+			add("_sum", p.GetAttributes(), p.GetSum())
+		}
+	case *metricspb.Metric_Summary:
+		for _, p := range data.Summary.GetDataPoints() {
+			add("_sum", p.GetAttributes(), p.GetSum())
+			add("_count", p.GetAttributes(), float64(p.GetCount()))
+			for _, q := range p.GetQuantileValues() {
+				add("", p.GetAttributes(), q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+			}
+		}
+	}
+	return series
+}
+
+func numberValue(p *metricspb.NumberDataPoint) float64 {
+	if v, ok := p.GetValue().(*metricspb.NumberDataPoint_AsInt); ok {
+		return float64(v.AsInt)
+	}
+	return p.GetAsDouble()
+}
+
+func attributeLabels(base map[string]string, prefix string, attrs []*commonpb.KeyValue, opts NamingOptions) map[string]string {
+	labels := make(map[string]string, len(base)+len(attrs))
+	for k, v := range base {
+		labels[k] = v
+	}
+	for _, kv := range attrs {
+		name := prefix + kv.GetKey()
+		if opts.PrometheusNaming {
+			name = sanitizeLabelName(name)
+		}
+		labels[name] = attributeValue(kv.GetValue())
+	}
+	return labels
+}
+
+func attributeValue(v *commonpb.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return formatFloat(v.DoubleValue)
+	default:
+		return ""
+	}
+}
+
+var invalidLabelNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// unitSuffixes maps common UCUM units to Prometheus unit suffixes.
+var unitSuffixes = map[string]string{
+	"d":    "days",
+	"h":    "hours",
+	"min":  "minutes",
+	"s":    "seconds",
+	"ms":   "milliseconds",
+	"us":   "microseconds",
+	"ns":   "nanoseconds",
+	"By":   "bytes",
+	"KiBy": "kibibytes",
+	"MiBy": "mebibytes",
+	"GiBy": "gibibytes",
+	"KBy":  "kilobytes",
+	"MBy":  "megabytes",
+	"GBy":  "gigabytes",
+	"TiBy": "tibibytes",
+	"TBy":  "terabytes",
+	"m":    "meters",
+	"V":    "volts",
+	"A":    "amperes",
+	"J":    "joules",
+	"W":    "watts",
+	"g":    "grams",
+	"Cel":  "celsius",
+	"Hz":   "hertz",
+	"%":    "percent",
+	"1":    "",
+}
+
+// perUnitSuffixes maps the denominator of units like By/s.
+var perUnitSuffixes = map[string]string{
+	"s":  "second",
+	"m":  "minute",
+	"h":  "hour",
+	"d":  "day",
+	"w":  "week",
+	"mo": "month",
+	"y":  "year",
+}
+
+// MetricName returns the metric name VictoriaMetrics uses for m, without histogram and summary suffixes.
+// With PrometheusNaming the name is split into words at /, _, ., -, : and spaces, the unit words
+// are appended unless the name already contains them, and a monotonic sum gets _total while a gauge
+// or a non-monotonic sum with unit 1 gets _ratio. An existing total or ratio word is moved to the end.
+func MetricName(m *metricspb.Metric, opts NamingOptions) string {
+	if !opts.PrometheusNaming {
+		return m.GetName()
+	}
+	words := strings.FieldsFunc(m.GetName(), func(r rune) bool {
+		return strings.ContainsRune("/_.-: ", r)
+	})
+
+	unit := m.GetUnit()
+	main, per, _ := strings.Cut(unit, "/")
+	main, per = strings.TrimSpace(main), strings.TrimSpace(per)
+	if main != "" && !strings.Contains(main, "{") {
+		if s, ok := unitSuffixes[main]; ok {
+			main = s
+		}
+		if main != "" && !slices.Contains(words, main) {
+			words = append(words, main)
+		}
+	}
+	if per != "" && !strings.Contains(per, "{") {
+		if s, ok := perUnitSuffixes[per]; ok {
+			per = s
+		}
+		if !slices.Contains(words, per) {
+			words = append(words, "per", per)
+		}
+	}
+
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Sum:
+		if data.Sum.GetIsMonotonic() {
+			words = moveToEnd(words, "total")
+		} else if unit == "1" {
+			words = moveToEnd(words, "ratio")
+		}
+	case *metricspb.Metric_Gauge:
+		if unit == "1" {
+			words = moveToEnd(words, "ratio")
+		}
+	}
+	return strings.Join(words, "_")
+}
+
+// moveToEnd removes the first occurrence of word from words and appends it.
+func moveToEnd(words []string, word string) []string {
+	for i, w := range words {
+		if w == word {
+			words = append(words[:i], words[i+1:]...)
+			break
+		}
+	}
+	return append(words, word)
+}
+
+func sanitizeLabelName(name string) string {
+	name = invalidLabelNameChars.ReplaceAllString(name, "_")
+	switch {
+	case name == "":
+	case name[0] >= '0' && name[0] <= '9':
+		name = "key_" + name
+	case strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "__"):
+		name = "key" + name
+	}
+	return name
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
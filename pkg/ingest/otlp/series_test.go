@@ -0,0 +1,119 @@
+package otlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
+)
+
+func TestExpectedSeries(t *testing.T) {
+	t.Parallel()
+	d := remotewrite.Distribution{Buckets: []float64{1, 5}, Observations: []float64{0.5, 2, 3, 10}}
+	b := NewBuilder().
+		WithResourceAttribute("service.name", "checkout").
+		WithScopeAttribute("library", "otel-go").
+		AddGauge("temperature", "Cel", 21.5, map[string]string{"room": "a"}).
+		AddSum("requests", "1", 7, true, nil).
+		AddHistogram("latency", "s", d, nil).
+		AddExponentialHistogram("size", "By", 0, d, nil).
+		AddSummary("duration", "s", []float64{0.5}, d, nil)
+
+	series := b.ExpectedSeries(NamingOptions{})
+	byName := map[string][]ingest.Series{}
+	for _, s := range series {
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+
+	require.Len(t, byName["temperature"], 1)
+	assert.Equal(t, 21.5, byName["temperature"][0].Value)
+	assert.Equal(t, map[string]string{
+		"service.name":             "checkout",
+		"scope.name":               "unknown",
+		"scope.version":            "unknown",
+		"scope.attributes.library": "otel-go",
+		"room":                     "a",
+	}, byName["temperature"][0].Labels)
+	assert.Equal(t, 7.0, byName["requests"][0].Value)
+
+	buckets := byName["latency_bucket"]
+	require.Len(t, buckets, 3)
+	for i, le := range []string{"1", "5", "+Inf"} {
+		assert.Equal(t, le, buckets[i].Labels["le"])
+		assert.Equal(t, d.BucketCounts()[i], buckets[i].Value)
+	}
+	assert.Equal(t, 4.0, byName["latency_count"][0].Value)
+	assert.Equal(t, 15.5, byName["latency_sum"][0].Value)
+
+	assert.Len(t, byName["size_count"], 1)
+	require.Len(t, byName["size_sum"], 1)
+	assert.Equal(t, 15.5, byName["size_sum"][0].Value)
+	assert.NotContains(t, byName, "size_bucket")
+
+	require.Len(t, byName["duration"], 1)
+	assert.Equal(t, "0.5", byName["duration"][0].Labels["quantile"])
+	assert.Equal(t, d.Quantile(0.5), byName["duration"][0].Value)
+	assert.Len(t, byName["duration_sum"], 1)
+	assert.Len(t, byName["duration_count"], 1)
+
+	series = b.WithScope("meter", "1.2.0").ExpectedSeries(NamingOptions{PrometheusNaming: true})
+	names := map[string]bool{}
+	for _, s := range series {
+		names[s.Name] = true
+		if s.Name == "temperature_celsius" {
+			assert.Equal(t, map[string]string{
+				"service_name":             "checkout",
+				"scope_name":               "meter",
+				"scope_version":            "1.2.0",
+				"scope_attributes_library": "otel-go",
+				"room":                     "a",
+			}, s.Labels)
+		}
+	}
+	for _, name := range []string{
+		"temperature_celsius", "requests_total", "latency_seconds_bucket", "size_bytes_count", "duration_seconds_sum",
+	} {
+		assert.True(t, names[name], name)
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	t.Parallel()
+	prom := NamingOptions{PrometheusNaming: true}
+	for _, tc := range []struct {
+		metric *metricspb.Metric
+		want   string
+	}{
+		{&metricspb.Metric{Name: "http.server.duration", Unit: "ms"}, "http_server_duration_milliseconds"},
+		{&metricspb.Metric{Name: "net.io", Unit: "By/s"}, "net_io_bytes_per_second"},
+		{&metricspb.Metric{Name: "queue_size", Unit: "{items}"}, "queue_size"},
+		{&metricspb.Metric{Name: "cpu.utilization", Unit: "1", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}}, "cpu_utilization_ratio"},
+		{&metricspb.Metric{Name: "jobs_total", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{IsMonotonic: true}}}, "jobs_total"},
+		{&metricspb.Metric{Name: "connections", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{}}}, "connections"},
+		{&metricspb.Metric{Name: "pool.usage", Unit: "1", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{}}}, "pool_usage_ratio"},
+		{&metricspb.Metric{Name: "total.requests", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{IsMonotonic: true}}}, "requests_total"},
+		{&metricspb.Metric{Name: "bytes.read.count", Unit: "By"}, "bytes_read_count"},
+		{&metricspb.Metric{Name: "disk-size", Unit: "TiBy"}, "disk_size_tibibytes"},
+		{&metricspb.Metric{Name: "retries", Unit: "1/s"}, "retries_per_second"},
+	} {
+		assert.Equal(t, tc.want, MetricName(tc.metric, prom), tc.metric.Name)
+	}
+	assert.Equal(t, "http.server.duration", MetricName(&metricspb.Metric{Name: "http.server.duration", Unit: "ms"}, NamingOptions{}))
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{
+		"service.name": "service_name",
+		"a..b":         "a__b",
+		"1st":          "key_1st",
+		"_private":     "key_private",
+		"__reserved":   "__reserved",
+	} {
+		assert.Equal(t, want, sanitizeLabelName(name), name)
+	}
+}
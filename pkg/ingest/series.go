@@ -0,0 +1,94 @@
+// Package ingest contains what the ingestion protocol clients in its subpackages share:
+// the series VictoriaMetrics is expected to create for ingested data and how to select them.
+package ingest
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// MaxErrorBodySize limits how much of an error response body is included in errors.
+// VictoriaMetrics components reply with a short message, while proxies in front of them
+// may return whole HTML pages.
+const MaxErrorBodySize = 4096
+
+// Series is a series VictoriaMetrics is expected to create for ingested data.
+type Series struct {
+	Name   string
+	Labels map[string]string
+	// Value is the latest value of the series. It is NaN if it cannot be predicted.
+	Value float64
+}
+
+// Selector returns a series selector matching the series exactly by name and labels.
+func (s Series) Selector() string {
+	matchers := []string{`__name__=` + quote(s.Name)}
+	for _, k := range SortedKeys(s.Labels) {
+		matchers = append(matchers, quoteName(k)+"="+quote(s.Labels[k]))
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+// Metric returns the name and labels of the series in the form returned by queries.
+func (s Series) Metric() model.Metric {
+	m := make(model.Metric, len(s.Labels)+1)
+	m[model.MetricNameLabel] = model.LabelValue(s.Name)
+	for k, v := range s.Labels {
+		m[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return m
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// quoteName quotes label names which are not valid Prometheus identifiers, e.g. names with dots.
+func quoteName(s string) string {
+	if validLabelName.MatchString(s) {
+		return s
+	}
+	return quote(s)
+}
+
+var validLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LatestSeries merges repeated series, e.g. points of one series sent at different times,
+// keeping the value with the latest time. times[i] is the time of series[i].
+// The order of first occurrences is preserved.
+func LatestSeries(series []Series, times []time.Time) []Series {
+	var (
+		res    []Series
+		index  = map[string]int{}
+		latest = map[string]time.Time{}
+	)
+	for i, s := range series {
+		key := s.Selector()
+		j, ok := index[key]
+		if !ok {
+			index[key] = len(res)
+			latest[key] = times[i]
+			res = append(res, s)
+			continue
+		}
+		if !times[i].Before(latest[key]) {
+			latest[key] = times[i]
+			res[j].Value = s.Value
+		}
+	}
+	return res
+}
+
+// SortedKeys returns the keys of m in ascending order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
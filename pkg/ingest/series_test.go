@@ -0,0 +1,43 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesSelector(t *testing.T) {
+	t.Parallel()
+	s := Series{Name: "system.load.1", Labels: map[string]string{"host": "web-1", "kube.pod": `a"b`, "le": "+Inf"}}
+	assert.Equal(t, `{__name__="system.load.1",host="web-1","kube.pod"="a\"b",le="+Inf"}`, s.Selector())
+}
+
+func TestSeriesMetric(t *testing.T) {
+	t.Parallel()
+	s := Series{Name: "temperature", Labels: map[string]string{"scope.name": "unknown"}}
+	assert.Equal(t, model.Metric{"__name__": "temperature", "scope.name": "unknown"}, s.Metric())
+}
+
+func TestLatestSeries(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	series := LatestSeries([]Series{
+		{Name: "a", Labels: map[string]string{"env": "e2e"}, Value: 1},
+		{Name: "b", Value: 2},
+		{Name: "a", Labels: map[string]string{"env": "e2e"}, Value: 3},
+		{Name: "a", Labels: map[string]string{"env": "e2e"}, Value: 4},
+	}, []time.Time{now.Add(time.Second), now, now, now.Add(time.Second)})
+	require.Len(t, series, 2)
+	assert.Equal(t, "a", series[0].Name)
+	assert.Equal(t, 4.0, series[0].Value, "The latest value should win, later series on equal times")
+	assert.Equal(t, 2.0, series[1].Value)
+}
+
+func TestSortedKeys(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"a", "b", "c"}, SortedKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	assert.Empty(t, SortedKeys(map[string]string(nil)))
+}
@@ -0,0 +1,323 @@
+package vmimport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+const (
+	// maxReportedDiffs limits the number of differences included in a round-trip error.
+	maxReportedDiffs = 20
+	// RoundTripLabel is added via extra_label to data re-imported in the native format,
+	// so it does not overlap with the original series.
+	RoundTripLabel = "vmimport_roundtrip"
+)
+
+// Endpoints are base URLs of import and export APIs, without the /api/v1/... suffix.
+type Endpoints struct {
+	Import string
+	Export string
+}
+
+// VMSingleEndpoints returns import and export endpoints of a VMSingle instance at host.
+func VMSingleEndpoints(host string) Endpoints {
+	return Endpoints{
+		Import: fmt.Sprintf("http://%s", host),
+		Export: fmt.Sprintf("http://%s", host),
+	}
+}
+
+// VMClusterEndpoints returns endpoints importing via vminsert and exporting via vmselect for the given tenant.
+func VMClusterEndpoints(insertHost, selectHost string, tenantID int) Endpoints {
+	return Endpoints{
+		Import: fmt.Sprintf("http://%s/insert/%d/prometheus", insertHost, tenantID),
+		Export: fmt.Sprintf("http://%s/select/%d/prometheus", selectHost, tenantID),
+	}
+}
+
+// Client imports series and reads them back to verify exact sample values and timestamps.
+type Client struct {
+	httpClient *http.Client
+	endpoints  Endpoints
+	// Timeout bounds waiting for imported data to become visible in RoundTrip.
+	Timeout time.Duration
+	// PollInterval is the delay between exports in RoundTrip.
+	PollInterval time.Duration
+}
+
+// NewClient creates a new Client.
+func NewClient(httpClient *http.Client, endpoints Endpoints) *Client {
+	return &Client{
+		httpClient:   httpClient,
+		endpoints:    endpoints,
+		Timeout:      time.Minute,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Import writes series in the given format. CSV series are imported one request per series,
+// since every request has a single column layout. Native data cannot be generated, use ImportNative.
+func (c *Client) Import(ctx context.Context, format Format, series []Series) error {
+	switch format {
+	case FormatJSONLines:
+		body, err := EncodeJSONLines(series)
+		if err != nil {
+			return err
+		}
+		return c.post(ctx, "/api/v1/import", nil, body)
+	case FormatCSV:
+		for _, s := range series {
+			body, err := EncodeCSV(s)
+			if err != nil {
+				return fmt.Errorf("cannot encode series %s as csv: %w", s.Key(), err)
+			}
+			if err := c.post(ctx, "/api/v1/import/csv", url.Values{"format": {CSVImportFormat(s)}}, body); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatPrometheus:
+		return c.post(ctx, "/api/v1/import/prometheus", nil, EncodePrometheus(series))
+	default:
+		return fmt.Errorf("cannot import series in %q format", format)
+	}
+}
+
+// ImportNative writes data exported with ExportNative. Extra labels are added to every imported series.
+func (c *Client) ImportNative(ctx context.Context, data []byte, extraLabels map[string]string) error {
+	args := url.Values{}
+	for _, k := range ingest.SortedKeys(extraLabels) {
+		args.Add("extra_label", k+"="+extraLabels[k])
+	}
+	return c.post(ctx, "/api/v1/import/native", args, data)
+}
+
+// Export reads back series matching like exactly within their time range, in the given format.
+// match[] selectors also match series with extra labels, e.g. series written by other tests
+// to a shared cluster. They are dropped from JSON lines and Prometheus exports, while CSV exports
+// contain only the labels of like and merge such series into the matching one.
+// Copies made by RoundTrip are excluded from every format.
+func (c *Client) Export(ctx context.Context, format Format, like []Series) ([]Series, error) {
+	switch format {
+	case FormatJSONLines:
+		data, err := c.get(ctx, "/api/v1/export", exportArgs(like))
+		if err != nil {
+			return nil, err
+		}
+		series, err := ParseJSONLines(data)
+		if err != nil {
+			return nil, err
+		}
+		return onlyLike(series, like), nil
+	case FormatCSV:
+		var series []Series
+		for _, s := range like {
+			args := exportArgs([]Series{s})
+			args.Set("format", CSVExportFormat(s))
+			data, err := c.get(ctx, "/api/v1/export/csv", args)
+			if err != nil {
+				return nil, err
+			}
+			exported, err := ParseCSV(data, s)
+			if err != nil {
+				return nil, err
+			}
+			series = append(series, exported...)
+		}
+		return Merge(series), nil
+	case FormatPrometheus:
+		data, err := c.get(ctx, "/api/v1/export/prometheus", exportArgs(like))
+		if err != nil {
+			return nil, err
+		}
+		series, err := ParsePrometheus(data)
+		if err != nil {
+			return nil, err
+		}
+		return onlyLike(series, like), nil
+	default:
+		return nil, fmt.Errorf("cannot parse series exported in %q format", format)
+	}
+}
+
+// ExportNative reads series matching like in the native format.
+func (c *Client) ExportNative(ctx context.Context, like []Series) ([]byte, error) {
+	return c.get(ctx, "/api/v1/export/native", exportArgs(like))
+}
+
+// RoundTrip imports series in the given format, exports them in the same format and compares
+// them sample by sample, retrying until they match or Timeout expires.
+//
+// The native format is verified by importing series as JSON lines, exporting them as native data,
+// re-importing it with the RoundTripLabel extra label and comparing the re-imported series.
+func (c *Client) RoundTrip(ctx context.Context, format Format, series []Series) error {
+	if format != FormatNative {
+		if err := c.Import(ctx, format, series); err != nil {
+			return err
+		}
+		return c.waitForSeries(ctx, format, series)
+	}
+
+	if err := c.Import(ctx, FormatJSONLines, series); err != nil {
+		return err
+	}
+	if err := c.waitForSeries(ctx, FormatJSONLines, series); err != nil {
+		return fmt.Errorf("source data is not exported as imported: %w", err)
+	}
+	data, err := c.ExportNative(ctx, series)
+	if err != nil {
+		return err
+	}
+	if err := c.ImportNative(ctx, data, map[string]string{RoundTripLabel: string(FormatNative)}); err != nil {
+		return err
+	}
+	return c.waitForSeries(ctx, FormatJSONLines, WithLabel(series, RoundTripLabel, string(FormatNative)))
+}
+
+// waitForSeries exports want until it matches exactly.
+func (c *Client) waitForSeries(ctx context.Context, format Format, want []Series) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var (
+		diffs   []string
+		lastErr error
+	)
+	for {
+		got, err := c.Export(ctx, format, want)
+		if err == nil {
+			if diffs = Compare(want, got); len(diffs) == 0 {
+				return nil
+			}
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil && !errors.Is(lastErr, ctx.Err()) {
+				return fmt.Errorf("export in %s format failed: %w", format, lastErr)
+			}
+			return fmt.Errorf("exported data in %s format does not match imported data:\n%s", format, formatDiffs(diffs))
+		case <-time.After(c.PollInterval):
+		}
+	}
+}
+
+func formatDiffs(diffs []string) string {
+	if len(diffs) > maxReportedDiffs {
+		diffs = append(diffs[:maxReportedDiffs:maxReportedDiffs], fmt.Sprintf("... and %d more", len(diffs)-maxReportedDiffs))
+	}
+	return "  " + strings.Join(diffs, "\n  ")
+}
+
+// WithLabel returns a copy of series with the label added.
+func WithLabel(series []Series, name, value string) []Series {
+	res := make([]Series, 0, len(series))
+	for _, s := range series {
+		labels := make(map[string]string, len(s.Labels)+1)
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		labels[name] = value
+		res = append(res, Series{Labels: labels, Samples: s.Samples})
+	}
+	return res
+}
+
+// onlyLike drops series whose labels differ from labels of every series in like.
+func onlyLike(series, like []Series) []Series {
+	keys := make(map[string]bool, len(like))
+	for _, s := range like {
+		keys[s.Key()] = true
+	}
+	var res []Series
+	for _, s := range series {
+		if keys[s.Key()] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// matchSelector returns the match[] selector of the series. Series without RoundTripLabel
+// get an empty matcher for it, so copies re-imported by RoundTrip are not exported with them.
+func matchSelector(s Series) string {
+	key := s.Key()
+	if _, ok := s.Labels[RoundTripLabel]; ok {
+		return key
+	}
+	key = strings.TrimSuffix(key, "}")
+	if !strings.HasSuffix(key, "{") {
+		key += ","
+	}
+	return key + RoundTripLabel + `=""}`
+}
+
+// exportArgs returns match[] selectors for every series and the time range covering all samples.
+func exportArgs(series []Series) url.Values {
+	args := url.Values{}
+	var start, end int64
+	first := true
+	for _, s := range series {
+		args.Add("match[]", matchSelector(s))
+		for _, p := range s.Samples {
+			if first || p.Timestamp < start {
+				start = p.Timestamp
+			}
+			if first || p.Timestamp > end {
+				end = p.Timestamp
+			}
+			first = false
+		}
+	}
+	if !first {
+		args.Set("start", formatTimestamp(start))
+		args.Set("end", formatTimestamp(end))
+	}
+	return args
+}
+
+// formatTimestamp formats milliseconds as fractional unix seconds accepted by the export API.
+func formatTimestamp(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1e3, 'f', 3, 64)
+}
+
+func (c *Client) post(ctx context.Context, path string, args url.Values, body []byte) error {
+	_, err := c.do(ctx, http.MethodPost, c.endpoints.Import+path, args, body)
+	return err
+}
+
+func (c *Client) get(ctx context.Context, path string, args url.Values) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, c.endpoints.Export+path, args, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, rawURL string, args url.Values, body []byte) ([]byte, error) {
+	if len(args) > 0 {
+		rawURL += "?" + args.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, ingest.MaxErrorBodySize))
+		return nil, fmt.Errorf("%s %s failed with status %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return io.ReadAll(resp.Body)
+}
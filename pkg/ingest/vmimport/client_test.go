@@ -0,0 +1,266 @@
+package vmimport
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStorage imitates VictoriaMetrics import and export APIs.
+// Native data is represented with JSON lines. Like VictoriaMetrics, it keeps 12 significant digits
+// of values and match[] selectors match series with extra labels.
+type fakeStorage struct {
+	mu     sync.Mutex
+	series []Series
+	// corrupt drops all but the first sample of every exported series.
+	corrupt bool
+}
+
+func (fs *fakeStorage) add(series []Series, extraLabels []string) {
+	for _, l := range extraLabels {
+		name, value, _ := strings.Cut(l, "=")
+		series = WithLabel(series, name, value)
+	}
+	for i, s := range series {
+		samples := make([]Sample, 0, len(s.Samples))
+		for _, p := range s.Samples {
+			p.Value, _ = strconv.ParseFloat(strconv.FormatFloat(p.Value, 'g', 12, 64), 64)
+			samples = append(samples, p)
+		}
+		series[i] = Series{Labels: s.Labels, Samples: samples}
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.series = Merge(append(fs.series, series...))
+}
+
+func (fs *fakeStorage) match(selectors []string) []Series {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var res []Series
+	for _, s := range fs.series {
+		for _, sel := range selectors {
+			if matches(s, sel) {
+				res = append(res, s)
+				break
+			}
+		}
+	}
+	if fs.corrupt {
+		for i := range res {
+			res[i] = Series{Labels: res[i].Labels, Samples: res[i].Samples[:1]}
+		}
+	}
+	return res
+}
+
+// matches reports whether the series matches selector; an empty matcher value matches a missing label.
+func matches(s Series, selector string) bool {
+	labels, _, err := parseSeriesSelector(selector)
+	if err != nil {
+		return false
+	}
+	for k, v := range labels {
+		if s.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (fs *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	q := r.URL.Query()
+	path := r.URL.Path[strings.Index(r.URL.Path, "/api/v1/"):]
+
+	var (
+		series []Series
+		err    error
+	)
+	switch path {
+	case "/api/v1/import", "/api/v1/import/native":
+		series, err = ParseJSONLines(body)
+	case "/api/v1/import/prometheus":
+		series, err = ParsePrometheus(body)
+	case "/api/v1/import/csv":
+		series, err = parseFakeCSV(q.Get("format"), body)
+	case "/api/v1/export", "/api/v1/export/native":
+		data, _ := EncodeJSONLines(fs.match(q["match[]"]))
+		_, _ = w.Write(data)
+		return
+	case "/api/v1/export/prometheus":
+		_, _ = w.Write(EncodePrometheus(fs.match(q["match[]"])))
+		return
+	case "/api/v1/export/csv":
+		cw := csv.NewWriter(w)
+		for _, s := range fs.match(q["match[]"]) {
+			for _, p := range s.Samples {
+				var row []string
+				for _, col := range strings.Split(q.Get("format"), ",") {
+					switch col {
+					case "__value__":
+						row = append(row, formatValue(p.Value))
+					case "__timestamp__:unix_ms":
+						row = append(row, strconv.FormatInt(p.Timestamp, 10))
+					default:
+						row = append(row, s.Labels[col])
+					}
+				}
+				_ = cw.Write(row)
+			}
+		}
+		cw.Flush()
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fs.add(series, q["extra_label"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseFakeCSV(format string, body []byte) ([]Series, error) {
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var series []Series
+	for _, row := range rows {
+		s := Series{Labels: map[string]string{}, Samples: []Sample{{}}}
+		for _, col := range strings.Split(format, ",") {
+			parts := strings.SplitN(col, ":", 3)
+			i, _ := strconv.Atoi(parts[0])
+			v := row[i-1]
+			switch parts[1] {
+			case "label":
+				s.Labels[parts[2]] = v
+			case "metric":
+				s.Labels["__name__"] = parts[2]
+				s.Samples[0].Value, _ = strconv.ParseFloat(v, 64)
+			case "time":
+				s.Samples[0].Timestamp, _ = strconv.ParseInt(v, 10, 64)
+			}
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, format := range []Format{FormatJSONLines, FormatCSV, FormatPrometheus, FormatNative} {
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+			storage := &fakeStorage{}
+			server := httptest.NewServer(storage)
+			defer server.Close()
+
+			host := server.Listener.Addr().String()
+			client := NewClient(server.Client(), VMClusterEndpoints(host, host, 3))
+			client.PollInterval = 10 * time.Millisecond
+			require.NoError(t, client.RoundTrip(context.Background(), format, testSeries))
+
+			if format == FormatNative {
+				assert.Len(t, storage.series, 2*len(testSeries), "Native data should be re-imported with the round-trip label")
+				got, err := client.Export(context.Background(), FormatJSONLines, WithLabel(testSeries, RoundTripLabel, "native"))
+				require.NoError(t, err)
+				assert.Empty(t, Compare(WithLabel(testSeries, RoundTripLabel, "native"), got))
+			}
+		})
+	}
+}
+
+func TestRoundTripGenSeries(t *testing.T) {
+	t.Parallel()
+	storage := &fakeStorage{}
+	server := httptest.NewServer(storage)
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleEndpoints(server.Listener.Addr().String()))
+	client.Timeout = 50 * time.Millisecond
+	client.PollInterval = 10 * time.Millisecond
+	series := GenSeries("gen", 100, 20, time.UnixMilli(1700000000500), 15*time.Second)
+	require.NoError(t, client.RoundTrip(context.Background(), FormatNative, series), "Generated values should survive storage precision")
+
+	lossy := []Series{{
+		Labels:  map[string]string{"__name__": "lossy"},
+		Samples: []Sample{{Timestamp: 1700000000000, Value: math.Pi}},
+	}}
+	err := client.RoundTrip(context.Background(), FormatJSONLines, lossy)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "want 3.141592653589793")
+}
+
+func TestExportExtraLabels(t *testing.T) {
+	t.Parallel()
+	storage := &fakeStorage{}
+	storage.add(WithLabel(testSeries, "tenant_test", "other"), nil)
+	storage.add(testSeries, nil)
+	server := httptest.NewServer(storage)
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleEndpoints(server.Listener.Addr().String()))
+	for _, format := range []Format{FormatJSONLines, FormatPrometheus} {
+		got, err := client.Export(context.Background(), format, testSeries)
+		require.NoError(t, err)
+		assert.Empty(t, Compare(testSeries, got), "Series with extra labels should be dropped from %s export", format)
+	}
+}
+
+func TestRoundTripMismatch(t *testing.T) {
+	t.Parallel()
+	storage := &fakeStorage{corrupt: true}
+	server := httptest.NewServer(storage)
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleEndpoints(server.Listener.Addr().String()))
+	client.Timeout = 50 * time.Millisecond
+	client.PollInterval = 10 * time.Millisecond
+	err := client.RoundTrip(context.Background(), FormatJSONLines, testSeries[:1])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match imported data")
+	assert.Contains(t, err.Error(), "want 2 samples, got 1")
+}
+
+func TestImportErrors(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "cannot parse", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), VMSingleEndpoints(server.Listener.Addr().String()))
+	err := client.Import(context.Background(), FormatPrometheus, testSeries)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/api/v1/import/prometheus failed with status 400: cannot parse")
+
+	err = client.Import(context.Background(), FormatNative, testSeries)
+	require.Error(t, err)
+}
+
+func TestExportArgs(t *testing.T) {
+	t.Parallel()
+	args := exportArgs(testSeries)
+	assert.Equal(t, []string{
+		`import_test{job="e2e",path="C:\\dir \"x\"",vmimport_roundtrip=""}`,
+		`import_plain{vmimport_roundtrip=""}`,
+	}, args["match[]"])
+	assert.Equal(t, []string{`import_plain{vmimport_roundtrip="native"}`}, exportArgs(WithLabel(testSeries[1:], RoundTripLabel, "native"))["match[]"])
+	assert.Equal(t, "1700000000.000", args.Get("start"))
+	assert.Equal(t, "1700000015.000", args.Get("end"))
+}
@@ -0,0 +1,383 @@
+package vmimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+)
+
+// Format is a VictoriaMetrics import/export format.
+type Format string
+
+const (
+	// FormatJSONLines is the /api/v1/import and /api/v1/export JSON line format.
+	FormatJSONLines Format = "json"
+	// FormatCSV is the /api/v1/import/csv and /api/v1/export/csv format.
+	FormatCSV Format = "csv"
+	// FormatPrometheus is the Prometheus text exposition format with millisecond timestamps.
+	FormatPrometheus Format = "prometheus"
+	// FormatNative is the VictoriaMetrics native binary format.
+	FormatNative Format = "native"
+)
+
+// Sample is a single value with a timestamp in milliseconds.
+type Sample struct {
+	Timestamp int64
+	Value     float64
+}
+
+// Series is a set of samples of a single time series.
+type Series struct {
+	// Labels include __name__.
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// Name returns the metric name of the series.
+func (s Series) Name() string {
+	return s.Labels["__name__"]
+}
+
+// labelValueEscaper escapes label values as in the Prometheus text format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Key returns a canonical string representation of series labels in the Prometheus text format.
+func (s Series) Key() string {
+	keys := ingest.SortedKeys(s.Labels)
+	var b strings.Builder
+	b.WriteString(s.Name())
+	b.WriteByte('{')
+	first := true
+	for _, k := range keys {
+		if k == "__name__" {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteByte('"')
+		b.WriteString(labelValueEscaper.Replace(s.Labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// GenSeries generates count series <namePrefix>_<i>{series="<i>"} with samples values each,
+// spaced by step and ending at end. Values are fractional and timestamps have millisecond offsets,
+// so round trips check full precision. VictoriaMetrics keeps 12 significant decimal digits of a value,
+// so values are multiples of 1/8 with fewer digits, which it stores exactly.
+func GenSeries(namePrefix string, count, samples int, end time.Time, step time.Duration) []Series {
+	series := make([]Series, 0, count)
+	for i := 0; i < count; i++ {
+		s := Series{Labels: map[string]string{
+			"__name__": fmt.Sprintf("%s_%d", namePrefix, i),
+			"series":   strconv.Itoa(i),
+		}}
+		last := end.UnixMilli() - int64(i)
+		for k := 0; k < samples; k++ {
+			s.Samples = append(s.Samples, Sample{
+				Timestamp: last - int64(samples-1-k)*step.Milliseconds(),
+				Value:     float64(i+1)*1.5 + float64(k)/8,
+			})
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+type jsonLine struct {
+	Metric     map[string]string `json:"metric"`
+	Values     []float64         `json:"values"`
+	Timestamps []int64           `json:"timestamps"`
+}
+
+// EncodeJSONLines encodes series in the /api/v1/import format, one series per line.
+func EncodeJSONLines(series []Series) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range series {
+		line := jsonLine{Metric: s.Labels}
+		for _, p := range s.Samples {
+			line.Values = append(line.Values, p.Value)
+			line.Timestamps = append(line.Timestamps, p.Timestamp)
+		}
+		if err := enc.Encode(line); err != nil {
+			return nil, fmt.Errorf("cannot encode series %s: %w", s.Key(), err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseJSONLines parses /api/v1/export output. Lines of the same series are merged.
+func ParseJSONLines(data []byte) ([]Series, error) {
+	var series []Series
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 64*1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var line jsonLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("cannot parse export line %q: %w", sc.Text(), err)
+		}
+		if len(line.Values) != len(line.Timestamps) {
+			return nil, fmt.Errorf("export line has %d values and %d timestamps", len(line.Values), len(line.Timestamps))
+		}
+		s := Series{Labels: line.Metric}
+		for i := range line.Values {
+			s.Samples = append(s.Samples, Sample{Timestamp: line.Timestamps[i], Value: line.Values[i]})
+		}
+		series = append(series, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return Merge(series), nil
+}
+
+// csvLayout returns the label names of a CSV row: label columns are followed by value and timestamp.
+func csvLayout(s Series) []string {
+	var names []string
+	for _, k := range ingest.SortedKeys(s.Labels) {
+		if k != "__name__" {
+			names = append(names, k)
+		}
+	}
+	return names
+}
+
+// CSVImportFormat returns the format query arg of /api/v1/import/csv for rows produced by EncodeCSV.
+func CSVImportFormat(s Series) string {
+	labels := csvLayout(s)
+	cols := make([]string, 0, len(labels)+2)
+	for i, k := range labels {
+		cols = append(cols, fmt.Sprintf("%d:label:%s", i+1, k))
+	}
+	cols = append(cols,
+		fmt.Sprintf("%d:metric:%s", len(labels)+1, s.Name()),
+		fmt.Sprintf("%d:time:unix_ms", len(labels)+2),
+	)
+	return strings.Join(cols, ",")
+}
+
+// CSVExportFormat returns the format query arg of /api/v1/export/csv matching the layout of EncodeCSV
+// with the metric name as the first column.
+func CSVExportFormat(s Series) string {
+	return strings.Join(append(append([]string{"__name__"}, csvLayout(s)...), "__value__", "__timestamp__:unix_ms"), ",")
+}
+
+// EncodeCSV encodes samples of a single series as CSV rows for /api/v1/import/csv with CSVImportFormat.
+func EncodeCSV(s Series) ([]byte, error) {
+	labels := csvLayout(s)
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, p := range s.Samples {
+		row := make([]string, 0, len(labels)+2)
+		for _, k := range labels {
+			row = append(row, s.Labels[k])
+		}
+		row = append(row, formatValue(p.Value), strconv.FormatInt(p.Timestamp, 10))
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// ParseCSV parses /api/v1/export/csv output requested with CSVExportFormat(like).
+func ParseCSV(data []byte, like Series) ([]Series, error) {
+	labels := append([]string{"__name__"}, csvLayout(like)...)
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = len(labels) + 2
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse csv export: %w", err)
+	}
+	var series []Series
+	for _, row := range rows {
+		s := Series{Labels: map[string]string{}}
+		for i, k := range labels {
+			if row[i] != "" {
+				s.Labels[k] = row[i]
+			}
+		}
+		v, err := strconv.ParseFloat(row[len(labels)], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse csv value %q: %w", row[len(labels)], err)
+		}
+		ts, err := strconv.ParseInt(row[len(labels)+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse csv timestamp %q: %w", row[len(labels)+1], err)
+		}
+		s.Samples = []Sample{{Timestamp: ts, Value: v}}
+		series = append(series, s)
+	}
+	return Merge(series), nil
+}
+
+// EncodePrometheus encodes series as Prometheus text lines with millisecond timestamps.
+func EncodePrometheus(series []Series) []byte {
+	var buf []byte
+	for _, s := range series {
+		prefix := []byte(s.Key())
+		if len(s.Labels) == 1 {
+			prefix = []byte(s.Name())
+		}
+		for _, p := range s.Samples {
+			buf = append(buf, prefix...)
+			buf = append(buf, ' ')
+			buf = append(buf, formatValue(p.Value)...)
+			buf = append(buf, ' ')
+			buf = strconv.AppendInt(buf, p.Timestamp, 10)
+			buf = append(buf, '\n')
+		}
+	}
+	return buf
+}
+
+// ParsePrometheus parses /api/v1/export/prometheus output.
+func ParsePrometheus(data []byte) ([]Series, error) {
+	var series []Series
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		labels, rest, err := parseSeriesSelector(line)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse line %q: %w", line, err)
+		}
+		fields := strings.Fields(rest)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("cannot parse line %q: expecting value and timestamp", line)
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse value in line %q: %w", line, err)
+		}
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse timestamp in line %q: %w", line, err)
+		}
+		series = append(series, Series{Labels: labels, Samples: []Sample{{Timestamp: ts, Value: v}}})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return Merge(series), nil
+}
+
+// parseSeriesSelector parses `name{k="v",...}` at the start of line and returns labels and the rest of line.
+func parseSeriesSelector(line string) (map[string]string, string, error) {
+	n := strings.IndexAny(line, "{ ")
+	if n <= 0 {
+		return nil, "", fmt.Errorf("missing metric name")
+	}
+	labels := map[string]string{"__name__": line[:n]}
+	line = line[n:]
+	if line[0] != '{' {
+		return labels, line, nil
+	}
+	line = line[1:]
+	for {
+		line = strings.TrimLeft(line, " ,")
+		if strings.HasPrefix(line, "}") {
+			return labels, line[1:], nil
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || len(line) < eq+2 || line[eq+1] != '"' {
+			return nil, "", fmt.Errorf("invalid label at %q", line)
+		}
+		name := strings.TrimSpace(line[:eq])
+		value, err := strconv.QuotedPrefix(line[eq+1:])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid label value at %q: %w", line[eq+1:], err)
+		}
+		labels[name], _ = strconv.Unquote(value)
+		line = line[eq+1+len(value):]
+	}
+}
+
+// Merge merges series with equal labels and sorts their samples by timestamp.
+func Merge(series []Series) []Series {
+	index := map[string]int{}
+	var res []Series
+	for _, s := range series {
+		key := s.Key()
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(res)
+			res = append(res, Series{Labels: s.Labels, Samples: append([]Sample(nil), s.Samples...)})
+			continue
+		}
+		res[i].Samples = append(res[i].Samples, s.Samples...)
+	}
+	for _, s := range res {
+		sort.SliceStable(s.Samples, func(i, j int) bool { return s.Samples[i].Timestamp < s.Samples[j].Timestamp })
+	}
+	return res
+}
+
+// Compare returns human-readable differences between want and got: missing and unexpected series,
+// and samples that differ in timestamp or value. Values must be equal exactly, NaN equals NaN,
+// so want values must have at most 12 significant decimal digits to survive a round trip.
+func Compare(want, got []Series) []string {
+	gotByKey := map[string]Series{}
+	for _, s := range Merge(got) {
+		gotByKey[s.Key()] = s
+	}
+	var diffs []string
+	for _, w := range Merge(want) {
+		key := w.Key()
+		g, ok := gotByKey[key]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("missing series %s", key))
+			continue
+		}
+		delete(gotByKey, key)
+		diffs = append(diffs, compareSamples(key, w.Samples, g.Samples)...)
+	}
+	for _, key := range ingest.SortedKeys(gotByKey) {
+		diffs = append(diffs, fmt.Sprintf("unexpected series %s", key))
+	}
+	return diffs
+}
+
+func compareSamples(key string, want, got []Sample) []string {
+	var diffs []string
+	if len(want) != len(got) {
+		diffs = append(diffs, fmt.Sprintf("series %s: want %d samples, got %d", key, len(want), len(got)))
+	}
+	for i := 0; i < len(want) && i < len(got); i++ {
+		w, g := want[i], got[i]
+		if w.Timestamp != g.Timestamp || !sameValue(w.Value, g.Value) {
+			diffs = append(diffs, fmt.Sprintf("series %s: sample %d: want %s@%d, got %s@%d",
+				key, i, formatValue(w.Value), w.Timestamp, formatValue(g.Value), g.Timestamp))
+		}
+	}
+	return diffs
+}
+
+func sameValue(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
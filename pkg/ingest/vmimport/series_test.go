@@ -0,0 +1,104 @@
+package vmimport
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSeries = []Series{
+	{
+		Labels:  map[string]string{"__name__": "import_test", "job": "e2e", "path": `C:\dir "x"`},
+		Samples: []Sample{{Timestamp: 1700000000000, Value: 1.5}, {Timestamp: 1700000015000, Value: -2}},
+	},
+	{
+		Labels:  map[string]string{"__name__": "import_plain"},
+		Samples: []Sample{{Timestamp: 1700000000123, Value: 1e-9}},
+	},
+}
+
+func TestGenSeries(t *testing.T) {
+	t.Parallel()
+	end := time.UnixMilli(1700000000500)
+	series := GenSeries("gen", 2, 3, end, 15*time.Second)
+	require.Len(t, series, 2)
+	assert.Equal(t, map[string]string{"__name__": "gen_1", "series": "1"}, series[1].Labels)
+	assert.Equal(t, []Sample{
+		{Timestamp: 1700000000500 - 30000, Value: 1.5},
+		{Timestamp: 1700000000500 - 15000, Value: 1.625},
+		{Timestamp: 1700000000500, Value: 1.75},
+	}, series[0].Samples)
+	assert.Equal(t, int64(1700000000499), series[1].Samples[2].Timestamp)
+}
+
+func TestSeriesKey(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, `import_test{job="e2e",path="C:\\dir \"x\""}`, testSeries[0].Key())
+	assert.Equal(t, `import_plain{}`, testSeries[1].Key())
+}
+
+func TestJSONLinesRoundTrip(t *testing.T) {
+	t.Parallel()
+	data, err := EncodeJSONLines(testSeries)
+	require.NoError(t, err)
+	got, err := ParseJSONLines(data)
+	require.NoError(t, err)
+	assert.Empty(t, Compare(testSeries, got))
+
+	_, err = ParseJSONLines([]byte(`{"metric":{"__name__":"a"},"values":[1],"timestamps":[]}`))
+	require.Error(t, err)
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	t.Parallel()
+	s := testSeries[0]
+	assert.Equal(t, "1:label:job,2:label:path,3:metric:import_test,4:time:unix_ms", CSVImportFormat(s))
+	assert.Equal(t, "__name__,job,path,__value__,__timestamp__:unix_ms", CSVExportFormat(s))
+
+	data, err := EncodeCSV(s)
+	require.NoError(t, err)
+	assert.Equal(t, "e2e,\"C:\\dir \"\"x\"\"\",1.5,1700000000000\ne2e,\"C:\\dir \"\"x\"\"\",-2,1700000015000\n", string(data))
+
+	exported := "import_test,e2e,\"C:\\dir \"\"x\"\"\",-2,1700000015000\nimport_test,e2e,\"C:\\dir \"\"x\"\"\",1.5,1700000000000\n"
+	got, err := ParseCSV([]byte(exported), s)
+	require.NoError(t, err)
+	assert.Empty(t, Compare([]Series{s}, got), "Rows should be merged and sorted by timestamp")
+}
+
+func TestPrometheusRoundTrip(t *testing.T) {
+	t.Parallel()
+	data := EncodePrometheus(testSeries)
+	assert.Contains(t, string(data), `import_test{job="e2e",path="C:\\dir \"x\""} 1.5 1700000000000`+"\n")
+	assert.Contains(t, string(data), "import_plain 1e-09 1700000000123\n")
+
+	got, err := ParsePrometheus(data)
+	require.NoError(t, err)
+	assert.Empty(t, Compare(testSeries, got))
+
+	_, err = ParsePrometheus([]byte(`bad{job="e2e} 1 2`))
+	require.Error(t, err)
+	_, err = ParsePrometheus([]byte(`bad 1`))
+	require.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	want := []Series{
+		{Labels: map[string]string{"__name__": "a"}, Samples: []Sample{{1, 1}, {2, math.NaN()}}},
+		{Labels: map[string]string{"__name__": "b"}, Samples: []Sample{{1, 1}}},
+	}
+	got := []Series{
+		{Labels: map[string]string{"__name__": "a"}, Samples: []Sample{{1, 1.5}, {2, math.NaN()}, {3, 3}}},
+		{Labels: map[string]string{"__name__": "c"}, Samples: []Sample{{1, 1}}},
+	}
+	assert.Equal(t, []string{
+		"series a{}: want 2 samples, got 3",
+		"series a{}: sample 0: want 1@1, got 1.5@1",
+		"missing series b{}",
+		"unexpected series c{}",
+	}, Compare(want, got))
+	assert.Empty(t, Compare(want, want))
+}
@@ -26,6 +26,8 @@ func buildVMK8StackValues(namespace string) map[string]string {
 		"vmcluster.ingress.insert.hosts[0]": consts.VMInsertHost(namespace),
 		"alertmanager.ingress.enabled":      "true",
 		"alertmanager.ingress.hosts[0]":     consts.AlertManagerHost(namespace),
+		"vmalert.ingress.enabled":           "true",
+		"vmalert.ingress.hosts[0]":          consts.VMAlertHost(namespace),
 	}
 
 	if consts.OperatorImageRegistry() != "" {
@@ -49,6 +49,8 @@ func TestBuildVMK8StackValues(t *testing.T) {
 			expectedValues: map[string]string{
 				"vmcluster.ingress.select.hosts[0]": "vmselect-prod.1.2.3.4.nip.io",
 				"vmcluster.ingress.insert.hosts[0]": "vminsert-prod.1.2.3.4.nip.io",
+				"vmalert.ingress.enabled":           "true",
+				"vmalert.ingress.hosts[0]":          "vmalert-prod.1.2.3.4.nip.io",
 			},
 		},
 		{
@@ -0,0 +1,101 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+)
+
+// Listener is a plaintext ingestion listener enabled with a -*ListenAddr flag.
+type Listener struct {
+	// Name is used as the Service port name.
+	Name string
+	// Flag is the command-line flag enabling the listener.
+	Flag string
+	Port int32
+	// UDP reports whether the listener also accepts UDP on the same port.
+	UDP bool
+}
+
+var (
+	// GraphiteListener accepts the Graphite plaintext protocol over TCP and UDP.
+	GraphiteListener = Listener{Name: "graphite", Flag: "graphiteListenAddr", Port: 2003, UDP: true}
+	// OpenTSDBListener accepts OpenTSDB telnet put commands over TCP and UDP, and /api/put requests.
+	OpenTSDBListener = Listener{Name: "opentsdb", Flag: "opentsdbListenAddr", Port: 4242, UDP: true}
+	// OpenTSDBHTTPListener accepts OpenTSDB /api/put requests only.
+	OpenTSDBHTTPListener = Listener{Name: "opentsdb-http", Flag: "opentsdbHTTPListenAddr", Port: 4243}
+)
+
+// Addr returns the flag value making the listener accept connections on all interfaces.
+func (l Listener) Addr() string {
+	return fmt.Sprintf(":%d", l.Port)
+}
+
+// ExposeVMSingleListeners creates a Service exposing the listener ports of VMSingle pods
+// and returns its name. Listeners must be enabled via extra args.
+func ExposeVMSingleListeners(t terratesting.TestingT, kubeOpts *k8s.KubectlOptions, namespace string, listeners ...Listener) string {
+	return exposeListeners(t, kubeOpts, namespace, "vmsingle-listeners", map[string]string{
+		"app.kubernetes.io/name":     "vmsingle",
+		"app.kubernetes.io/instance": "vmsingle",
+	}, listeners)
+}
+
+// ExposeVMInsertListeners creates a Service exposing the listener ports of vminsert pods
+// and returns its name. Listeners must be enabled via vminsert extra args.
+func ExposeVMInsertListeners(t terratesting.TestingT, kubeOpts *k8s.KubectlOptions, namespace string, listeners ...Listener) string {
+	return exposeListeners(t, kubeOpts, namespace, "vminsert-listeners", map[string]string{
+		"app.kubernetes.io/name":     "vminsert",
+		"app.kubernetes.io/instance": consts.DefaultVMClusterName,
+	}, listeners)
+}
+
+func exposeListeners(t terratesting.TestingT, kubeOpts *k8s.KubectlOptions, namespace, name string, selector map[string]string, listeners []Listener) string {
+	svcYaml, err := yaml.Marshal(listenersService(namespace, name, selector, listeners))
+	require.NoError(t, err)
+
+	k8s.KubectlApplyFromString(t, kubeOpts, string(svcYaml))
+	k8s.WaitUntilServiceAvailable(t, kubeOpts, name, consts.Retries, consts.PollingInterval)
+	return name
+}
+
+// listenersService returns a ClusterIP Service with a TCP port per listener and an extra UDP port for UDP listeners.
+func listenersService(namespace, name string, selector map[string]string, listeners []Listener) corev1.Service {
+	svc := corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+	for _, l := range listeners {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       l.Name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       l.Port,
+			TargetPort: intstr.FromInt32(l.Port),
+		})
+		if l.UDP {
+			svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+				Name:       l.Name + "-udp",
+				Protocol:   corev1.ProtocolUDP,
+				Port:       l.Port,
+				TargetPort: intstr.FromInt32(l.Port),
+			})
+		}
+	}
+	return svc
+}
+
+// OpenListenerTunnel forwards a free local port to the TCP port of the listener behind serviceName.
+// UDP cannot be port-forwarded. The caller must close the tunnel.
+func OpenListenerTunnel(t terratesting.TestingT, kubeOpts *k8s.KubectlOptions, serviceName string, l Listener) *k8s.Tunnel {
+	tunnel := k8s.NewTunnel(kubeOpts, k8s.ResourceTypeService, serviceName, k8s.GetAvailablePort(t), int(l.Port))
+	tunnel.ForwardPort(t)
+	return tunnel
+}
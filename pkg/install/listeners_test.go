@@ -0,0 +1,32 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestListenerAddr(t *testing.T) {
+	assert.Equal(t, ":2003", GraphiteListener.Addr())
+	assert.Equal(t, ":4243", OpenTSDBHTTPListener.Addr())
+}
+
+func TestListenersService(t *testing.T) {
+	selector := map[string]string{"app.kubernetes.io/name": "vmsingle"}
+	svc := listenersService("vm-test", "vmsingle-listeners", selector, []Listener{GraphiteListener, OpenTSDBHTTPListener})
+
+	assert.Equal(t, "vmsingle-listeners", svc.Name)
+	assert.Equal(t, "vm-test", svc.Namespace)
+	assert.Equal(t, selector, svc.Spec.Selector)
+	require.Len(t, svc.Spec.Ports, 3)
+
+	assert.Equal(t, "graphite", svc.Spec.Ports[0].Name)
+	assert.Equal(t, corev1.ProtocolTCP, svc.Spec.Ports[0].Protocol)
+	assert.Equal(t, "graphite-udp", svc.Spec.Ports[1].Name)
+	assert.Equal(t, corev1.ProtocolUDP, svc.Spec.Ports[1].Protocol)
+	assert.Equal(t, int32(2003), svc.Spec.Ports[1].TargetPort.IntVal)
+	assert.Equal(t, "opentsdb-http", svc.Spec.Ports[2].Name)
+	assert.Equal(t, int32(4243), svc.Spec.Ports[2].Port)
+}
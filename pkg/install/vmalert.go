@@ -68,8 +68,7 @@ func WaitForVMAlertToBeOperational(ctx context.Context, t terratesting.TestingT,
 
 // AddCustomAlertRules creates a VMRule with custom alerts
 func AddCustomAlertRules(ctx context.Context, t terratesting.TestingT, namespace string) {
-	manifestPath := "../../manifests/custom-alerts.yaml"
-	manifest, err := os.ReadFile(manifestPath)
+	manifest, err := os.ReadFile(consts.CustomAlertsYaml)
 	require.NoError(t, err)
 
 	docJson, err := yaml.YAMLToJSON(manifest)
@@ -0,0 +1,330 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/vmimport"
+)
+
+// SeriesLabel holds the index of a series written by the Checker.
+const SeriesLabel = "integrity_series"
+
+// WriteFunc sends time series to the storage under test.
+// A nil error means the write was acknowledged and its samples must not be lost.
+type WriteFunc func(ctx context.Context, ts []prompb.TimeSeries) error
+
+// Exporter reads back exact samples of series, e.g. *vmimport.Client.
+type Exporter interface {
+	Export(ctx context.Context, format vmimport.Format, like []vmimport.Series) ([]vmimport.Series, error)
+}
+
+// Options configures a Checker.
+type Options struct {
+	// MetricName is the name of written series. Defaults to integrity_check.
+	MetricName string
+	// Labels are added to every written series, e.g. to tell scenarios apart.
+	Labels map[string]string
+	// Series is the number of series written on every tick. Defaults to 10.
+	Series int
+	// Interval is the distance between sequence numbers. Defaults to 1s.
+	Interval time.Duration
+	// Bucket is the time range every report row covers. Defaults to 1m.
+	Bucket time.Duration
+	// VerifyTimeout bounds waiting for acknowledged samples to become visible in Verify. Defaults to 2m.
+	VerifyTimeout time.Duration
+	// PollInterval is the delay between exports in Verify. Defaults to 5s.
+	PollInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MetricName == "" {
+		o.MetricName = "integrity_check"
+	}
+	if o.Series <= 0 {
+		o.Series = 10
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.Bucket <= 0 {
+		o.Bucket = time.Minute
+	}
+	if o.VerifyTimeout <= 0 {
+		o.VerifyTimeout = 2 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+	return o
+}
+
+// Checker writes deterministic, sequence-numbered samples while a fault is injected
+// and verifies afterwards that every acknowledged sample is stored exactly once and unchanged.
+//
+// Sample number seq is written to every series at start+seq*Interval with value seq,
+// so a sample found at any other timestamp or with any other value is corrupted.
+type Checker struct {
+	write WriteFunc
+	opts  Options
+
+	mu    sync.Mutex
+	start time.Time
+	// writes maps sequence numbers of attempted writes to whether they were acknowledged.
+	writes  map[int]bool
+	lastErr error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChecker creates a new Checker sending samples with write.
+func NewChecker(write WriteFunc, opts Options) *Checker {
+	return &Checker{
+		write:  write,
+		opts:   opts.withDefaults(),
+		writes: map[int]bool{},
+	}
+}
+
+// Start writes samples in background until Stop is called or ctx is canceled.
+// The first sample is written immediately.
+func (c *Checker) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	c.start = time.Now().Truncate(c.opts.Interval)
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.opts.Interval)
+		defer ticker.Stop()
+
+		last := -1
+		for {
+			// Sequence numbers follow wall time, so ticks skipped by a slow write leave gaps
+			// instead of shifting later samples.
+			if seq := int(time.Since(c.start) / c.opts.Interval); seq > last {
+				last = seq
+				c.writeSeq(ctx, seq)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops writing and waits for the in-flight write to finish.
+func (c *Checker) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+func (c *Checker) writeSeq(ctx context.Context, seq int) {
+	ts := make([]prompb.TimeSeries, 0, c.opts.Series)
+	for i := 0; i < c.opts.Series; i++ {
+		ts = append(ts, prompb.TimeSeries{
+			Labels:  c.seriesLabels(i),
+			Samples: []prompb.Sample{{Value: float64(seq), Timestamp: c.timestamp(seq)}},
+		})
+	}
+	err := c.write(ctx, ts)
+	if err != nil && ctx.Err() != nil {
+		// The write was interrupted by Stop, its outcome is unknown.
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes[seq] = err == nil
+	if err != nil {
+		c.lastErr = err
+	}
+}
+
+func (c *Checker) seriesLabels(i int) []prompb.Label {
+	labels := []prompb.Label{
+		{Name: "__name__", Value: c.opts.MetricName},
+		{Name: SeriesLabel, Value: strconv.Itoa(i)},
+	}
+	for _, k := range ingest.SortedKeys(c.opts.Labels) {
+		labels = append(labels, prompb.Label{Name: k, Value: c.opts.Labels[k]})
+	}
+	return labels
+}
+
+func (c *Checker) timestamp(seq int) int64 {
+	return c.start.Add(time.Duration(seq) * c.opts.Interval).UnixMilli()
+}
+
+// LastError returns the last failed write, or nil if every write was acknowledged.
+func (c *Checker) LastError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastErr
+}
+
+// Series returns the written series with samples at the first and the last written timestamp,
+// which is enough to select them for export.
+func (c *Checker) Series() []vmimport.Series {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.writes) == 0 {
+		return nil
+	}
+	first, last := -1, -1
+	for seq := range c.writes {
+		if first < 0 || seq < first {
+			first = seq
+		}
+		if seq > last {
+			last = seq
+		}
+	}
+
+	series := make([]vmimport.Series, 0, c.opts.Series)
+	for i := 0; i < c.opts.Series; i++ {
+		labels := map[string]string{}
+		for _, l := range c.seriesLabels(i) {
+			labels[l.Name] = l.Value
+		}
+		series = append(series, vmimport.Series{
+			Labels: labels,
+			Samples: []vmimport.Sample{
+				{Timestamp: c.timestamp(first), Value: float64(first)},
+				{Timestamp: c.timestamp(last), Value: float64(last)},
+			},
+		})
+	}
+	return series
+}
+
+// Verify exports the written series and builds a Report. It retries until no acknowledged
+// sample is missing or VerifyTimeout expires, since the latest samples may not be searchable yet.
+// An error is returned only if the data could not be exported at all.
+func (c *Checker) Verify(ctx context.Context, exporter Exporter) (Report, error) {
+	like := c.Series()
+	if len(like) == 0 {
+		return Report{}, fmt.Errorf("no samples were written")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.VerifyTimeout)
+	defer cancel()
+
+	var (
+		report  Report
+		lastErr error
+		ok      bool
+	)
+	for {
+		got, err := exporter.Export(ctx, vmimport.FormatJSONLines, like)
+		if err == nil {
+			report, ok = c.analyze(got), true
+			if report.Total.Missing == 0 {
+				return report, nil
+			}
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if ok {
+				return report, nil
+			}
+			if lastErr != nil && !errors.Is(lastErr, ctx.Err()) {
+				return Report{}, fmt.Errorf("cannot export integrity check series: %w", lastErr)
+			}
+			return Report{}, fmt.Errorf("cannot export integrity check series: %w", ctx.Err())
+		case <-time.After(c.opts.PollInterval):
+		}
+	}
+}
+
+// analyze compares exported series with the writes made so far.
+func (c *Checker) analyze(got []vmimport.Series) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buckets := map[int64]*Counts{}
+	bucket := func(ts int64) *Counts {
+		start := time.UnixMilli(ts).Truncate(c.opts.Bucket).UnixMilli()
+		b, ok := buckets[start]
+		if !ok {
+			b = &Counts{}
+			buckets[start] = b
+		}
+		return b
+	}
+
+	// found[i][seq] counts samples of series i stored at the timestamp of seq.
+	found := make([]map[int]int, c.opts.Series)
+	for i := range found {
+		found[i] = map[int]int{}
+	}
+	for _, s := range vmimport.Merge(got) {
+		i, err := strconv.Atoi(s.Labels[SeriesLabel])
+		if err != nil || i < 0 || i >= c.opts.Series {
+			continue
+		}
+		for _, sample := range s.Samples {
+			b := bucket(sample.Timestamp)
+			offset := time.Duration(sample.Timestamp-c.start.UnixMilli()) * time.Millisecond
+			seq := int(offset / c.opts.Interval)
+			if offset < 0 || offset%c.opts.Interval != 0 {
+				b.Unexpected++
+				continue
+			}
+			if _, written := c.writes[seq]; !written {
+				b.Unexpected++
+				continue
+			}
+			if sample.Value != float64(seq) {
+				b.Corrupted++
+			}
+			if found[i][seq]++; found[i][seq] > 1 {
+				b.Duplicated++
+			}
+		}
+	}
+
+	for seq, acked := range c.writes {
+		b := bucket(c.timestamp(seq))
+		b.Written += c.opts.Series
+		if !acked {
+			continue
+		}
+		b.Acknowledged += c.opts.Series
+		for i := range found {
+			if found[i][seq] == 0 {
+				b.Missing++
+			}
+		}
+	}
+
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	var r Report
+	for _, start := range starts {
+		counts := *buckets[start]
+		r.Buckets = append(r.Buckets, Bucket{Start: time.UnixMilli(start).UTC(), Counts: counts})
+		r.Total.add(counts)
+	}
+	return r
+}
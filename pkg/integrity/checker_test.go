@@ -0,0 +1,164 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/vmimport"
+)
+
+// memoryStorage stores written samples and exports them like vmimport.Client.
+type memoryStorage struct {
+	mu      sync.Mutex
+	series  map[string]*vmimport.Series
+	writes  int
+	failAt  map[int]bool
+	exports int
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{series: map[string]*vmimport.Series{}, failAt: map[int]bool{}}
+}
+
+func (m *memoryStorage) Write(_ context.Context, ts []prompb.TimeSeries) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writes++
+	if m.failAt[m.writes] {
+		return fmt.Errorf("write %d rejected", m.writes)
+	}
+	for _, t := range ts {
+		labels := map[string]string{}
+		for _, l := range t.Labels {
+			labels[l.Name] = l.Value
+		}
+		s := vmimport.Series{Labels: labels}
+		key := s.Key()
+		if _, ok := m.series[key]; !ok {
+			m.series[key] = &s
+		}
+		for _, sample := range t.Samples {
+			m.series[key].Samples = append(m.series[key].Samples, vmimport.Sample{Timestamp: sample.Timestamp, Value: sample.Value})
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) Export(_ context.Context, _ vmimport.Format, like []vmimport.Series) ([]vmimport.Series, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exports++
+	var res []vmimport.Series
+	for _, l := range like {
+		if s, ok := m.series[l.Key()]; ok {
+			res = append(res, vmimport.Series{Labels: s.Labels, Samples: append([]vmimport.Sample(nil), s.Samples...)})
+		}
+	}
+	return res, nil
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+	storage := newMemoryStorage()
+	storage.failAt[2] = true
+
+	checker := NewChecker(storage.Write, Options{
+		Labels:   map[string]string{"scenario": "test"},
+		Series:   3,
+		Interval: 10 * time.Millisecond,
+		Bucket:   time.Second,
+	})
+	checker.Start(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	checker.Stop()
+	require.ErrorContains(t, checker.LastError(), "write 2 rejected")
+
+	like := checker.Series()
+	require.Len(t, like, 3)
+	assert.Equal(t, "test", like[0].Labels["scenario"])
+	assert.Equal(t, "integrity_check", like[0].Name())
+
+	report, err := checker.Verify(context.Background(), storage)
+	require.NoError(t, err)
+	assert.Equal(t, 1, storage.exports)
+	assert.Equal(t, storage.writes*3, report.Total.Written)
+	assert.Equal(t, report.Total.Written-3, report.Total.Acknowledged)
+	assert.Zero(t, report.Total.Missing)
+	assert.Zero(t, report.Total.Duplicated)
+	assert.Zero(t, report.Total.Corrupted)
+	require.NoError(t, Budget{}.Check(report))
+}
+
+func TestChecker_Verify_Timeout(t *testing.T) {
+	t.Parallel()
+	storage := newMemoryStorage()
+	checker := NewChecker(func(context.Context, []prompb.TimeSeries) error { return nil }, Options{
+		Series:        1,
+		Interval:      10 * time.Millisecond,
+		VerifyTimeout: 50 * time.Millisecond,
+		PollInterval:  10 * time.Millisecond,
+	})
+	checker.Start(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	checker.Stop()
+
+	report, err := checker.Verify(context.Background(), storage)
+	require.NoError(t, err)
+	assert.Greater(t, storage.exports, 1, "Verify should retry while samples are missing")
+	assert.Equal(t, report.Total.Acknowledged, report.Total.Missing)
+}
+
+func TestChecker_Verify_NoWrites(t *testing.T) {
+	t.Parallel()
+	checker := NewChecker(nil, Options{})
+	_, err := checker.Verify(context.Background(), newMemoryStorage())
+	require.ErrorContains(t, err, "no samples were written")
+}
+
+func TestChecker_Analyze(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 1, 1, 0, 0, 50, 0, time.UTC)
+	checker := NewChecker(nil, Options{Series: 2, Interval: 5 * time.Second, Bucket: time.Minute})
+	checker.start = start
+	// Samples 0..1 are in the first minute, 2..5 in the second one.
+	for seq := 0; seq < 6; seq++ {
+		checker.writes[seq] = seq != 4
+	}
+
+	series := func(i int, samples ...vmimport.Sample) vmimport.Series {
+		return vmimport.Series{
+			Labels:  map[string]string{"__name__": "integrity_check", SeriesLabel: fmt.Sprint(i)},
+			Samples: samples,
+		}
+	}
+	sample := func(seq int, value float64) vmimport.Sample {
+		return vmimport.Sample{Timestamp: checker.timestamp(seq), Value: value}
+	}
+	got := []vmimport.Series{
+		// Sample 1 is missing, sample 2 is duplicated, sample 3 is corrupted.
+		series(0, sample(0, 0), sample(2, 2), sample(2, 2), sample(3, 42), sample(5, 5)),
+		// Sample 4 was not acknowledged, but got stored anyway.
+		series(1, sample(0, 0), sample(1, 1), sample(2, 2), sample(3, 3), sample(4, 4), sample(5, 5)),
+		// Unknown series are ignored.
+		series(7, sample(0, 0)),
+	}
+	// A sample between sequence numbers and a sample after the last write.
+	got[1].Samples = append(got[1].Samples,
+		vmimport.Sample{Timestamp: checker.timestamp(5) + 1, Value: 5},
+		sample(9, 9),
+	)
+
+	report := checker.analyze(got)
+	require.Len(t, report.Buckets, 2)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), report.Buckets[0].Start)
+	assert.Equal(t, Counts{Written: 4, Acknowledged: 4, Missing: 1}, report.Buckets[0].Counts)
+	assert.Equal(t, Counts{Written: 8, Acknowledged: 6, Duplicated: 1, Corrupted: 1, Unexpected: 2}, report.Buckets[1].Counts)
+	assert.Equal(t, Counts{Written: 12, Acknowledged: 10, Missing: 1, Duplicated: 1, Corrupted: 1, Unexpected: 2}, report.Total)
+}
@@ -0,0 +1,93 @@
+package integrity
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Counts summarizes samples of a time range.
+type Counts struct {
+	// Written is the number of samples the checker tried to write.
+	Written int
+	// Acknowledged is the number of written samples the storage accepted.
+	Acknowledged int
+	// Missing is the number of acknowledged samples absent from the export.
+	Missing int
+	// Duplicated is the number of extra samples stored at the same timestamp of the same series.
+	Duplicated int
+	// Corrupted is the number of stored samples whose value does not match their timestamp.
+	Corrupted int
+	// Unexpected is the number of stored samples at timestamps that were never written.
+	Unexpected int
+}
+
+func (c *Counts) add(o Counts) {
+	c.Written += o.Written
+	c.Acknowledged += o.Acknowledged
+	c.Missing += o.Missing
+	c.Duplicated += o.Duplicated
+	c.Corrupted += o.Corrupted
+	c.Unexpected += o.Unexpected
+}
+
+// Bucket holds counts of samples with timestamps in [Start, Start+Options.Bucket).
+type Bucket struct {
+	Start time.Time
+	Counts
+}
+
+// Report is the result of Checker.Verify.
+type Report struct {
+	// Buckets are sorted by start time.
+	Buckets []Bucket
+	Total   Counts
+}
+
+// String formats the report as a table with a row per bucket and a total row.
+func (r Report) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "bucket\twritten\tacked\tmissing\tduplicated\tcorrupted\tunexpected\t")
+	row := func(name string, c Counts) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t\n", name, c.Written, c.Acknowledged, c.Missing, c.Duplicated, c.Corrupted, c.Unexpected)
+	}
+	for _, b := range r.Buckets {
+		row(b.Start.Format(time.TimeOnly), b.Counts)
+	}
+	row("total", r.Total)
+	_ = w.Flush()
+	return sb.String()
+}
+
+// Budget is the data loss a scenario tolerates. The zero Budget tolerates no loss at all.
+type Budget struct {
+	// MaxMissingRatio is the allowed fraction (0..1) of acknowledged samples missing from the export.
+	MaxMissingRatio float64
+	// MaxDuplicated is the allowed number of duplicated samples.
+	MaxDuplicated int
+	// MaxCorrupted is the allowed number of corrupted and unexpected samples.
+	MaxCorrupted int
+}
+
+// Check returns an error describing every budget the report exceeds.
+func (b Budget) Check(r Report) error {
+	var problems []string
+	if r.Total.Acknowledged == 0 {
+		problems = append(problems, "no write was acknowledged")
+	} else if ratio := float64(r.Total.Missing) / float64(r.Total.Acknowledged); ratio > b.MaxMissingRatio {
+		problems = append(problems, fmt.Sprintf("%d of %d acknowledged samples are missing (%.4f%%, budget %.4f%%)",
+			r.Total.Missing, r.Total.Acknowledged, ratio*100, b.MaxMissingRatio*100))
+	}
+	if r.Total.Duplicated > b.MaxDuplicated {
+		problems = append(problems, fmt.Sprintf("%d samples are duplicated (budget %d)", r.Total.Duplicated, b.MaxDuplicated))
+	}
+	if corrupted := r.Total.Corrupted + r.Total.Unexpected; corrupted > b.MaxCorrupted {
+		problems = append(problems, fmt.Sprintf("%d samples are corrupted or unexpected (budget %d)", corrupted, b.MaxCorrupted))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("data integrity budget exceeded: %s\n%s", strings.Join(problems, "; "), r)
+}
@@ -0,0 +1,38 @@
+package integrity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudget_Check(t *testing.T) {
+	t.Parallel()
+	report := Report{Total: Counts{Written: 1200, Acknowledged: 1000, Missing: 5, Duplicated: 2, Corrupted: 1, Unexpected: 1}}
+
+	err := Budget{}.Check(report)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 of 1000 acknowledged samples are missing")
+	assert.Contains(t, err.Error(), "2 samples are duplicated (budget 0)")
+	assert.Contains(t, err.Error(), "2 samples are corrupted or unexpected (budget 0)")
+
+	require.NoError(t, Budget{MaxMissingRatio: 0.005, MaxDuplicated: 2, MaxCorrupted: 2}.Check(report))
+
+	err = Budget{MaxMissingRatio: 1}.Check(Report{Total: Counts{Written: 10}})
+	require.ErrorContains(t, err, "no write was acknowledged")
+}
+
+func TestReport_String(t *testing.T) {
+	t.Parallel()
+	counts := Counts{Written: 20, Acknowledged: 18, Missing: 3}
+	report := Report{
+		Buckets: []Bucket{{Start: time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC), Counts: counts}},
+		Total:   counts,
+	}
+	lines := report.String()
+	assert.Contains(t, lines, "bucket  written  acked  missing  duplicated  corrupted  unexpected")
+	assert.Regexp(t, `10:05:00\s+20\s+18\s+3\s+0\s+0\s+0`, lines)
+	assert.Regexp(t, `total\s+20\s+18\s+3\s+0\s+0\s+0`, lines)
+}
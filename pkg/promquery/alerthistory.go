@@ -0,0 +1,311 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// alertHistoryStep is the resolution of alert history: firing periods start and end on its multiples.
+// It does not depend on the vmalert evaluation interval (20s in manifests/smoke.yaml): VictoriaMetrics
+// fills every step of a range query with the last sample seen within about 1.25 sample intervals,
+// and vmalert writes stale markers once an alert resolves. So the points of a firing alert are
+// exactly one step apart whatever the step is, and firingPeriods treats a larger gap or a last point
+// more than a step before the end as the alert being resolved.
+const alertHistoryStep = 15 * time.Second
+
+// AlertFiringPeriod is a continuous period an alert series was firing.
+type AlertFiringPeriod struct {
+	Labels prommodel.Metric
+	// ActiveAt is when the alert became pending, taken from ALERTS_FOR_STATE. Zero if unknown.
+	ActiveAt time.Time
+	// Start is the first evaluation the alert was seen firing.
+	Start time.Time
+	// End is the first evaluation the alert was no longer firing. Zero if it is still firing.
+	End time.Time
+}
+
+// Resolved reports whether the alert stopped firing within the window.
+func (p AlertFiringPeriod) Resolved() bool {
+	return !p.End.IsZero()
+}
+
+// AlertHistory is the firing history of alerts matching a selector, built from
+// the ALERTS and ALERTS_FOR_STATE series vmalert writes to the overwatch.
+type AlertHistory struct {
+	Selector string
+	// Start and End bound the inspected window.
+	Start time.Time
+	End   time.Time
+	// Periods are sorted by start time. Alerts with different labels have separate periods.
+	Periods []AlertFiringPeriod
+}
+
+// Fired reports whether any matching alert was firing within the window.
+func (h AlertHistory) Fired() bool {
+	return len(h.Periods) > 0
+}
+
+// FirstFired returns the start of the first firing period, or zero time if the alert never fired.
+func (h AlertHistory) FirstFired() time.Time {
+	if len(h.Periods) == 0 {
+		return time.Time{}
+	}
+	return h.Periods[0].Start
+}
+
+// Firing reports whether any matching alert is still firing at the end of the window.
+func (h AlertHistory) Firing() bool {
+	for _, p := range h.Periods {
+		if !p.Resolved() {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvedAt returns when the last firing period ended, or zero time if the alert
+// never fired or is still firing.
+func (h AlertHistory) ResolvedAt() time.Time {
+	if !h.Fired() || h.Firing() {
+		return time.Time{}
+	}
+	var resolved time.Time
+	for _, p := range h.Periods {
+		if p.End.After(resolved) {
+			resolved = p.End
+		}
+	}
+	return resolved
+}
+
+// FiringDuration returns the total time any matching alert was firing within the window.
+// Overlapping periods of alerts with different labels are counted once.
+func (h AlertHistory) FiringDuration() time.Duration {
+	var (
+		total    time.Duration
+		curStart time.Time
+		curEnd   time.Time
+	)
+	// Periods are sorted by start, so overlapping ones are adjacent after merging.
+	for _, p := range h.Periods {
+		end := p.End
+		if end.IsZero() {
+			end = h.End
+		}
+		if curEnd.IsZero() || p.Start.After(curEnd) {
+			total += curEnd.Sub(curStart)
+			curStart, curEnd = p.Start, end
+			continue
+		}
+		if end.After(curEnd) {
+			curEnd = end
+		}
+	}
+	return total + curEnd.Sub(curStart)
+}
+
+// String formats the history as a summary line and a table with a row per firing period.
+func (h AlertHistory) String() string {
+	if !h.Fired() {
+		return fmt.Sprintf("%s did not fire between %s and %s", h.Selector, h.Start.Format(time.RFC3339), h.End.Format(time.RFC3339))
+	}
+	var sb strings.Builder
+	resolved := "still firing"
+	if !h.Firing() {
+		resolved = "resolved at " + h.ResolvedAt().Format(time.RFC3339)
+	}
+	fmt.Fprintf(&sb, "%s first fired at %s, %s, firing for %s\n", h.Selector, h.FirstFired().Format(time.RFC3339), resolved, h.FiringDuration())
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "active\tfiring\tresolved\tlabels")
+	for _, p := range h.Periods {
+		active, end := "-", "-"
+		if !p.ActiveAt.IsZero() {
+			active = p.ActiveAt.Format(time.TimeOnly)
+		}
+		if p.Resolved() {
+			end = p.End.Format(time.TimeOnly)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", active, p.Start.Format(time.TimeOnly), end, p.Labels)
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+// alertMatcher is a label matcher of an alert selector.
+type alertMatcher struct {
+	Name  string
+	Op    string
+	Value string
+}
+
+// String formats the matcher as a PromQL label matcher.
+func (m alertMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Op, m.Value)
+}
+
+// parseAlertSelector converts a selector in the format accepted by Alertmanager checks,
+// an alert name or comma-separated label filters, to label matchers.
+// A non-empty namespace adds a namespace matcher.
+func parseAlertSelector(namespace, selector string) ([]alertMatcher, error) {
+	var matchers []alertMatcher
+	if namespace != "" {
+		matchers = append(matchers, alertMatcher{Name: "namespace", Op: "=", Value: namespace})
+	}
+	if selector == "" {
+		return matchers, nil
+	}
+	if !strings.Contains(selector, "=") {
+		return append(matchers, alertMatcher{Name: "alertname", Op: "=", Value: selector}), nil
+	}
+	for _, part := range strings.Split(strings.Trim(strings.TrimSpace(selector), "{}"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		matched := false
+		for _, op := range []string{"=~", "!~", "!=", "="} {
+			name, value, ok := strings.Cut(part, op)
+			if !ok {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			matchers = append(matchers, alertMatcher{Name: strings.TrimSpace(name), Op: op, Value: value})
+			matched = true
+			break
+		}
+		if !matched {
+			return nil, fmt.Errorf("invalid alert selector %q: cannot parse %q", selector, part)
+		}
+	}
+	return matchers, nil
+}
+
+// alertMatchers returns PromQL label matchers for the namespace and selector.
+func alertMatchers(namespace, selector string) ([]string, error) {
+	matchers, err := parseAlertSelector(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		res = append(res, m.String())
+	}
+	return res, nil
+}
+
+// AlertHistory returns the firing history of alerts matching selector in namespace between since and now.
+// The selector is an alert name or comma-separated label filters, like in CheckAlertIsFiring.
+// Firing periods are detected with alertHistoryStep resolution.
+func (p PrometheusClient) AlertHistory(ctx context.Context, namespace, selector string, since time.Time) (AlertHistory, error) {
+	matchers, err := alertMatchers(namespace, selector)
+	if err != nil {
+		return AlertHistory{}, err
+	}
+	h := AlertHistory{Selector: selector, Start: since, End: time.Now()}
+	if h.Selector == "" {
+		h.Selector = "alerts"
+	}
+	opts := RangeOptions{Start: h.Start, End: h.End, Step: alertHistoryStep}
+
+	firingQuery := fmt.Sprintf("ALERTS{%s}", strings.Join(append(matchers, `alertstate="firing"`), ","))
+	firing, err := p.QueryMatrix(ctx, firingQuery, opts)
+	if err != nil {
+		return AlertHistory{}, err
+	}
+	// ALERTS_FOR_STATE has no alertstate label, its value is the time the alert became active.
+	forState, err := p.QueryMatrix(ctx, fmt.Sprintf("ALERTS_FOR_STATE{%s}", strings.Join(matchers, ",")), opts)
+	if err != nil {
+		return AlertHistory{}, err
+	}
+	h.Periods = firingPeriods(firing, forState, h.End, alertHistoryStep)
+	return h, nil
+}
+
+// firingPeriods splits every ALERTS series into periods of points at most step apart.
+// A period whose last point is within step of end is still firing.
+func firingPeriods(firing, forState prommodel.Matrix, end time.Time, step time.Duration) []AlertFiringPeriod {
+	activeAt := map[prommodel.Fingerprint][]prommodel.SamplePair{}
+	for _, s := range forState {
+		labels := s.Metric.Clone()
+		delete(labels, prommodel.MetricNameLabel)
+		activeAt[labels.Fingerprint()] = s.Values
+	}
+
+	var periods []AlertFiringPeriod
+	for _, s := range firing {
+		labels := s.Metric.Clone()
+		delete(labels, prommodel.MetricNameLabel)
+		delete(labels, "alertstate")
+		states := activeAt[labels.Fingerprint()]
+
+		for i := 0; i < len(s.Values); {
+			j := i
+			for j+1 < len(s.Values) && s.Values[j+1].Timestamp.Sub(s.Values[j].Timestamp) <= step {
+				j++
+			}
+			period := AlertFiringPeriod{
+				Labels:   labels,
+				ActiveAt: activeAtTime(states, s.Values[i].Timestamp),
+				Start:    s.Values[i].Timestamp.Time(),
+			}
+			if last := s.Values[j].Timestamp.Time(); end.Sub(last) > step {
+				period.End = last.Add(step)
+			}
+			periods = append(periods, period)
+			i = j + 1
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+// activeAtTime returns the ALERTS_FOR_STATE value at ts.
+func activeAtTime(states []prommodel.SamplePair, ts prommodel.Time) time.Time {
+	for _, s := range states {
+		if s.Timestamp == ts && s.Value > 0 {
+			return time.Unix(int64(s.Value), 0)
+		}
+	}
+	return time.Time{}
+}
+
+// parseLookback accepts Go durations, e.g. time.Duration.String(), and Prometheus durations like 1d.
+func parseLookback(lookback string) (time.Duration, error) {
+	if d, err := time.ParseDuration(lookback); err == nil {
+		return d, nil
+	}
+	d, err := prommodel.ParseDuration(lookback)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lookback %q: %w", lookback, err)
+	}
+	return time.Duration(d), nil
+}
+
+// CheckAlertWasFiringSince verifies that a specific alert (or selector) was firing at some point
+// within lookbackTime, a duration like 30m or 1h0m0s, and returns its history.
+func (p PrometheusClient) CheckAlertWasFiringSince(ctx context.Context, t testing.TestingT, namespace, selector, lookbackTime string) AlertHistory {
+	lookback, err := parseLookback(lookbackTime)
+	require.NoError(t, err)
+	h, err := p.AlertHistory(ctx, namespace, selector, time.Now().Add(-lookback))
+	require.NoError(t, err, "Failed to get history of alert %s", selector)
+	require.True(t, h.Fired(), "Alert %s should have fired in namespace %s within %s", selector, namespace, lookbackTime)
+	return h
+}
+
+// CheckAlertFiredAndResolvedSince verifies that a specific alert (or selector) fired after since
+// and is no longer firing, and returns its history.
+func (p PrometheusClient) CheckAlertFiredAndResolvedSince(ctx context.Context, t testing.TestingT, namespace, selector string, since time.Time) AlertHistory {
+	h, err := p.AlertHistory(ctx, namespace, selector, since)
+	require.NoError(t, err, "Failed to get history of alert %s", selector)
+	require.True(t, h.Fired(), "Alert %s should have fired in namespace %s since %s", selector, namespace, since)
+	require.False(t, h.Firing(), "Alert %s should have been resolved in namespace %s:\n%s", selector, namespace, h)
+	return h
+}
@@ -0,0 +1,144 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertMatchers(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		namespace string
+		selector  string
+		want      []string
+		err       string
+	}{
+		{"ns", "", []string{`namespace="ns"`}, ""},
+		{"ns", "ServiceDown", []string{`namespace="ns"`, `alertname="ServiceDown"`}, ""},
+		{"", `{alertname="ServiceDown", job=~"vm.*"}`, []string{`alertname="ServiceDown"`, `job=~"vm.*"`}, ""},
+		{"", "alertname=ServiceDown,severity!=info", []string{`alertname="ServiceDown"`, `severity!="info"`}, ""},
+		{"", "alertname=A,severity", nil, `cannot parse "severity"`},
+	}
+	for _, tt := range tests {
+		got, err := alertMatchers(tt.namespace, tt.selector)
+		if tt.err != "" {
+			assert.ErrorContains(t, err, tt.err, tt.selector)
+			continue
+		}
+		require.NoError(t, err, tt.selector)
+		assert.Equal(t, tt.want, got, tt.selector)
+	}
+}
+
+func TestFiringPeriods(t *testing.T) {
+	t.Parallel()
+	step := 15 * time.Second
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(i int) prommodel.Time {
+		return prommodel.TimeFromUnixNano(start.Add(time.Duration(i) * step).UnixNano())
+	}
+	points := func(from, to int) []prommodel.SamplePair {
+		var res []prommodel.SamplePair
+		for i := from; i <= to; i++ {
+			res = append(res, prommodel.SamplePair{Timestamp: at(i), Value: 1})
+		}
+		return res
+	}
+	end := at(20).Time()
+
+	firing := prommodel.Matrix{
+		// Fired twice, the second time till the end of the window.
+		{Metric: prommodel.Metric{"__name__": "ALERTS", "alertname": "A", "alertstate": "firing", "pod": "a"}, Values: append(points(2, 5), points(15, 20)...)},
+		// Overlaps with the first period of pod a.
+		{Metric: prommodel.Metric{"__name__": "ALERTS", "alertname": "A", "alertstate": "firing", "pod": "b"}, Values: points(4, 8)},
+	}
+	forState := prommodel.Matrix{
+		{Metric: prommodel.Metric{"__name__": "ALERTS_FOR_STATE", "alertname": "A", "pod": "a"}, Values: []prommodel.SamplePair{
+			{Timestamp: at(2), Value: prommodel.SampleValue(start.Unix())},
+		}},
+	}
+
+	periods := firingPeriods(firing, forState, end, step)
+	require.Len(t, periods, 3)
+	assert.Equal(t, prommodel.Metric{"alertname": "A", "pod": "a"}, periods[0].Labels)
+	assert.Equal(t, start, periods[0].ActiveAt.UTC())
+	assert.Equal(t, at(2).Time(), periods[0].Start)
+	assert.Equal(t, at(6).Time(), periods[0].End)
+	assert.Equal(t, "b", string(periods[1].Labels["pod"]))
+	assert.True(t, periods[1].ActiveAt.IsZero())
+	assert.Equal(t, at(9).Time(), periods[1].End)
+	assert.False(t, periods[2].Resolved())
+
+	h := AlertHistory{Selector: "A", Start: start, End: end, Periods: periods}
+	assert.True(t, h.Fired())
+	assert.True(t, h.Firing())
+	assert.Equal(t, at(2).Time(), h.FirstFired())
+	assert.True(t, h.ResolvedAt().IsZero())
+	// Steps 2..9 and 15..20.
+	assert.Equal(t, 12*step, h.FiringDuration())
+
+	h.Periods = periods[:2]
+	assert.False(t, h.Firing())
+	assert.Equal(t, at(9).Time(), h.ResolvedAt())
+	assert.Equal(t, 7*step, h.FiringDuration())
+	assert.Contains(t, h.String(), "A first fired at 2024-01-01T10:00:30Z, resolved at 2024-01-01T10:02:15Z, firing for 1m45s")
+}
+
+func TestCheckAlertWasFiringSince(t *testing.T) {
+	t.Parallel()
+	var queries []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		query := r.Form.Get("query")
+		queries = append(queries, query)
+		assert.Equal(t, "15", r.Form.Get("step"))
+
+		start, err := strconv.ParseFloat(r.Form.Get("start"), 64)
+		assert.NoError(t, err)
+		// Fired an hour ago for a minute.
+		from := time.Now().Add(-time.Hour).Unix()
+
+		w.Header().Set("Content-Type", "application/json")
+		result := "[]"
+		if strings.HasPrefix(query, "ALERTS{") && strings.Contains(query, `alertname="Fired"`) && float64(from) >= start {
+			result = fmt.Sprintf(`[{"metric":{"__name__":"ALERTS","alertname":"Fired","alertstate":"firing"},"values":[[%d,"1"],[%d,"1"],[%d,"1"],[%d,"1"],[%d,"1"]]}]`,
+				from, from+15, from+30, from+45, from+60)
+		}
+		_, _ = fmt.Fprint(w, queryResponse("matrix", result))
+	}))
+	ctx := context.Background()
+
+	mockTest := &mockTestingT{}
+	h := client.CheckAlertWasFiringSince(ctx, mockTest, "ns", "Fired", "2h0m0s")
+	assert.False(t, mockTest.failed)
+	assert.Equal(t, time.Minute+15*time.Second, h.FiringDuration())
+	assert.Equal(t, []string{
+		`ALERTS{namespace="ns",alertname="Fired",alertstate="firing"}`,
+		`ALERTS_FOR_STATE{namespace="ns",alertname="Fired"}`,
+	}, queries)
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertFiredAndResolvedSince(ctx, mockTest, "ns", "Fired", time.Now().Add(-2*time.Hour))
+	assert.False(t, mockTest.failed)
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertWasFiringSince(ctx, mockTest, "ns", "Fired", "30m")
+	assert.True(t, mockTest.failed, "Should fail when the alert fired before the window")
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertWasFiringSince(ctx, mockTest, "ns", "Other", "1d")
+	assert.True(t, mockTest.failed, "Should fail when the alert never fired")
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertWasFiringSince(ctx, mockTest, "ns", "Fired", "yesterday")
+	assert.True(t, mockTest.failed, "Should fail on invalid lookback")
+}
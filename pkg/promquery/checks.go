@@ -60,6 +60,10 @@ func (p PrometheusClient) getFiringAlerts(ctx context.Context, t testing.Testing
 	allExceptions := append(DefaultExceptions, exceptions...)
 
 	for _, alert := range alerts {
+		if alert.Status != nil && alert.Status.State != nil && *alert.Status.State == models.AlertStatusStateSuppressed {
+			// Silenced and inhibited alerts are expected
+			continue
+		}
 		name := alert.Labels["alertname"]
 		isExcepted := false
 		for _, ex := range allExceptions {
@@ -84,15 +88,14 @@ func (p PrometheusClient) CheckAlertIsFiring(ctx context.Context, t testing.Test
 	require.NotEmpty(t, alerts, "Alert %s should be firing in namespace %s", selector, namespace)
 }
 
-// CheckAlertWasFiringSince verifies that a specific alert (or selector) was firing.
-// When using Alertmanager, it checks if the alert is currently active.
-func (p PrometheusClient) CheckAlertWasFiringSince(ctx context.Context, t testing.TestingT, namespace, selector, lookbackTime string) {
-	alerts, err := p.getAlertsFromAM(ctx, t, namespace, selector)
-	require.NoError(t, err, "Failed to get alerts from Alertmanager")
-	require.NotEmpty(t, alerts, "Alert %s should be firing in namespace %s", selector, namespace)
+func (p PrometheusClient) getAlertsFromAM(ctx context.Context, t testing.TestingT, namespace, selector string) ([]*models.GettableAlert, error) {
+	amURL, filters := p.alertmanagerRequest(namespace, selector)
+	logger.Default.Logf(t, "Requesting alerts from AM: %s with filters %v", amURL, filters)
+	return p.queryAlertmanager(ctx, amURL, filters)
 }
 
-func (p PrometheusClient) getAlertsFromAM(ctx context.Context, t testing.TestingT, namespace, selector string) ([]*models.GettableAlert, error) {
+// alertmanagerRequest returns the Alertmanager URL and the alert filters for the namespace and selector.
+func (p PrometheusClient) alertmanagerRequest(namespace, selector string) (string, []string) {
 	var amURL string
 	if p.AlertManagerURL != "" {
 		amURL = p.AlertManagerURL
@@ -101,16 +104,7 @@ func (p PrometheusClient) getAlertsFromAM(ctx context.Context, t testing.Testing
 		amURL = fmt.Sprintf("http://%s", amHost)
 	}
 
-	u, err := url.Parse(amURL)
-	if err != nil {
-		return nil, err
-	}
-
-	transport := httptransport.New(u.Host, "/api/v2", []string{u.Scheme})
-	c := amclient.New(transport, strfmt.Default)
-
-	params := alert.NewGetAlertsParams().WithContext(ctx)
-	params.Filter = []string{
+	filters := []string{
 		fmt.Sprintf("namespace=%s", namespace),
 	}
 
@@ -119,14 +113,23 @@ func (p PrometheusClient) getAlertsFromAM(ctx context.Context, t testing.Testing
 			// Assume comma-separated list of filters
 			parts := strings.Split(selector, ",")
 			for _, part := range parts {
-				params.Filter = append(params.Filter, strings.Trim(strings.TrimSpace(part), "{}"))
+				filters = append(filters, strings.Trim(strings.TrimSpace(part), "{}"))
 			}
 		} else {
-			params.Filter = append(params.Filter, fmt.Sprintf("alertname=%s", selector))
+			filters = append(filters, fmt.Sprintf("alertname=%s", selector))
 		}
 	}
+	return amURL, filters
+}
 
-	logger.Default.Logf(t, "Requesting alerts from AM: %s with filters %v", amURL, params.Filter)
+func (p PrometheusClient) queryAlertmanager(ctx context.Context, amURL string, filters []string) ([]*models.GettableAlert, error) {
+	c, err := alertmanagerAPI(amURL)
+	if err != nil {
+		return nil, err
+	}
+
+	params := alert.NewGetAlertsParams().WithContext(ctx)
+	params.Filter = filters
 
 	resp, err := c.Alert.GetAlerts(params)
 	if err != nil {
@@ -135,3 +138,12 @@ func (p PrometheusClient) getAlertsFromAM(ctx context.Context, t testing.Testing
 
 	return resp.Payload, nil
 }
+
+func alertmanagerAPI(amURL string) (*amclient.AlertmanagerAPI, error) {
+	u, err := url.Parse(amURL)
+	if err != nil {
+		return nil, err
+	}
+	transport := httptransport.New(u.Host, "/api/v2", []string{u.Scheme})
+	return amclient.New(transport, strfmt.Default), nil
+}
@@ -0,0 +1,86 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// QueryDiff is the difference between results of a query returned by two endpoints.
+type QueryDiff struct {
+	Query string
+	// Err is set if the query failed on any of the endpoints, so the results were not compared.
+	Err error
+	// Diffs are human-readable differences of the right result compared with the left one.
+	Diffs []string
+}
+
+// String formats the difference as the query followed by indented differences.
+func (d QueryDiff) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s: %s", d.Query, d.Err)
+	}
+	return fmt.Sprintf("%s:\n  %s", d.Query, strings.Join(d.Diffs, "\n  "))
+}
+
+// Differ runs the same queries against two endpoints and reports semantic differences of their results,
+// e.g. VMSingle vs VMCluster, two vmselect versions, or global vs zone endpoints.
+// Series are matched by labels, so their order does not matter.
+type Differ struct {
+	Left  PrometheusClient
+	Right PrometheusClient
+	// LeftName and RightName identify the endpoints in reports.
+	LeftName  string
+	RightName string
+	// Options configure how results are compared. If Options.At is zero,
+	// every instant query is evaluated at the same time on both endpoints.
+	Options GoldenOptions
+}
+
+// Diff runs every query against both endpoints and returns the queries whose results differ.
+func (d Differ) Diff(ctx context.Context, queries ...string) []QueryDiff {
+	opts := d.Options
+	if opts.At.IsZero() {
+		opts.At = time.Now()
+	}
+
+	var diffs []QueryDiff
+	for _, query := range queries {
+		left, err := d.Left.GoldenResult(ctx, query, opts)
+		if err != nil {
+			diffs = append(diffs, QueryDiff{Query: query, Err: fmt.Errorf("%s: %w", d.name(d.LeftName, "left"), err)})
+			continue
+		}
+		right, err := d.Right.GoldenResult(ctx, query, opts)
+		if err != nil {
+			diffs = append(diffs, QueryDiff{Query: query, Err: fmt.Errorf("%s: %w", d.name(d.RightName, "right"), err)})
+			continue
+		}
+		if queryDiffs := CompareGolden(left, right, opts); len(queryDiffs) > 0 {
+			diffs = append(diffs, QueryDiff{Query: query, Diffs: queryDiffs})
+		}
+	}
+	return diffs
+}
+
+func (d Differ) name(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// CheckEqual verifies that every query returns the same result from both endpoints.
+func (d Differ) CheckEqual(ctx context.Context, t testing.TestingT, queries ...string) {
+	diffs := d.Diff(ctx, queries...)
+	lines := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		lines = append(lines, diff.String())
+	}
+	require.Empty(t, lines, "Results of %d of %d queries differ between %s and %s",
+		len(diffs), len(queries), d.name(d.LeftName, "left"), d.name(d.RightName, "right"))
+}
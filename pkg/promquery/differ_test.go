@@ -0,0 +1,74 @@
+package promquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffer(t *testing.T) {
+	t.Parallel()
+	differ := Differ{
+		Left:      newTestClient(t, goldenHandler(t, "2.5")),
+		Right:     newTestClient(t, goldenHandler(t, "2.6")),
+		LeftName:  "vmsingle",
+		RightName: "vmcluster",
+		Options:   GoldenOptions{IgnoreLabels: []string{"vm_account_id"}},
+	}
+	ctx := context.Background()
+
+	diffs := differ.Diff(ctx, "sum(m)", "scalar(m)", `"e2e"`, "unknown")
+	require.Len(t, diffs, 3)
+	assert.Equal(t, QueryDiff{Query: "sum(m)", Diffs: []string{`series {job="b"} sample 0 has value 2.6, want 2.5`}}, diffs[0])
+	assert.Equal(t, "scalar(m)", diffs[1].Query)
+	assert.Equal(t, "unknown", diffs[2].Query)
+	require.ErrorContains(t, diffs[2].Err, "vmsingle: ")
+	assert.Equal(t, "sum(m):\n  series {job=\"b\"} sample 0 has value 2.6, want 2.5", diffs[0].String())
+
+	differ.Options.Tolerance = 0.1
+	assert.Empty(t, differ.Diff(ctx, "sum(m)", "scalar(m)"))
+	differ.CheckEqual(ctx, t, "sum(m)", "scalar(m)", `"e2e"`)
+
+	differ.Options.Tolerance = 0
+	mock := &mockTestingT{}
+	differ.CheckEqual(ctx, mock, "sum(m)", "scalar(m)")
+	assert.True(t, mock.failed)
+	assert.Contains(t, mock.errors[0], "Results of 2 of 2 queries differ between vmsingle and vmcluster")
+}
+
+func TestDiffer_SameEvaluationTime(t *testing.T) {
+	t.Parallel()
+	var (
+		mu    sync.Mutex
+		times []string
+	)
+	newServer := func() PrometheusClient {
+		return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			mu.Lock()
+			times = append(times, r.Form.Get("time"))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, queryResponse("vector", "[]"))
+		}))
+	}
+
+	differ := Differ{Left: newServer(), Right: newServer()}
+	assert.Empty(t, differ.Diff(context.Background(), "a", "b"))
+	require.Len(t, times, 4)
+	for _, ts := range times {
+		assert.Equal(t, times[0], ts, "Every query should be evaluated at the same time")
+	}
+}
+
+func TestQueryDiff_String(t *testing.T) {
+	t.Parallel()
+	d := QueryDiff{Query: "up", Err: errors.New("right: timeout")}
+	assert.Equal(t, "up: right: timeout", d.String())
+}
@@ -0,0 +1,334 @@
+package promquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+)
+
+const (
+	defaultPollTimeout  = 3 * time.Minute
+	defaultPollInterval = time.Second
+	defaultPollBackoff  = 2
+	// maxReportedSeries limits the number of series of the last result included in a PollError.
+	maxReportedSeries = 10
+)
+
+// PollOptions configures Eventually.
+type PollOptions struct {
+	// Timeout bounds polling unless ctx has an earlier deadline. Defaults to 3m.
+	Timeout time.Duration
+	// Interval is the delay after the first attempt. Defaults to 1s.
+	Interval time.Duration
+	// MaxInterval caps the delay between attempts. Defaults to consts.PollingInterval.
+	MaxInterval time.Duration
+	// Backoff multiplies the delay after every attempt. Defaults to 2, values below 1 are treated as 1.
+	Backoff float64
+	// At is the evaluation time of the instant query. Defaults to the time of every attempt.
+	At time.Time
+}
+
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultPollTimeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultPollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = consts.PollingInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.Backoff == 0 {
+		o.Backoff = defaultPollBackoff
+	}
+	o.Backoff = math.Max(o.Backoff, 1)
+	return o
+}
+
+// Predicate checks a query result. It returns nil if the result is the expected one
+// and an error describing the mismatch otherwise.
+type Predicate func(prommodel.Value) error
+
+// ValueEquals expects a non-empty vector or a scalar whose every value equals v.
+func ValueEquals(v float64) Predicate {
+	return ValueInDelta(v, 0)
+}
+
+// ValueInDelta expects a non-empty vector or a scalar whose every value is within delta of v.
+func ValueInDelta(v, delta float64) Predicate {
+	return func(result prommodel.Value) error {
+		values, err := resultValues(result)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return errors.New(consts.ErrNoDataReturned)
+		}
+		for _, got := range values {
+			if math.Abs(got-v) > delta || math.IsNaN(got) {
+				if delta == 0 {
+					return fmt.Errorf("value is %v, want %v", got, v)
+				}
+				return fmt.Errorf("value is %v, want %v±%v", got, v, delta)
+			}
+		}
+		return nil
+	}
+}
+
+// SeriesCountAtLeast expects a vector or a matrix with at least n series.
+func SeriesCountAtLeast(n int) Predicate {
+	return func(result prommodel.Value) error {
+		count, err := seriesCount(result)
+		if err != nil {
+			return err
+		}
+		if count < n {
+			return fmt.Errorf("got %d series, want at least %d", count, n)
+		}
+		return nil
+	}
+}
+
+// SeriesCountEquals expects a vector or a matrix with exactly n series.
+func SeriesCountEquals(n int) Predicate {
+	return func(result prommodel.Value) error {
+		count, err := seriesCount(result)
+		if err != nil {
+			return err
+		}
+		if count != n {
+			return fmt.Errorf("got %d series, want %d", count, n)
+		}
+		return nil
+	}
+}
+
+// Present expects a vector or a matrix with at least one series.
+func Present() Predicate {
+	return SeriesCountAtLeast(1)
+}
+
+// Absent expects an empty vector or matrix, e.g. after series were deleted or became stale.
+func Absent() Predicate {
+	return SeriesCountEquals(0)
+}
+
+// HasLabels expects a non-empty vector or matrix whose every series has the given label values.
+// Other labels of the series are ignored.
+func HasLabels(labels prommodel.Metric) Predicate {
+	return func(result prommodel.Value) error {
+		metrics, err := seriesMetrics(result)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			for name, want := range labels {
+				if got, ok := m[name]; !ok || got != want {
+					return fmt.Errorf("series %s has %s=%q, want %q", m, name, got, want)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// MetricEquals expects a non-empty vector or matrix whose every series has exactly the given name and labels.
+func MetricEquals(metric prommodel.Metric) Predicate {
+	return func(result prommodel.Value) error {
+		metrics, err := seriesMetrics(result)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if !m.Equal(metric) {
+				return fmt.Errorf("series is %s, want %s", m, metric)
+			}
+		}
+		return nil
+	}
+}
+
+// All expects every predicate to hold. The first failing predicate is reported.
+func All(preds ...Predicate) Predicate {
+	return func(result prommodel.Value) error {
+		for _, pred := range preds {
+			if err := pred(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func seriesCount(result prommodel.Value) (int, error) {
+	switch v := result.(type) {
+	case prommodel.Vector:
+		return len(v), nil
+	case prommodel.Matrix:
+		return len(v), nil
+	default:
+		return 0, fmt.Errorf("unexpected result type: %s, expected %s or %s", resultType(result), prommodel.ValVector, prommodel.ValMatrix)
+	}
+}
+
+// seriesMetrics returns the names and labels of a non-empty vector or matrix.
+func seriesMetrics(result prommodel.Value) ([]prommodel.Metric, error) {
+	var metrics []prommodel.Metric
+	switch v := result.(type) {
+	case prommodel.Vector:
+		for _, s := range v {
+			metrics = append(metrics, s.Metric)
+		}
+	case prommodel.Matrix:
+		for _, s := range v {
+			metrics = append(metrics, s.Metric)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected result type: %s, expected %s or %s", resultType(result), prommodel.ValVector, prommodel.ValMatrix)
+	}
+	if len(metrics) == 0 {
+		return nil, errors.New(consts.ErrNoDataReturned)
+	}
+	return metrics, nil
+}
+
+func resultValues(result prommodel.Value) ([]float64, error) {
+	switch v := result.(type) {
+	case prommodel.Vector:
+		values := make([]float64, 0, len(v))
+		for _, s := range v {
+			values = append(values, float64(s.Value))
+		}
+		return values, nil
+	case *prommodel.Scalar:
+		return []float64{float64(v.Value)}, nil
+	default:
+		return nil, fmt.Errorf("unexpected result type: %s, expected %s or %s", resultType(result), prommodel.ValVector, prommodel.ValScalar)
+	}
+}
+
+func resultType(result prommodel.Value) string {
+	if result == nil {
+		return "none"
+	}
+	return result.Type().String()
+}
+
+// PollError is returned by Eventually if the query result did not satisfy the predicate in time.
+// It keeps the outcome of the last attempt.
+type PollError struct {
+	Query    string
+	Attempts int
+	Elapsed  time.Duration
+	// Result and Warnings are from the last successful query, if any.
+	Result   prommodel.Value
+	Warnings promv1.Warnings
+	// QueryErr is the last query error, if any.
+	QueryErr error
+	// PredicateErr is the last reason the result was rejected, if any.
+	PredicateErr error
+}
+
+// Error describes the last attempt, so a failed spec tells whether the data was missing,
+// wrong or could not be queried at all.
+func (e *PollError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "query %s did not return the expected result after %d attempts in %s", e.Query, e.Attempts, e.Elapsed.Round(time.Millisecond))
+	if e.PredicateErr != nil {
+		fmt.Fprintf(&sb, "\nlast mismatch: %s", e.PredicateErr)
+	}
+	if e.QueryErr != nil {
+		fmt.Fprintf(&sb, "\nlast query error: %s", e.QueryErr)
+	}
+	if len(e.Warnings) > 0 {
+		fmt.Fprintf(&sb, "\nwarnings: %s", strings.Join(e.Warnings, "; "))
+	}
+	if e.Result != nil {
+		fmt.Fprintf(&sb, "\nlast result (%s):\n%s", e.Result.Type(), describeResult(e.Result))
+	}
+	return sb.String()
+}
+
+func describeResult(result prommodel.Value) string {
+	var lines []string
+	switch v := result.(type) {
+	case prommodel.Vector:
+		for _, s := range v {
+			lines = append(lines, s.String())
+		}
+	case prommodel.Matrix:
+		for _, s := range v {
+			lines = append(lines, s.String())
+		}
+	default:
+		return "  " + result.String()
+	}
+	if len(lines) == 0 {
+		return "  (empty)"
+	}
+	more := len(lines) - maxReportedSeries
+	if more > 0 {
+		lines = append(lines[:maxReportedSeries], fmt.Sprintf("... and %d more series", more))
+	}
+	return "  " + strings.Join(lines, "\n  ")
+}
+
+// Eventually runs the instant query until pred accepts its result and returns that result.
+// Failed queries are retried as well. The delay between attempts grows from opts.Interval
+// to opts.MaxInterval. If opts.Timeout expires or ctx is done first, a *PollError is returned.
+func (p PrometheusClient) Eventually(ctx context.Context, query string, pred Predicate, opts PollOptions) (prommodel.Value, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	pollErr := &PollError{Query: query}
+	interval := opts.Interval
+	for {
+		at := opts.At
+		if at.IsZero() {
+			at = time.Now()
+		}
+		result, warnings, err := p.QueryAt(ctx, query, at)
+		pollErr.Attempts++
+		if err == nil {
+			pollErr.Result, pollErr.Warnings, pollErr.QueryErr = result, warnings, nil
+			if pollErr.PredicateErr = pred(result); pollErr.PredicateErr == nil {
+				return result, nil
+			}
+		} else if ctx.Err() == nil || pollErr.Attempts == 1 {
+			// A query interrupted by the deadline tells nothing new about the data.
+			pollErr.QueryErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			pollErr.Elapsed = time.Since(started)
+			return pollErr.Result, pollErr
+		case <-time.After(interval):
+		}
+		interval = time.Duration(math.Min(float64(interval)*opts.Backoff, float64(opts.MaxInterval)))
+	}
+}
+
+// CheckEventually verifies that the instant query returns a result accepted by pred
+// before opts.Timeout expires, and returns that result.
+func (p PrometheusClient) CheckEventually(ctx context.Context, t testing.TestingT, query string, pred Predicate, opts PollOptions) prommodel.Value {
+	result, err := p.Eventually(ctx, query, pred, opts)
+	require.NoError(t, err)
+	return result
+}
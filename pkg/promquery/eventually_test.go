@@ -0,0 +1,143 @@
+package promquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func vectorResponse(values ...string) string {
+	result := ""
+	for i, v := range values {
+		if i > 0 {
+			result += ","
+		}
+		result += fmt.Sprintf(`{"metric":{"__name__":"m_%d"},"value":[1704103200,%q]}`, i, v)
+	}
+	return queryResponse("vector", "["+result+"]")
+}
+
+var fastPoll = PollOptions{Timeout: 2 * time.Second, Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+func TestEventually(t *testing.T) {
+	t.Parallel()
+	client, calls := newCannedClient(t, "", vectorResponse(), vectorResponse("1"), vectorResponse("5"))
+
+	result, err := client.Eventually(context.Background(), "m", ValueEquals(5), fastPoll)
+	require.NoError(t, err)
+	vec, err := AsVector(result)
+	require.NoError(t, err)
+	require.Len(t, vec, 1)
+	assert.Equal(t, prommodel.SampleValue(5), vec[0].Value)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestEventually_Timeout(t *testing.T) {
+	t.Parallel()
+	client, _ := newCannedClient(t, vectorResponse("1", "2"))
+
+	opts := fastPoll
+	opts.Timeout = 50 * time.Millisecond
+	_, err := client.Eventually(context.Background(), "m", ValueEquals(5), opts)
+	var pollErr *PollError
+	require.True(t, errors.As(err, &pollErr))
+	assert.Greater(t, pollErr.Attempts, 1)
+	assert.NoError(t, pollErr.QueryErr)
+	assert.EqualError(t, pollErr.PredicateErr, "value is 1, want 5")
+	assert.Contains(t, err.Error(), "query m did not return the expected result after")
+	assert.Contains(t, err.Error(), "last mismatch: value is 1, want 5")
+	assert.Contains(t, err.Error(), `m_1 => 2 @[1704103200]`)
+}
+
+func TestEventually_QueryError(t *testing.T) {
+	t.Parallel()
+	client, _ := newCannedClient(t, "")
+
+	opts := fastPoll
+	opts.Timeout = 50 * time.Millisecond
+	_, err := client.Eventually(context.Background(), "m", Present(), opts)
+	require.ErrorContains(t, err, "last query error:")
+	assert.NotContains(t, err.Error(), "last result")
+}
+
+func TestEventually_ContextCanceled(t *testing.T) {
+	t.Parallel()
+	client, calls := newCannedClient(t, vectorResponse())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.Eventually(ctx, "m", Present(), PollOptions{})
+	require.ErrorContains(t, err, "after 1 attempts")
+	assert.ErrorContains(t, err, "context canceled")
+	assert.Zero(t, calls.Load())
+}
+
+func TestPredicates(t *testing.T) {
+	t.Parallel()
+	vec := prommodel.Vector{{Value: 5}, {Value: 5.1}}
+	matrix := prommodel.Matrix{{}, {}, {}}
+	scalar := &prommodel.Scalar{Value: 5}
+	labeled := prommodel.Vector{{Metric: prommodel.Metric{"__name__": "m", "job": "a"}, Value: 1}}
+
+	tests := []struct {
+		name string
+		pred Predicate
+		v    prommodel.Value
+		err  string
+	}{
+		{"ValueEquals scalar", ValueEquals(5), scalar, ""},
+		{"ValueEquals mismatch", ValueEquals(5), vec, "value is 5.1, want 5"},
+		{"ValueEquals empty", ValueEquals(5), prommodel.Vector{}, "no data returned"},
+		{"ValueEquals matrix", ValueEquals(5), matrix, "unexpected result type: matrix, expected vector or scalar"},
+		{"ValueInDelta", ValueInDelta(5, 0.2), vec, ""},
+		{"ValueInDelta mismatch", ValueInDelta(4, 0.5), vec, "value is 5, want 4±0.5"},
+		{"SeriesCountAtLeast", SeriesCountAtLeast(3), matrix, ""},
+		{"SeriesCountAtLeast mismatch", SeriesCountAtLeast(3), vec, "got 2 series, want at least 3"},
+		{"SeriesCountEquals mismatch", SeriesCountEquals(3), prommodel.Vector{}, "got 0 series, want 3"},
+		{"SeriesCountEquals scalar", SeriesCountEquals(1), scalar, "unexpected result type: scalar, expected vector or matrix"},
+		{"Present", Present(), vec, ""},
+		{"Absent", Absent(), prommodel.Vector{}, ""},
+		{"Absent mismatch", Absent(), vec, "got 2 series, want 0"},
+		{"HasLabels", HasLabels(prommodel.Metric{"job": "a"}), labeled, ""},
+		{"HasLabels mismatch", HasLabels(prommodel.Metric{"job": "a", "env": "dev"}), labeled, `series m{job="a"} has env="", want "dev"`},
+		{"HasLabels empty", HasLabels(prommodel.Metric{"job": "a"}), prommodel.Matrix{}, "no data returned"},
+		{"MetricEquals", MetricEquals(prommodel.Metric{"__name__": "m", "job": "a"}), labeled, ""},
+		{"MetricEquals extra label", MetricEquals(prommodel.Metric{"__name__": "m"}), labeled, `series is m{job="a"}, want m`},
+		{"MetricEquals scalar", MetricEquals(prommodel.Metric{}), scalar, "unexpected result type: scalar, expected vector or matrix"},
+		{"All", All(Present(), ValueInDelta(5, 0.2)), vec, ""},
+		{"All mismatch", All(SeriesCountEquals(2), ValueEquals(5)), vec, "value is 5.1, want 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pred(tt.v)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestCheckEventually(t *testing.T) {
+	t.Parallel()
+	client, _ := newCannedClient(t, vectorResponse("1"))
+
+	mockTest := &mockTestingT{}
+	client.CheckEventually(context.Background(), mockTest, "m", ValueEquals(1), fastPoll)
+	assert.False(t, mockTest.failed)
+
+	opts := fastPoll
+	opts.Timeout = 20 * time.Millisecond
+	mockTest = &mockTestingT{}
+	client.CheckEventually(context.Background(), mockTest, "m", Absent(), opts)
+	assert.True(t, mockTest.failed)
+	require.Len(t, mockTest.errors, 1)
+	assert.Contains(t, mockTest.errors[0], "got 1 series, want 0")
+}
@@ -0,0 +1,307 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+)
+
+// GoldenSample is a sample of a golden query result.
+// Value is formatted like in the Prometheus API, so NaN and Inf values can be recorded.
+type GoldenSample struct {
+	// Timestamp is in milliseconds. It is not recorded if timestamps are ignored.
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Value     string `json:"value"`
+}
+
+// GoldenSeries is a series of a golden query result. Scalar and string results are recorded
+// as a single series without labels.
+type GoldenSeries struct {
+	Metric  map[string]string `json:"metric"`
+	Samples []GoldenSample    `json:"samples"`
+}
+
+// GoldenResult is the recorded result of a query. A golden file is a list of GoldenResult.
+type GoldenResult struct {
+	Query      string         `json:"query"`
+	ResultType string         `json:"resultType,omitempty"`
+	Series     []GoldenSeries `json:"series"`
+}
+
+// GoldenOptions configures how query results are compared with golden files.
+type GoldenOptions struct {
+	// Tolerance is the allowed relative difference between values. Zero requires exact values, NaN equals NaN.
+	Tolerance float64
+	// IgnoreTimestamps compares only values, e.g. for instant queries evaluated at the current time.
+	// Timestamps are not recorded when golden files are updated.
+	IgnoreTimestamps bool
+	// Labels limits compared labels to these names. If empty, all labels are compared.
+	Labels []string
+	// IgnoreLabels are not compared, e.g. tenant labels added to multitenant query results.
+	IgnoreLabels []string
+	// At is the evaluation time of instant queries. Defaults to now.
+	At time.Time
+	// Range runs range queries with these options instead of instant queries.
+	Range *RangeOptions
+}
+
+func (o GoldenOptions) keepLabel(name string) bool {
+	for _, l := range o.IgnoreLabels {
+		if l == name {
+			return false
+		}
+	}
+	if len(o.Labels) == 0 {
+		return true
+	}
+	for _, l := range o.Labels {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadGolden reads golden results from a JSON or YAML file.
+func LoadGolden(path string) ([]GoldenResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read golden file: %w", err)
+	}
+	var results []GoldenResult
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, &results)
+	} else {
+		err = json.Unmarshal(data, &results)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse golden file %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// WriteGolden writes golden results to a JSON or YAML file depending on its extension.
+func WriteGolden(path string, results []GoldenResult) error {
+	var (
+		data []byte
+		err  error
+	)
+	if isYAML(path) {
+		data, err = yaml.Marshal(results)
+	} else {
+		data, err = json.MarshalIndent(results, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("cannot marshal golden results: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create golden file directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func isYAML(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// GoldenResult runs the query and converts its result for comparison with a golden file.
+func (p PrometheusClient) GoldenResult(ctx context.Context, query string, opts GoldenOptions) (GoldenResult, error) {
+	res := GoldenResult{Query: query}
+	if opts.Range != nil {
+		m, err := p.QueryMatrix(ctx, query, *opts.Range)
+		if err != nil {
+			return res, err
+		}
+		res.ResultType = prommodel.ValMatrix.String()
+		for _, s := range m {
+			gs := GoldenSeries{Metric: goldenMetric(s.Metric, opts)}
+			for _, v := range s.Values {
+				gs.Samples = append(gs.Samples, goldenSample(int64(v.Timestamp), formatGoldenValue(float64(v.Value)), opts))
+			}
+			res.Series = append(res.Series, gs)
+		}
+		sortGoldenSeries(res.Series)
+		return res, nil
+	}
+
+	at := opts.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	result, _, err := p.QueryAt(ctx, query, at)
+	if err != nil {
+		// The Prometheus API client cannot decode string results
+		if s, serr := p.QueryString(ctx, query); serr == nil {
+			res.ResultType = prommodel.ValString.String()
+			res.Series = []GoldenSeries{{Metric: map[string]string{}, Samples: []GoldenSample{goldenSample(int64(s.Timestamp), s.Value, opts)}}}
+			return res, nil
+		}
+		return res, err
+	}
+	res.ResultType = result.Type().String()
+	switch v := result.(type) {
+	case prommodel.Vector:
+		for _, s := range v {
+			res.Series = append(res.Series, GoldenSeries{
+				Metric:  goldenMetric(s.Metric, opts),
+				Samples: []GoldenSample{goldenSample(int64(s.Timestamp), formatGoldenValue(float64(s.Value)), opts)},
+			})
+		}
+		sortGoldenSeries(res.Series)
+	case *prommodel.Scalar:
+		res.Series = []GoldenSeries{{Metric: map[string]string{}, Samples: []GoldenSample{goldenSample(int64(v.Timestamp), formatGoldenValue(float64(v.Value)), opts)}}}
+	default:
+		return res, fmt.Errorf("query %s: unexpected result type: %s", query, result.Type())
+	}
+	return res, nil
+}
+
+func goldenMetric(m prommodel.Metric, opts GoldenOptions) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		if opts.keepLabel(string(k)) {
+			res[string(k)] = string(v)
+		}
+	}
+	return res
+}
+
+func goldenSample(ts int64, value string, opts GoldenOptions) GoldenSample {
+	if opts.IgnoreTimestamps {
+		ts = 0
+	}
+	return GoldenSample{Timestamp: ts, Value: value}
+}
+
+func formatGoldenValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func goldenKey(metric map[string]string) string {
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func sortGoldenSeries(series []GoldenSeries) {
+	sort.Slice(series, func(i, j int) bool { return goldenKey(series[i].Metric) < goldenKey(series[j].Metric) })
+}
+
+// CompareGolden returns human-readable differences between the recorded and the actual result.
+// Series are matched by labels, so their order does not matter.
+func CompareGolden(want, got GoldenResult, opts GoldenOptions) []string {
+	var diffs []string
+	if want.ResultType != "" && want.ResultType != got.ResultType {
+		diffs = append(diffs, fmt.Sprintf("result type is %s, want %s", got.ResultType, want.ResultType))
+	}
+
+	gotByKey := map[string]GoldenSeries{}
+	for _, s := range got.Series {
+		gotByKey[goldenKey(s.Metric)] = s
+	}
+	for _, w := range want.Series {
+		key := goldenKey(w.Metric)
+		g, ok := gotByKey[key]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("missing series %s", key))
+			continue
+		}
+		delete(gotByKey, key)
+		diffs = append(diffs, compareGoldenSamples(key, w.Samples, g.Samples, opts)...)
+	}
+	keys := make([]string, 0, len(gotByKey))
+	for key := range gotByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		diffs = append(diffs, fmt.Sprintf("unexpected series %s", key))
+	}
+	return diffs
+}
+
+func compareGoldenSamples(key string, want, got []GoldenSample, opts GoldenOptions) []string {
+	if len(want) != len(got) {
+		return []string{fmt.Sprintf("series %s has %d samples, want %d", key, len(got), len(want))}
+	}
+	var diffs []string
+	for i := range want {
+		if !opts.IgnoreTimestamps && want[i].Timestamp != got[i].Timestamp {
+			diffs = append(diffs, fmt.Sprintf("series %s sample %d has timestamp %d, want %d", key, i, got[i].Timestamp, want[i].Timestamp))
+		}
+		if !goldenValueEqual(want[i].Value, got[i].Value, opts.Tolerance) {
+			diffs = append(diffs, fmt.Sprintf("series %s sample %d has value %s, want %s", key, i, got[i].Value, want[i].Value))
+		}
+	}
+	return diffs
+}
+
+// goldenValueEqual compares values as numbers if both are numbers and as strings otherwise.
+func goldenValueEqual(want, got string, tolerance float64) bool {
+	w, werr := strconv.ParseFloat(want, 64)
+	g, gerr := strconv.ParseFloat(got, 64)
+	if werr != nil || gerr != nil {
+		return want == got
+	}
+	if math.IsNaN(w) || math.IsNaN(g) {
+		return math.IsNaN(w) && math.IsNaN(g)
+	}
+	if w == g {
+		return true
+	}
+	return math.Abs(w-g) <= tolerance*math.Max(1, math.Abs(w))
+}
+
+// CheckGolden runs every query of the golden file at path and compares the results with the recorded ones.
+// If the -update-golden flag is set, the results are recorded to the file instead, so a new case
+// is added by appending an entry with only a query to the file.
+func (p PrometheusClient) CheckGolden(ctx context.Context, t testing.TestingT, path string, opts GoldenOptions) {
+	want, err := LoadGolden(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, want, "Golden file %s has no queries", path)
+
+	got := make([]GoldenResult, 0, len(want))
+	for _, w := range want {
+		res, err := p.GoldenResult(ctx, w.Query, opts)
+		require.NoError(t, err, "Failed to query %s", w.Query)
+		got = append(got, res)
+	}
+
+	if consts.UpdateGolden() {
+		require.NoError(t, WriteGolden(path, got))
+		logger.Default.Logf(t, "Updated golden file %s", path)
+		return
+	}
+
+	var diffs []string
+	for i := range want {
+		for _, d := range CompareGolden(want[i], got[i], opts) {
+			diffs = append(diffs, fmt.Sprintf("%s: %s", want[i].Query, d))
+		}
+	}
+	require.Empty(t, diffs, "Query results differ from golden file %s, run with -update-golden to record them", path)
+}
@@ -0,0 +1,145 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+)
+
+// goldenHandler answers the queries of golden tests, using value for the samples that differ between servers.
+func goldenHandler(t *testing.T, value string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch query := r.Form.Get("query"); {
+		case r.URL.Path == "/api/v1/query_range":
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"m","job":"a"},"values":[[1704103200,%q],[1704103260,"NaN"]]}
+			]}}`, value)
+		case query == "sum(m)":
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"job":"b","vm_account_id":"1"},"value":[1704103200,%q]},
+				{"metric":{"job":"a","vm_account_id":"1"},"value":[1704103200,"1"]}
+			]}}`, value)
+		case query == "scalar(m)":
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"scalar","result":[1704103200,%q]}}`, value)
+		case query == `"e2e"`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"string","result":[1704103200,"e2e"]}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unknown query"}`)
+		}
+	})
+}
+
+func TestGoldenResult(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(t, goldenHandler(t, "2.5"))
+	ctx := context.Background()
+
+	res, err := client.GoldenResult(ctx, "sum(m)", GoldenOptions{IgnoreTimestamps: true, IgnoreLabels: []string{"vm_account_id"}})
+	require.NoError(t, err)
+	assert.Equal(t, GoldenResult{Query: "sum(m)", ResultType: "vector", Series: []GoldenSeries{
+		{Metric: map[string]string{"job": "a"}, Samples: []GoldenSample{{Value: "1"}}},
+		{Metric: map[string]string{"job": "b"}, Samples: []GoldenSample{{Value: "2.5"}}},
+	}}, res)
+
+	res, err = client.GoldenResult(ctx, "scalar(m)", GoldenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []GoldenSeries{{Metric: map[string]string{}, Samples: []GoldenSample{{Timestamp: 1704103200000, Value: "2.5"}}}}, res.Series)
+
+	res, err = client.GoldenResult(ctx, `"e2e"`, GoldenOptions{IgnoreTimestamps: true})
+	require.NoError(t, err)
+	assert.Equal(t, "string", res.ResultType)
+	assert.Equal(t, []GoldenSample{{Value: "e2e"}}, res.Series[0].Samples)
+
+	res, err = client.GoldenResult(ctx, "m", GoldenOptions{Labels: []string{"job"}, Range: &RangeOptions{Step: time.Minute}})
+	require.NoError(t, err)
+	assert.Equal(t, []GoldenSeries{{Metric: map[string]string{"job": "a"}, Samples: []GoldenSample{
+		{Timestamp: 1704103200000, Value: "2.5"},
+		{Timestamp: 1704103260000, Value: "NaN"},
+	}}}, res.Series)
+
+	_, err = client.GoldenResult(ctx, "unknown", GoldenOptions{})
+	require.ErrorContains(t, err, "unknown query")
+}
+
+func TestCompareGolden(t *testing.T) {
+	t.Parallel()
+	want := GoldenResult{Query: "q", ResultType: "vector", Series: []GoldenSeries{
+		{Metric: map[string]string{"job": "a"}, Samples: []GoldenSample{{Timestamp: 1000, Value: "100"}}},
+		{Metric: map[string]string{"job": "b"}, Samples: []GoldenSample{{Timestamp: 1000, Value: "NaN"}}},
+		{Metric: map[string]string{"job": "c"}, Samples: []GoldenSample{{Timestamp: 1000, Value: "1"}}},
+	}}
+	got := GoldenResult{Query: "q", ResultType: "matrix", Series: []GoldenSeries{
+		{Metric: map[string]string{"job": "d"}, Samples: []GoldenSample{{Timestamp: 1000, Value: "1"}}},
+		{Metric: map[string]string{"job": "b"}, Samples: []GoldenSample{{Timestamp: 1000, Value: "NaN"}}},
+		{Metric: map[string]string{"job": "a"}, Samples: []GoldenSample{{Timestamp: 2000, Value: "100.01"}}},
+	}}
+
+	assert.Equal(t, []string{
+		"result type is matrix, want vector",
+		`series {job="a"} sample 0 has timestamp 2000, want 1000`,
+		`series {job="a"} sample 0 has value 100.01, want 100`,
+		`missing series {job="c"}`,
+		`unexpected series {job="d"}`,
+	}, CompareGolden(want, got, GoldenOptions{}))
+
+	got.ResultType = "vector"
+	got.Series[0].Metric["job"] = "c"
+	assert.Empty(t, CompareGolden(want, got, GoldenOptions{Tolerance: 1e-3, IgnoreTimestamps: true}))
+
+	got.Series[2].Samples = append(got.Series[2].Samples, GoldenSample{Value: "1"})
+	assert.Equal(t, []string{`series {job="a"} has 2 samples, want 1`}, CompareGolden(want, got, GoldenOptions{IgnoreTimestamps: true}))
+}
+
+func TestGoldenFiles(t *testing.T) {
+	t.Parallel()
+	results := []GoldenResult{{Query: "sum(m)", ResultType: "vector", Series: []GoldenSeries{
+		{Metric: map[string]string{"job": "a"}, Samples: []GoldenSample{{Value: "+Inf"}}},
+	}}}
+	for _, name := range []string{"golden.json", "golden.yaml"} {
+		path := filepath.Join(t.TempDir(), "nested", name)
+		require.NoError(t, WriteGolden(path, results))
+		loaded, err := LoadGolden(path)
+		require.NoError(t, err)
+		assert.Equal(t, results, loaded, name)
+	}
+
+	_, err := LoadGolden(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.ErrorContains(t, err, "cannot read golden file")
+}
+
+func TestCheckGolden(t *testing.T) {
+	// Not parallel: the test changes the global -update-golden setting.
+	path := filepath.Join(t.TempDir(), "golden.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("- query: sum(m)\n- query: scalar(m)\n"), 0o644))
+	opts := GoldenOptions{IgnoreTimestamps: true, Tolerance: 0.01}
+
+	consts.SetUpdateGolden(true)
+	newTestClient(t, goldenHandler(t, "2.5")).CheckGolden(context.Background(), t, path, opts)
+	consts.SetUpdateGolden(false)
+
+	recorded, err := LoadGolden(path)
+	require.NoError(t, err)
+	require.Len(t, recorded, 2)
+	assert.Len(t, recorded[0].Series, 2)
+	assert.Equal(t, "scalar", recorded[1].ResultType)
+
+	// Values within tolerance match the recorded results
+	newTestClient(t, goldenHandler(t, "2.51")).CheckGolden(context.Background(), t, path, opts)
+
+	mock := &mockTestingT{}
+	newTestClient(t, goldenHandler(t, "3")).CheckGolden(context.Background(), mock, path, opts)
+	assert.True(t, mock.failed, "Expected CheckGolden to fail on changed values")
+	assert.Contains(t, mock.errors[0], "scalar(m): series {} sample 0 has value 3, want 2.5")
+}
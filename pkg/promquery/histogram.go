@@ -0,0 +1,35 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
+)
+
+// histogramQuantileTolerance is the relative difference allowed between queried and expected quantiles.
+const histogramQuantileTolerance = 1e-6
+
+// HistogramQuantileQuery returns a query calculating the q-quantile over classic histogram buckets
+// matching bucketSelector, aggregated by le.
+func HistogramQuantileQuery(q float64, bucketSelector string) string {
+	return fmt.Sprintf("histogram_quantile(%g, sum(%s) by (le))", q, bucketSelector)
+}
+
+// CheckHistogramQuantiles verifies that histogram_quantile over the buckets matching bucketSelector
+// returns the quantiles expected for the generated distribution d.
+func (p PrometheusClient) CheckHistogramQuantiles(ctx context.Context, t testing.TestingT, bucketSelector string, d remotewrite.Distribution, quantiles ...float64) {
+	for _, q := range quantiles {
+		query := HistogramQuantileQuery(q, bucketSelector)
+		_, value, err := p.VectorScan(ctx, query)
+		require.NoError(t, err, "Failed to query %s", query)
+
+		expected := d.HistogramQuantile(q)
+		delta := histogramQuantileTolerance * math.Max(1, math.Abs(expected))
+		require.InDelta(t, expected, float64(value), delta, "Unexpected result of %s", query)
+	}
+}
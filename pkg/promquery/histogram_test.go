@@ -0,0 +1,51 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
+)
+
+func TestCheckHistogramQuantiles(t *testing.T) {
+	t.Parallel()
+	d := remotewrite.Distribution{
+		Buckets:      []float64{0.1, 0.5, 1},
+		Observations: []float64{0.05, 0.2, 0.3, 0.4, 0.7, 2},
+	}
+
+	newClient := func(t *testing.T, results map[string]float64) PrometheusClient {
+		return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			value, ok := results[r.Form.Get("query")]
+			assert.True(t, ok, "Unexpected query %q", r.Form.Get("query"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"%g"]}]}}`, value)
+		}))
+	}
+
+	t.Run("matching", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, map[string]float64{
+			`histogram_quantile(0.5, sum(latency_bucket) by (le))`:  d.HistogramQuantile(0.5),
+			`histogram_quantile(0.99, sum(latency_bucket) by (le))`: d.HistogramQuantile(0.99),
+		})
+		mockTest := &mockTestingT{}
+		client.CheckHistogramQuantiles(context.Background(), mockTest, "latency_bucket", d, 0.5, 0.99)
+		assert.False(t, mockTest.failed, "Should not fail when quantiles match: %v", mockTest.errors)
+	})
+
+	t.Run("mismatching", func(t *testing.T) {
+		t.Parallel()
+		client := newClient(t, map[string]float64{
+			`histogram_quantile(0.5, sum(latency_bucket) by (le))`: 0.9,
+		})
+		mockTest := &mockTestingT{}
+		client.CheckHistogramQuantiles(context.Background(), mockTest, "latency_bucket", d, 0.5)
+		assert.True(t, mockTest.failed, "Should fail when quantile differs")
+	})
+}
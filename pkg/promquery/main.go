@@ -2,7 +2,11 @@ package promquery
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"time"
 
 	promapi "github.com/prometheus/client_golang/api"
@@ -19,34 +23,149 @@ const (
 // It keeps track of a Start time for range queries.
 type PrometheusClient struct {
 	client promv1.API
-	Start  time.Time
+	// raw sends requests the Prometheus API client cannot decode, e.g. queries returning strings.
+	raw   promapi.Client
+	Start time.Time
 	// AlertManagerURL is the URL of the Alertmanager to use for alert checks.
 	// If empty, the URL is derived from the namespace.
 	AlertManagerURL string
 }
 
+// ClientOptions configures authentication and transport of a PrometheusClient.
+type ClientOptions struct {
+	// Headers are added to every request, e.g. Authorization or tenant headers.
+	Headers http.Header
+	// TLSConfig is used for HTTPS connections, e.g. to present a client certificate.
+	TLSConfig *tls.Config
+}
+
 // NewPrometheusClient creates a new PrometheusClient for the given URL.
 func NewPrometheusClient(url string) (PrometheusClient, error) {
+	return NewPrometheusClientWithOptions(url, ClientOptions{})
+}
+
+// NewPrometheusClientWithOptions creates a new PrometheusClient for the given URL
+// sending the configured headers and using the configured TLS settings.
+func NewPrometheusClientWithOptions(url string, opts ClientOptions) (PrometheusClient, error) {
+	var rt http.RoundTripper = promapi.DefaultRoundTripper
+	if opts.TLSConfig != nil {
+		transport := promapi.DefaultRoundTripper.(*http.Transport).Clone()
+		transport.TLSClientConfig = opts.TLSConfig
+		rt = transport
+	}
+	if len(opts.Headers) > 0 {
+		rt = &headerRoundTripper{next: rt, headers: opts.Headers}
+	}
+
 	promClient, err := promapi.NewClient(promapi.Config{
-		Address: url,
+		Address:      url,
+		RoundTripper: rt,
 	})
 	if err != nil {
 		return PrometheusClient{}, err
 	}
 	promv1api := promv1.NewAPI(promClient)
-	return PrometheusClient{client: promv1api}, nil
+	return PrometheusClient{client: promv1api, raw: promClient}, nil
+}
+
+// headerRoundTripper sets headers on every request before passing it to next.
+type headerRoundTripper struct {
+	next    http.RoundTripper
+	headers http.Header
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range rt.headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// get sends a GET request to path relative to the client URL and returns the response status and body.
+// It is used for endpoints the Prometheus API client does not support.
+func (p PrometheusClient) get(ctx context.Context, path string, args url.Values) (int, []byte, error) {
+	if p.raw == nil {
+		return 0, nil, fmt.Errorf("prometheus client is not initialized")
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	u := p.raw.URL(path, nil)
+	u.RawQuery = args.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, body, err := p.raw.Do(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// getData sends a GET request to path and decodes the data field of the API response into dst.
+// VictoriaMetrics reports success either as "success" or "ok".
+func (p PrometheusClient) getData(ctx context.Context, path string, args url.Values, dst any) error {
+	code, body, err := p.get(ctx, path, args)
+	if err != nil {
+		return err
+	}
+	var r struct {
+		Status string          `json:"status"`
+		Error  string          `json:"error"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("cannot parse %s response with status %d: %w", path, code, err)
+	}
+	if r.Status != "success" && r.Status != "ok" {
+		return fmt.Errorf("%s failed with status %d: %s", path, code, r.Error)
+	}
+	if err := json.Unmarshal(r.Data, dst); err != nil {
+		return fmt.Errorf("cannot parse %s response data: %w", path, err)
+	}
+	return nil
+}
+
+// RangeOptions configures a range query. Zero fields keep the defaults:
+// from p.Start to now with a 1m step and a 10s timeout.
+type RangeOptions struct {
+	Start   time.Time
+	End     time.Time
+	Step    time.Duration
+	Timeout time.Duration
 }
 
 // QueryRange executes a Prometheus range query from p.Start to now.
 func (p PrometheusClient) QueryRange(ctx context.Context, query string) (prommodel.Value, promv1.Warnings, error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	return p.QueryRangeWithOptions(ctx, query, RangeOptions{})
+}
+
+// QueryRangeWithOptions executes a Prometheus range query with the given range, step and timeout.
+func (p PrometheusClient) QueryRangeWithOptions(ctx context.Context, query string, opts RangeOptions) (prommodel.Value, promv1.Warnings, error) {
+	r := promv1.Range{Start: opts.Start, End: opts.End, Step: opts.Step}
+	if r.Start.IsZero() {
+		r.Start = p.Start
+	}
+	if r.End.IsZero() {
+		r.End = time.Now()
+	}
+	if r.Step <= 0 {
+		r.Step = queryStep
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = queryTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	return p.client.QueryRange(ctx, query, promv1.Range{
-		Start: p.Start,
-		End:   time.Now(),
-		Step:  queryStep,
-	})
+	return p.client.QueryRange(ctx, query, r)
 }
 
 // Query executes an instant Prometheus query at the current time.
@@ -57,17 +176,26 @@ func (p PrometheusClient) Query(ctx context.Context, query string) (prommodel.Va
 	return p.client.Query(ctx, query, time.Now())
 }
 
+// QueryAt executes an instant Prometheus query at the given time.
+func (p PrometheusClient) QueryAt(ctx context.Context, query string, ts time.Time) (prommodel.Value, promv1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	return p.client.Query(ctx, query, ts)
+}
+
 // VectorScan executes an instant query and returns the first sample's metric and value from the result vector.
 // It returns an error if the query fails, returns no data, or returns a non-vector result.
+// Use VectorScanAll if the query may return multiple series.
 func (p PrometheusClient) VectorScan(ctx context.Context, query string) (prommodel.Metric, prommodel.SampleValue, error) {
 	result, _, err := p.Query(ctx, query)
 	if err != nil {
 		return nil, 0, err
 	}
-	if result.Type() != prommodel.ValVector {
-		return nil, 0, fmt.Errorf("unexpected result type: %s", result.Type())
+	vec, err := AsVector(result)
+	if err != nil {
+		return nil, 0, err
 	}
-	vec := result.(prommodel.Vector)
 	if len(vec) == 0 {
 		return nil, 0, fmt.Errorf("no data returned")
 	}
@@ -2,6 +2,8 @@ package promquery
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -273,3 +275,32 @@ func TestClientStartTime(t *testing.T) {
 
 	assert.True(t, client.Start.Equal(testTime), "Expected Start time to match")
 }
+
+func TestNewPrometheusClientWithOptions(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "1", r.Header.Get("AccountID"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"1"]}]}}`)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client, err := NewPrometheusClientWithOptions(server.URL, ClientOptions{
+		Headers:   http.Header{"Authorization": {"Bearer secret"}, "AccountID": {"1"}},
+		TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+	})
+	require.NoError(t, err)
+
+	_, value, err := client.VectorScan(context.Background(), "up")
+	require.NoError(t, err)
+	assert.Equal(t, prommodel.SampleValue(1), value)
+
+	// Without the CA the server certificate is not trusted
+	client, err = NewPrometheusClient(server.URL)
+	require.NoError(t, err)
+	_, _, err = client.VectorScan(context.Background(), "up")
+	require.Error(t, err)
+}
@@ -0,0 +1,250 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// MetadataOptions restricts series and label lookups.
+type MetadataOptions struct {
+	// Matches are series selectors sent as match[]. Series lookups require at least one.
+	Matches []string
+	// Start and End limit the time range. A zero Start falls back to p.Start, a zero End to now.
+	Start time.Time
+	End   time.Time
+}
+
+func (p PrometheusClient) metadataRange(opts MetadataOptions) (time.Time, time.Time) {
+	start, end := opts.Start, opts.End
+	if start.IsZero() {
+		start = p.Start
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return start, end
+}
+
+// Series returns label sets of series matching opts.Matches, sorted by labels.
+func (p PrometheusClient) Series(ctx context.Context, opts MetadataOptions) ([]prommodel.LabelSet, error) {
+	if len(opts.Matches) == 0 {
+		return nil, fmt.Errorf("at least one series selector is required")
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	start, end := p.metadataRange(opts)
+	series, _, err := p.client.Series(ctx, opts.Matches, start, end)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Before(series[j]) })
+	return series, nil
+}
+
+// LabelNames returns sorted label names of series matching opts.Matches, or of all series if there are none.
+func (p PrometheusClient) LabelNames(ctx context.Context, opts MetadataOptions) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	start, end := p.metadataRange(opts)
+	names, _, err := p.client.LabelNames(ctx, opts.Matches, start, end)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LabelValues returns sorted values of the label for series matching opts.Matches,
+// or of all series if there are none.
+func (p PrometheusClient) LabelValues(ctx context.Context, label string, opts MetadataOptions) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	start, end := p.metadataRange(opts)
+	values, _, err := p.client.LabelValues(ctx, label, opts.Matches, start, end)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		res = append(res, string(v))
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// TSDBStatusOptions configures the cardinality explorer request.
+type TSDBStatusOptions struct {
+	// TopN limits the number of entries in every list. Defaults to the server default of 10.
+	TopN int
+	// Date selects the day to inspect. Defaults to today.
+	Date time.Time
+	// Matches restrict the inspected series.
+	Matches []string
+	// FocusLabel fills SeriesCountByFocusLabelValue with series counts per value of the label.
+	FocusLabel string
+}
+
+// TSDBStat is a name with a series or label value count.
+type TSDBStat struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+// TSDBStatus is the response of /api/v1/status/tsdb of VictoriaMetrics.
+type TSDBStatus struct {
+	TotalSeries                  uint64     `json:"totalSeries"`
+	TotalLabelValuePairs         uint64     `json:"totalLabelValuePairs"`
+	SeriesCountByMetricName      []TSDBStat `json:"seriesCountByMetricName"`
+	SeriesCountByLabelName       []TSDBStat `json:"seriesCountByLabelName"`
+	SeriesCountByFocusLabelValue []TSDBStat `json:"seriesCountByFocusLabelValue"`
+	SeriesCountByLabelValuePair  []TSDBStat `json:"seriesCountByLabelValuePair"`
+	LabelValueCountByLabelName   []TSDBStat `json:"labelValueCountByLabelName"`
+}
+
+// MetricSeriesCount returns the number of series of the metric from SeriesCountByMetricName.
+// The second result is false if the metric is not in the list, e.g. because of TopN.
+func (s TSDBStatus) MetricSeriesCount(metric string) (uint64, bool) {
+	for _, stat := range s.SeriesCountByMetricName {
+		if stat.Name == metric {
+			return stat.Value, true
+		}
+	}
+	return 0, false
+}
+
+// TSDBStatus returns cardinality statistics of the storage.
+func (p PrometheusClient) TSDBStatus(ctx context.Context, opts TSDBStatusOptions) (TSDBStatus, error) {
+	args := url.Values{}
+	if opts.TopN > 0 {
+		args.Set("topN", strconv.Itoa(opts.TopN))
+	}
+	if !opts.Date.IsZero() {
+		args.Set("date", opts.Date.UTC().Format(time.DateOnly))
+	}
+	for _, m := range opts.Matches {
+		args.Add("match[]", m)
+	}
+	if opts.FocusLabel != "" {
+		args.Set("focusLabel", opts.FocusLabel)
+	}
+
+	var status TSDBStatus
+	if err := p.getData(ctx, "/api/v1/status/tsdb", args, &status); err != nil {
+		return TSDBStatus{}, err
+	}
+	return status, nil
+}
+
+// ActiveQuery is a query being executed, as reported by /api/v1/status/active_queries.
+type ActiveQuery struct {
+	ID         string `json:"id"`
+	RemoteAddr string `json:"remote_addr"`
+	Query      string `json:"query"`
+	// Start, End and Step are in milliseconds.
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Step     int64  `json:"step"`
+	Duration string `json:"duration"`
+}
+
+// ActiveQueries returns queries currently being executed.
+func (p PrometheusClient) ActiveQueries(ctx context.Context) ([]ActiveQuery, error) {
+	var queries []ActiveQuery
+	if err := p.getData(ctx, "/api/v1/status/active_queries", nil, &queries); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// TopQuery is an entry of /api/v1/status/top_queries.
+type TopQuery struct {
+	AccountID          int     `json:"accountID"`
+	ProjectID          int     `json:"projectID"`
+	Query              string  `json:"query"`
+	TimeRangeSeconds   int64   `json:"timeRangeSeconds"`
+	Count              int     `json:"count"`
+	AvgDurationSeconds float64 `json:"avgDurationSeconds"`
+	SumDurationSeconds float64 `json:"sumDurationSeconds"`
+}
+
+// TopQueries is the response of /api/v1/status/top_queries.
+type TopQueries struct {
+	TopByCount       []TopQuery `json:"topByCount"`
+	TopByAvgDuration []TopQuery `json:"topByAvgDuration"`
+	TopBySumDuration []TopQuery `json:"topBySumDuration"`
+}
+
+// TopQueries returns the most frequent and the slowest queries executed during maxLifetime.
+// Zero topN and maxLifetime keep the server defaults.
+func (p PrometheusClient) TopQueries(ctx context.Context, topN int, maxLifetime time.Duration) (TopQueries, error) {
+	args := url.Values{}
+	if topN > 0 {
+		args.Set("topN", strconv.Itoa(topN))
+	}
+	if maxLifetime > 0 {
+		args.Set("maxLifetime", maxLifetime.String())
+	}
+
+	// Unlike other status endpoints, top_queries is not wrapped into status and data fields.
+	code, body, err := p.get(ctx, "/api/v1/status/top_queries", args)
+	if err != nil {
+		return TopQueries{}, err
+	}
+	if code/100 != 2 {
+		return TopQueries{}, fmt.Errorf("/api/v1/status/top_queries failed with status %d: %s", code, body)
+	}
+	var top TopQueries
+	if err := json.Unmarshal(body, &top); err != nil {
+		return TopQueries{}, fmt.Errorf("cannot parse /api/v1/status/top_queries response: %w", err)
+	}
+	return top, nil
+}
+
+// CheckSeriesCount verifies that match selects exactly expected series.
+func (p PrometheusClient) CheckSeriesCount(ctx context.Context, t testing.TestingT, match string, expected int) {
+	series, err := p.Series(ctx, MetadataOptions{Matches: []string{match}})
+	require.NoError(t, err, "Failed to get series for %s", match)
+	require.Len(t, series, expected, "Unexpected number of series for %s: %v", match, series)
+}
+
+// CheckSeriesLabels verifies that match selects series with exactly the expected label sets, in any order.
+func (p PrometheusClient) CheckSeriesLabels(ctx context.Context, t testing.TestingT, match string, expected ...prommodel.LabelSet) {
+	series, err := p.Series(ctx, MetadataOptions{Matches: []string{match}})
+	require.NoError(t, err, "Failed to get series for %s", match)
+	require.ElementsMatch(t, expected, series, "Unexpected series for %s", match)
+}
+
+// CheckLabelNames verifies that series selected by match have exactly the expected label names, in any order.
+func (p PrometheusClient) CheckLabelNames(ctx context.Context, t testing.TestingT, match string, expected ...string) {
+	names, err := p.LabelNames(ctx, MetadataOptions{Matches: []string{match}})
+	require.NoError(t, err, "Failed to get label names for %s", match)
+	require.ElementsMatch(t, expected, names, "Unexpected label names for %s", match)
+}
+
+// CheckLabelValues verifies that the label of series selected by match has exactly the expected values, in any order.
+func (p PrometheusClient) CheckLabelValues(ctx context.Context, t testing.TestingT, label, match string, expected ...string) {
+	values, err := p.LabelValues(ctx, label, MetadataOptions{Matches: []string{match}})
+	require.NoError(t, err, "Failed to get values of label %s for %s", label, match)
+	require.ElementsMatch(t, expected, values, "Unexpected values of label %s for %s", label, match)
+}
+
+// CheckTSDBMetricSeriesCount verifies that the cardinality explorer reports expected series for the metric today.
+func (p PrometheusClient) CheckTSDBMetricSeriesCount(ctx context.Context, t testing.TestingT, metric string, expected uint64) {
+	status, err := p.TSDBStatus(ctx, TSDBStatusOptions{Matches: []string{fmt.Sprintf("{__name__=%q}", metric)}})
+	require.NoError(t, err, "Failed to get TSDB status for %s", metric)
+	count, ok := status.MetricSeriesCount(metric)
+	require.True(t, ok, "Metric %s is not reported by TSDB status: %v", metric, status.SeriesCountByMetricName)
+	require.Equal(t, expected, count, "Unexpected number of series for %s in TSDB status", metric)
+}
@@ -0,0 +1,152 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataHandler serves metadata APIs of tenant 1 and a failing TSDB status API of tenant 2.
+func metadataHandler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/select/1/prometheus/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, []string{`{__name__="foo"}`}, r.Form["match[]"])
+		assert.NotEmpty(t, r.Form.Get("start"))
+		assert.NotEmpty(t, r.Form.Get("end"))
+		fmt.Fprint(w, `{"status":"success","data":[
+			{"__name__":"foo","job":"b"},
+			{"__name__":"foo","job":"a"}
+		]}`)
+	})
+	mux.HandleFunc("/select/1/prometheus/api/v1/labels", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":["job","__name__"]}`)
+	})
+	mux.HandleFunc("/select/1/prometheus/api/v1/label/job/values", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":["b","a"]}`)
+	})
+	mux.HandleFunc("/select/1/prometheus/api/v1/status/tsdb", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "5", r.URL.Query().Get("topN"))
+		assert.Equal(t, "2024-01-02", r.URL.Query().Get("date"))
+		assert.Equal(t, "job", r.URL.Query().Get("focusLabel"))
+		fmt.Fprint(w, `{"status":"success","data":{
+			"totalSeries":3,
+			"totalLabelValuePairs":6,
+			"seriesCountByMetricName":[{"name":"foo","value":2},{"name":"bar","value":1}],
+			"seriesCountByFocusLabelValue":[{"name":"a","value":1}],
+			"labelValueCountByLabelName":[{"name":"job","value":2}]
+		}}`)
+	})
+	mux.HandleFunc("/select/1/prometheus/api/v1/status/active_queries", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok","data":[{"id":"1","remote_addr":"10.0.0.1:1234","query":"up","start":1000,"end":2000,"step":15000,"duration":"0.100s"}]}`)
+	})
+	mux.HandleFunc("/select/1/prometheus/api/v1/status/top_queries", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "3", r.URL.Query().Get("topN"))
+		assert.Equal(t, "5m0s", r.URL.Query().Get("maxLifetime"))
+		fmt.Fprint(w, `{"topN":"3","maxLifetime":"5m0s",
+			"topByCount":[{"accountID":1,"projectID":0,"query":"up","timeRangeSeconds":3600,"count":7}],
+			"topByAvgDuration":[{"accountID":1,"projectID":0,"query":"rate(foo[5m])","timeRangeSeconds":0,"avgDurationSeconds":0.5,"count":1}],
+			"topBySumDuration":[]}`)
+	})
+	mux.HandleFunc("/select/2/prometheus/api/v1/status/tsdb", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"invalid date"}`)
+	})
+	return mux
+}
+
+func TestSeries(t *testing.T) {
+	t.Parallel()
+	client := newTestClientAt(t, metadataHandler(t), "/select/1/prometheus")
+	client.Start = time.Now().Add(-time.Hour)
+	ctx := context.Background()
+
+	series, err := client.Series(ctx, MetadataOptions{Matches: []string{`{__name__="foo"}`}})
+	require.NoError(t, err)
+	assert.Equal(t, []prommodel.LabelSet{
+		{"__name__": "foo", "job": "a"},
+		{"__name__": "foo", "job": "b"},
+	}, series)
+
+	_, err = client.Series(ctx, MetadataOptions{})
+	require.EqualError(t, err, "at least one series selector is required")
+
+	client.CheckSeriesCount(ctx, t, `{__name__="foo"}`, 2)
+	client.CheckSeriesLabels(ctx, t, `{__name__="foo"}`,
+		prommodel.LabelSet{"__name__": "foo", "job": "b"},
+		prommodel.LabelSet{"__name__": "foo", "job": "a"},
+	)
+}
+
+func TestLabels(t *testing.T) {
+	t.Parallel()
+	client := newTestClientAt(t, metadataHandler(t), "/select/1/prometheus")
+	ctx := context.Background()
+
+	names, err := client.LabelNames(ctx, MetadataOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__name__", "job"}, names)
+
+	values, err := client.LabelValues(ctx, "job", MetadataOptions{Matches: []string{"foo"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, values)
+
+	client.CheckLabelNames(ctx, t, "foo", "job", "__name__")
+	client.CheckLabelValues(ctx, t, "job", "foo", "b", "a")
+}
+
+func TestTSDBStatus(t *testing.T) {
+	t.Parallel()
+	client := newTestClientAt(t, metadataHandler(t), "/select/1/prometheus")
+
+	status, err := client.TSDBStatus(context.Background(), TSDBStatusOptions{
+		TopN:       5,
+		Date:       time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC),
+		FocusLabel: "job",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), status.TotalSeries)
+	assert.Equal(t, []TSDBStat{{Name: "a", Value: 1}}, status.SeriesCountByFocusLabelValue)
+	count, ok := status.MetricSeriesCount("foo")
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), count)
+	_, ok = status.MetricSeriesCount("baz")
+	assert.False(t, ok)
+
+	// Another tenant of the same server rejects the request
+	other, err := NewPrometheusClient(strings.Replace(client.raw.URL("", nil).String(), "/select/1/", "/select/2/", 1))
+	require.NoError(t, err)
+	_, err = other.TSDBStatus(context.Background(), TSDBStatusOptions{})
+	require.EqualError(t, err, "/api/v1/status/tsdb failed with status 400: invalid date")
+}
+
+func TestQueryStats(t *testing.T) {
+	t.Parallel()
+	client := newTestClientAt(t, metadataHandler(t), "/select/1/prometheus")
+	ctx := context.Background()
+
+	active, err := client.ActiveQueries(ctx)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, ActiveQuery{ID: "1", RemoteAddr: "10.0.0.1:1234", Query: "up", Start: 1000, End: 2000, Step: 15000, Duration: "0.100s"}, active[0])
+
+	top, err := client.TopQueries(ctx, 3, 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, top.TopByCount, 1)
+	assert.Equal(t, TopQuery{AccountID: 1, Query: "up", TimeRangeSeconds: 3600, Count: 7}, top.TopByCount[0])
+	assert.Equal(t, 0.5, top.TopByAvgDuration[0].AvgDurationSeconds)
+	assert.Empty(t, top.TopBySumDuration)
+}
+
+func TestUninitializedClient(t *testing.T) {
+	t.Parallel()
+	_, err := PrometheusClient{}.ActiveQueries(context.Background())
+	require.EqualError(t, err, "prometheus client is not initialized")
+}
@@ -0,0 +1,137 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+)
+
+// AsVector returns v as a vector or an error naming the actual result type.
+func AsVector(v prommodel.Value) (prommodel.Vector, error) {
+	vec, ok := v.(prommodel.Vector)
+	if !ok {
+		return nil, unexpectedType(v, prommodel.ValVector)
+	}
+	return vec, nil
+}
+
+// AsMatrix returns v as a matrix or an error naming the actual result type.
+func AsMatrix(v prommodel.Value) (prommodel.Matrix, error) {
+	m, ok := v.(prommodel.Matrix)
+	if !ok {
+		return nil, unexpectedType(v, prommodel.ValMatrix)
+	}
+	return m, nil
+}
+
+// AsScalar returns v as a scalar or an error naming the actual result type.
+func AsScalar(v prommodel.Value) (*prommodel.Scalar, error) {
+	s, ok := v.(*prommodel.Scalar)
+	if !ok {
+		return nil, unexpectedType(v, prommodel.ValScalar)
+	}
+	return s, nil
+}
+
+// AsString returns v as a string or an error naming the actual result type.
+func AsString(v prommodel.Value) (*prommodel.String, error) {
+	s, ok := v.(*prommodel.String)
+	if !ok {
+		return nil, unexpectedType(v, prommodel.ValString)
+	}
+	return s, nil
+}
+
+func unexpectedType(v prommodel.Value, want prommodel.ValueType) error {
+	if v == nil {
+		return fmt.Errorf("unexpected result type: none, expected %s", want)
+	}
+	return fmt.Errorf("unexpected result type: %s, expected %s", v.Type(), want)
+}
+
+// QueryVector executes an instant query at the current time and returns the result vector.
+func (p PrometheusClient) QueryVector(ctx context.Context, query string) (prommodel.Vector, error) {
+	return p.QueryVectorAt(ctx, query, time.Now())
+}
+
+// QueryVectorAt executes an instant query at the given time and returns the result vector.
+func (p PrometheusClient) QueryVectorAt(ctx context.Context, query string, ts time.Time) (prommodel.Vector, error) {
+	result, _, err := p.QueryAt(ctx, query, ts)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := AsVector(result)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", query, err)
+	}
+	return vec, nil
+}
+
+// QueryMatrix executes a range query and returns the result matrix.
+func (p PrometheusClient) QueryMatrix(ctx context.Context, query string, opts RangeOptions) (prommodel.Matrix, error) {
+	result, _, err := p.QueryRangeWithOptions(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	m, err := AsMatrix(result)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", query, err)
+	}
+	return m, nil
+}
+
+// QueryScalar executes an instant query returning a scalar, e.g. scalar(...) or time().
+func (p PrometheusClient) QueryScalar(ctx context.Context, query string) (*prommodel.Scalar, error) {
+	result, _, err := p.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s, err := AsScalar(result)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", query, err)
+	}
+	return s, nil
+}
+
+// QueryString executes an instant query returning a string literal.
+// The Prometheus API client cannot decode string results, so the response is decoded here.
+func (p PrometheusClient) QueryString(ctx context.Context, query string) (*prommodel.String, error) {
+	var data struct {
+		ResultType prommodel.ValueType `json:"resultType"`
+		Result     json.RawMessage     `json:"result"`
+	}
+	if err := p.getData(ctx, "/api/v1/query", url.Values{"query": {query}}, &data); err != nil {
+		return nil, fmt.Errorf("query %s: %w", query, err)
+	}
+	if data.ResultType != prommodel.ValString {
+		return nil, fmt.Errorf("query %s: unexpected result type: %s, expected %s", query, data.ResultType, prommodel.ValString)
+	}
+	var s prommodel.String
+	if err := json.Unmarshal(data.Result, &s); err != nil {
+		return nil, fmt.Errorf("cannot parse string result: %w", err)
+	}
+	return &s, nil
+}
+
+// VectorScanAll executes an instant query and returns all samples of the result vector sorted by labels.
+// It returns an error if the query fails, returns no data, or returns a non-vector result.
+func (p PrometheusClient) VectorScanAll(ctx context.Context, query string) (prommodel.Vector, error) {
+	result, _, err := p.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := AsVector(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(vec) == 0 {
+		return nil, fmt.Errorf("no data returned")
+	}
+	sort.Slice(vec, func(i, j int) bool { return vec[i].Metric.Before(vec[j].Metric) })
+	return vec, nil
+}
@@ -0,0 +1,99 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRangeWithOptions(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "1704103200", r.Form.Get("start"))
+		assert.Equal(t, "1704103800", r.Form.Get("end"))
+		assert.Equal(t, "15", r.Form.Get("step"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"__name__":"a"},"values":[[1704103200,"1"],[1704103215,"2"]]},
+			{"metric":{"__name__":"b"},"values":[[1704103200,"3"]]}
+		]}}`)
+	}))
+
+	m, err := client.QueryMatrix(context.Background(), "test", RangeOptions{Start: start, End: end, Step: 15 * time.Second})
+	require.NoError(t, err)
+	require.Len(t, m, 2)
+	assert.Len(t, m[0].Values, 2)
+	assert.Equal(t, prommodel.SampleValue(3), m[1].Values[0].Value)
+}
+
+func TestQueryRangeWithOptions_Timeout(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+
+	_, _, err := client.QueryRangeWithOptions(context.Background(), "slow", RangeOptions{Timeout: 10 * time.Millisecond})
+	require.ErrorContains(t, err, "context deadline exceeded")
+}
+
+func TestTypedResults(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	client, _ := newCannedClient(t, queryResponse("scalar", `[1704103200,"42"]`))
+	s, err := client.QueryScalar(ctx, "scalar(up)")
+	require.NoError(t, err)
+	assert.Equal(t, prommodel.SampleValue(42), s.Value)
+	_, err = client.QueryVector(ctx, "scalar(up)")
+	require.EqualError(t, err, "query scalar(up): unexpected result type: scalar, expected vector")
+
+	client, _ = newCannedClient(t, queryResponse("string", `[1704103200,"hello"]`))
+	str, err := client.QueryString(ctx, `"hello"`)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", str.Value)
+
+	client, _ = newCannedClient(t, queryResponse("vector", `[]`))
+	vec, err := client.QueryVector(ctx, "absent_metric")
+	require.NoError(t, err)
+	assert.Empty(t, vec)
+	_, err = client.QueryMatrix(ctx, "absent_metric", RangeOptions{})
+	require.ErrorContains(t, err, "unexpected result type: vector, expected matrix")
+	_, err = client.QueryScalar(ctx, "absent_metric")
+	require.ErrorContains(t, err, "expected scalar")
+	_, err = client.QueryString(ctx, "absent_metric")
+	require.EqualError(t, err, "query absent_metric: unexpected result type: vector, expected string")
+	_, err = AsString(vec)
+	require.ErrorContains(t, err, "expected string")
+
+	_, err = AsVector(nil)
+	require.EqualError(t, err, "unexpected result type: none, expected vector")
+}
+
+func TestVectorScanAll(t *testing.T) {
+	t.Parallel()
+	client, _ := newCannedClient(t, queryResponse("vector", `[
+		{"metric":{"__name__":"m","i":"2"},"value":[1704103200,"2"]},
+		{"metric":{"__name__":"m","i":"0"},"value":[1704103200,"0"]},
+		{"metric":{"__name__":"m","i":"1"},"value":[1704103200,"1"]}
+	]`))
+	vec, err := client.VectorScanAll(context.Background(), "m")
+	require.NoError(t, err)
+	require.Len(t, vec, 3)
+	for i, sample := range vec {
+		assert.Equal(t, prommodel.LabelValue(fmt.Sprint(i)), sample.Metric["i"])
+		assert.Equal(t, prommodel.SampleValue(i), sample.Value)
+	}
+
+	client, _ = newCannedClient(t, queryResponse("vector", `[]`))
+	_, err = client.VectorScanAll(context.Background(), "m")
+	require.EqualError(t, err, "no data returned")
+}
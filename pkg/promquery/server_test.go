@@ -0,0 +1,55 @@
+package promquery
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient starts a server with the handler and returns a client querying it.
+// The server is closed when the test ends.
+func newTestClient(t *testing.T, handler http.Handler) PrometheusClient {
+	t.Helper()
+	return newTestClientAt(t, handler, "")
+}
+
+// newTestClientAt is like newTestClient, but the client queries the API under path, e.g. /select/1/prometheus.
+func newTestClientAt(t *testing.T, handler http.Handler, path string) PrometheusClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewPrometheusClient(server.URL + path)
+	require.NoError(t, err)
+	return client
+}
+
+// newCannedClient returns a client of a server answering the n-th request with responses[n],
+// repeating the last response afterwards, and the number of served requests.
+// An empty response is answered with 503 Service Unavailable.
+func newCannedClient(t *testing.T, responses ...string) (PrometheusClient, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(responses) {
+			n = len(responses) - 1
+		}
+		if responses[n] == "" {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, responses[n])
+	}))
+	return client, &calls
+}
+
+// queryResponse returns a successful query response with the result of the given type.
+func queryResponse(resultType, result string) string {
+	return fmt.Sprintf(`{"status":"success","data":{"resultType":%q,"result":%s}}`, resultType, result)
+}
@@ -0,0 +1,292 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// SilenceCreatedBy marks silences created by the tests, so cleanup never expires silences of others.
+	SilenceCreatedBy = "end-to-end-tests"
+	// silenceSpecPrefix starts the first comment line holding the spec owning the silence.
+	silenceSpecPrefix      = "spec: "
+	defaultSilenceDuration = time.Hour
+)
+
+// SilenceOptions configures a silence created by CreateSilence.
+type SilenceOptions struct {
+	// Spec is the spec owning the silence, e.g. the full text of the current Ginkgo spec.
+	// ExpireSilences uses it to clean up silences of a spec only.
+	Spec string
+	// Duration is how long the silence is active unless expired. Defaults to 1h,
+	// so a silence outlives its spec only for a while if cleanup fails.
+	Duration time.Duration
+	// Comment describes why the alert is expected.
+	Comment string
+}
+
+// Silence is an Alertmanager silence created by the tests.
+type Silence struct {
+	ID string
+	// State is active, pending or expired.
+	State    string
+	Matchers []string
+	Spec     string
+	Comment  string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+func silenceComment(spec, comment string) string {
+	return silenceSpecPrefix + spec + "\n" + comment
+}
+
+func parseSilenceComment(comment string) (string, string) {
+	first, rest, _ := strings.Cut(comment, "\n")
+	spec, ok := strings.CutPrefix(first, silenceSpecPrefix)
+	if !ok {
+		return "", comment
+	}
+	return spec, rest
+}
+
+func (p PrometheusClient) alertmanagerURL() string {
+	amURL, _ := p.alertmanagerRequest("", "")
+	return amURL
+}
+
+// CreateSilence silences alerts matching selector in namespace and returns the silence ID.
+// The selector is an alert name or comma-separated label filters, like in CheckAlertIsFiring.
+// An empty namespace silences matching alerts of every namespace.
+func (p PrometheusClient) CreateSilence(ctx context.Context, namespace, selector string, opts SilenceOptions) (string, error) {
+	if selector == "" {
+		return "", fmt.Errorf("silence selector is required")
+	}
+	matchers, err := parseAlertSelector(namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	duration := opts.Duration
+	if duration <= 0 {
+		duration = defaultSilenceDuration
+	}
+	comment := opts.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("%s is expected during the test", selector)
+	}
+
+	s := models.Silence{
+		Comment:   stringPtr(silenceComment(opts.Spec, comment)),
+		CreatedBy: stringPtr(SilenceCreatedBy),
+		StartsAt:  dateTimePtr(time.Now()),
+		EndsAt:    dateTimePtr(time.Now().Add(duration)),
+	}
+	for _, m := range matchers {
+		s.Matchers = append(s.Matchers, &models.Matcher{
+			Name:    stringPtr(m.Name),
+			Value:   stringPtr(m.Value),
+			IsRegex: boolPtr(strings.Contains(m.Op, "~")),
+			IsEqual: boolPtr(!strings.HasPrefix(m.Op, "!")),
+		})
+	}
+
+	c, err := alertmanagerAPI(p.alertmanagerURL())
+	if err != nil {
+		return "", err
+	}
+	params := silence.NewPostSilencesParams().WithContext(ctx).WithSilence(&models.PostableSilence{Silence: s})
+	resp, err := c.Silence.PostSilences(params)
+	if err != nil {
+		return "", fmt.Errorf("cannot create silence for %s: %w", selector, err)
+	}
+	return resp.Payload.SilenceID, nil
+}
+
+// ListSilences returns active and pending silences created by the tests, sorted by start time.
+// A non-empty namespace keeps silences scoped to it, a non-empty spec keeps silences owned by it.
+func (p PrometheusClient) ListSilences(ctx context.Context, namespace, spec string) ([]Silence, error) {
+	c, err := alertmanagerAPI(p.alertmanagerURL())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list silences: %w", err)
+	}
+
+	var res []Silence
+	for _, gs := range resp.Payload {
+		s := toSilence(gs)
+		if s.State == models.SilenceStatusStateExpired || gs.CreatedBy == nil || *gs.CreatedBy != SilenceCreatedBy {
+			continue
+		}
+		if spec != "" && s.Spec != spec {
+			continue
+		}
+		if namespace != "" && !slices.Contains(s.Matchers, alertMatcher{Name: "namespace", Op: "=", Value: namespace}.String()) {
+			continue
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].StartsAt.Before(res[j].StartsAt) })
+	return res, nil
+}
+
+func toSilence(gs *models.GettableSilence) Silence {
+	s := Silence{}
+	if gs.ID != nil {
+		s.ID = *gs.ID
+	}
+	if gs.Status != nil && gs.Status.State != nil {
+		s.State = *gs.Status.State
+	}
+	if gs.Comment != nil {
+		s.Spec, s.Comment = parseSilenceComment(*gs.Comment)
+	}
+	if gs.StartsAt != nil {
+		s.StartsAt = time.Time(*gs.StartsAt)
+	}
+	if gs.EndsAt != nil {
+		s.EndsAt = time.Time(*gs.EndsAt)
+	}
+	for _, m := range gs.Matchers {
+		if m == nil || m.Name == nil || m.Value == nil {
+			continue
+		}
+		op := "="
+		isEqual := m.IsEqual == nil || *m.IsEqual
+		isRegex := m.IsRegex != nil && *m.IsRegex
+		switch {
+		case isRegex && isEqual:
+			op = "=~"
+		case isRegex:
+			op = "!~"
+		case !isEqual:
+			op = "!="
+		}
+		s.Matchers = append(s.Matchers, alertMatcher{Name: *m.Name, Op: op, Value: *m.Value}.String())
+	}
+	return s
+}
+
+// ExpireSilence expires the silence with the given ID.
+func (p PrometheusClient) ExpireSilence(ctx context.Context, id string) error {
+	c, err := alertmanagerAPI(p.alertmanagerURL())
+	if err != nil {
+		return err
+	}
+	params := silence.NewDeleteSilenceParams().WithContext(ctx).WithSilenceID(strfmt.UUID(id))
+	if _, err := c.Silence.DeleteSilence(params); err != nil {
+		return fmt.Errorf("cannot expire silence %s: %w", id, err)
+	}
+	return nil
+}
+
+// ExpireSilences expires silences listed by ListSilences for the namespace and spec
+// and returns the number of expired silences.
+func (p PrometheusClient) ExpireSilences(ctx context.Context, namespace, spec string) (int, error) {
+	silences, err := p.ListSilences(ctx, namespace, spec)
+	if err != nil {
+		return 0, err
+	}
+	for i, s := range silences {
+		if err := p.ExpireSilence(ctx, s.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(silences), nil
+}
+
+// AlertmanagerAlert is an alert with its Alertmanager status.
+type AlertmanagerAlert struct {
+	Labels map[string]string
+	// State is unprocessed, active or suppressed.
+	State       string
+	SilencedBy  []string
+	InhibitedBy []string
+}
+
+// AlertmanagerAlerts returns alerts matching selector in namespace including silenced and inhibited ones.
+func (p PrometheusClient) AlertmanagerAlerts(ctx context.Context, namespace, selector string) ([]AlertmanagerAlert, error) {
+	amURL, filters := p.alertmanagerRequest(namespace, selector)
+	alerts, err := p.queryAlertmanager(ctx, amURL, filters)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]AlertmanagerAlert, 0, len(alerts))
+	for _, a := range alerts {
+		alert := AlertmanagerAlert{Labels: a.Labels}
+		if a.Status != nil {
+			if a.Status.State != nil {
+				alert.State = *a.Status.State
+			}
+			alert.SilencedBy = a.Status.SilencedBy
+			alert.InhibitedBy = a.Status.InhibitedBy
+		}
+		res = append(res, alert)
+	}
+	return res, nil
+}
+
+// PostAlerts sends alerts with the given labels to Alertmanager the way vmalert does, e.g. to check
+// inhibition rules without waiting for real alerts to fire. The alerts fire until endsAt,
+// sending them again with endsAt in the past resolves them.
+func (p PrometheusClient) PostAlerts(ctx context.Context, endsAt time.Time, alerts ...map[string]string) error {
+	c, err := alertmanagerAPI(p.alertmanagerURL())
+	if err != nil {
+		return err
+	}
+	postable := make(models.PostableAlerts, 0, len(alerts))
+	for _, labels := range alerts {
+		postable = append(postable, &models.PostableAlert{
+			Alert:  models.Alert{Labels: models.LabelSet(labels)},
+			EndsAt: strfmt.DateTime(endsAt),
+		})
+	}
+	if _, err := c.Alert.PostAlerts(alert.NewPostAlertsParams().WithContext(ctx).WithAlerts(postable)); err != nil {
+		return fmt.Errorf("cannot post alerts: %w", err)
+	}
+	return nil
+}
+
+// CheckAlertSilenced verifies that alerts matching selector are firing and suppressed by the silence.
+func (p PrometheusClient) CheckAlertSilenced(ctx context.Context, t testing.TestingT, namespace, selector, silenceID string) {
+	alerts, err := p.AlertmanagerAlerts(ctx, namespace, selector)
+	require.NoError(t, err, "Failed to get alerts from Alertmanager")
+	require.NotEmpty(t, alerts, "Alert %s should be firing in namespace %s", selector, namespace)
+	for _, a := range alerts {
+		require.Equal(t, models.AlertStatusStateSuppressed, a.State, "Alert %v should be suppressed", a.Labels)
+		require.Contains(t, a.SilencedBy, silenceID, "Alert %v should be silenced by %s", a.Labels, silenceID)
+	}
+}
+
+// CheckAlertInhibited verifies that alerts matching selector are firing and suppressed by inhibition rules.
+func (p PrometheusClient) CheckAlertInhibited(ctx context.Context, t testing.TestingT, namespace, selector string) {
+	alerts, err := p.AlertmanagerAlerts(ctx, namespace, selector)
+	require.NoError(t, err, "Failed to get alerts from Alertmanager")
+	require.NotEmpty(t, alerts, "Alert %s should be firing in namespace %s", selector, namespace)
+	for _, a := range alerts {
+		require.Equal(t, models.AlertStatusStateSuppressed, a.State, "Alert %v should be suppressed", a.Labels)
+		require.NotEmpty(t, a.InhibitedBy, "Alert %v should be inhibited", a.Labels)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
+
+func dateTimePtr(t time.Time) *strfmt.DateTime {
+	dt := strfmt.DateTime(t)
+	return &dt
+}
@@ -0,0 +1,155 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAlertmanager stores silences and posted alerts and suppresses a firing ServiceDown alert while it is silenced.
+type fakeAlertmanager struct {
+	mu       sync.Mutex
+	silences []map[string]any
+	posted   []map[string]any
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		var s map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s["id"] = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(f.silences)+1)
+		s["status"] = map[string]any{"state": "active"}
+		s["updatedAt"] = s["startsAt"]
+		f.silences = append(f.silences, s)
+		_ = json.NewEncoder(w).Encode(map[string]string{"silenceID": s["id"].(string)})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		_ = json.NewEncoder(w).Encode(f.silences)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+		for _, s := range f.silences {
+			if s["id"] == id {
+				s["status"] = map[string]any{"state": "expired"}
+				return
+			}
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/alerts":
+		var alerts []map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.posted = append(f.posted, alerts...)
+	case r.URL.Path == "/api/v2/alerts":
+		status := map[string]any{"state": "active", "silencedBy": []string{}, "inhibitedBy": []string{}, "mutedBy": []string{}}
+		for _, s := range f.silences {
+			if s["status"].(map[string]any)["state"] == "active" && strings.Contains(fmt.Sprint(s["matchers"]), "ServiceDown") {
+				status = map[string]any{"state": "suppressed", "silencedBy": []string{s["id"].(string)}, "inhibitedBy": []string{}, "mutedBy": []string{}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"labels": map[string]string{"alertname": "ServiceDown", "namespace": "ns"}, "status": status}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSilences(t *testing.T) {
+	t.Parallel()
+	am := &fakeAlertmanager{}
+	server := httptest.NewServer(am)
+	defer server.Close()
+
+	client, err := NewPrometheusClient(server.URL)
+	require.NoError(t, err)
+	client.AlertManagerURL = server.URL
+	ctx := context.Background()
+
+	mockTest := &mockTestingT{}
+	client.CheckNoAlertsFiring(ctx, mockTest, "ns", nil)
+	assert.True(t, mockTest.failed, "ServiceDown should be firing before it is silenced")
+
+	id, err := client.CreateSilence(ctx, "ns", "ServiceDown", SilenceOptions{Spec: "spec a", Duration: time.Minute})
+	require.NoError(t, err)
+	_, err = client.CreateSilence(ctx, "other", `{alertname=~"Disk.*", job!="vmagent"}`, SilenceOptions{Spec: "spec b", Comment: "expected"})
+	require.NoError(t, err)
+	_, err = client.CreateSilence(ctx, "ns", "", SilenceOptions{})
+	require.EqualError(t, err, "silence selector is required")
+
+	silences, err := client.ListSilences(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, silences, 2)
+	assert.Equal(t, id, silences[0].ID)
+	assert.Equal(t, "active", silences[0].State)
+	assert.Equal(t, []string{`namespace="ns"`, `alertname="ServiceDown"`}, silences[0].Matchers)
+	assert.Equal(t, "spec a", silences[0].Spec)
+	assert.Equal(t, "ServiceDown is expected during the test", silences[0].Comment)
+	assert.WithinDuration(t, silences[0].StartsAt.Add(time.Minute), silences[0].EndsAt, time.Second)
+	assert.Equal(t, []string{`namespace="other"`, `alertname=~"Disk.*"`, `job!="vmagent"`}, silences[1].Matchers)
+	assert.Equal(t, "expected", silences[1].Comment)
+
+	silences, err = client.ListSilences(ctx, "other", "")
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, "spec b", silences[0].Spec)
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertSilenced(ctx, mockTest, "ns", "ServiceDown", id)
+	assert.False(t, mockTest.failed, "ServiceDown should be silenced")
+	mockTest = &mockTestingT{}
+	client.CheckNoAlertsFiring(ctx, mockTest, "ns", nil)
+	assert.False(t, mockTest.failed, "Silenced alerts should not be reported as firing")
+	mockTest = &mockTestingT{}
+	client.CheckAlertInhibited(ctx, mockTest, "ns", "ServiceDown")
+	assert.True(t, mockTest.failed, "Silenced alerts are not inhibited")
+
+	expired, err := client.ExpireSilences(ctx, "", "spec a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	silences, err = client.ListSilences(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, "spec b", silences[0].Spec)
+
+	mockTest = &mockTestingT{}
+	client.CheckAlertSilenced(ctx, mockTest, "ns", "ServiceDown", id)
+	assert.True(t, mockTest.failed, "ServiceDown should not be silenced after the silence expired")
+
+	require.ErrorContains(t, client.ExpireSilence(ctx, "00000000-0000-0000-0000-000000000042"), "cannot expire silence")
+
+	endsAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, client.PostAlerts(ctx, endsAt,
+		map[string]string{"alertname": "Probe", "severity": "critical"},
+		map[string]string{"alertname": "Probe", "severity": "warning"}))
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	require.Len(t, am.posted, 2)
+	assert.Equal(t, map[string]any{"alertname": "Probe", "severity": "warning"}, am.posted[1]["labels"])
+	assert.Equal(t, "2026-01-02T03:04:05.000Z", am.posted[1]["endsAt"])
+}
+
+func TestParseSilenceComment(t *testing.T) {
+	t.Parallel()
+	spec, comment := parseSilenceComment(silenceComment("Chaos tests [chaos-test]", "noise\nmore"))
+	assert.Equal(t, "Chaos tests [chaos-test]", spec)
+	assert.Equal(t, "noise\nmore", comment)
+
+	spec, comment = parseSilenceComment("created manually")
+	assert.Empty(t, spec)
+	assert.Equal(t, "created manually", comment)
+}
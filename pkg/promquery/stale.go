@@ -0,0 +1,48 @@
+package promquery
+
+import (
+	"context"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// staleCheckDelay is how long after the staleness marker the series is checked to be still absent.
+// It is well below the default lookback window, so absence is caused by the marker and not by missing samples.
+const staleCheckDelay = time.Minute
+
+// SeriesCountAt returns the number of series returned by an instant query at the given time.
+func (p PrometheusClient) SeriesCountAt(ctx context.Context, query string, ts time.Time) (int, error) {
+	result, _, err := p.QueryAt(ctx, query, ts)
+	if err != nil {
+		return 0, err
+	}
+	vec, err := AsVector(result)
+	if err != nil {
+		return 0, err
+	}
+	return len(vec), nil
+}
+
+// CheckSeriesPresentAt verifies that an instant query at the given time returns at least one series.
+func (p PrometheusClient) CheckSeriesPresentAt(ctx context.Context, t testing.TestingT, query string, ts time.Time) {
+	count, err := p.SeriesCountAt(ctx, query, ts)
+	require.NoError(t, err, "Failed to query %s at %s", query, ts)
+	require.NotZero(t, count, "Expected %s to return series at %s", query, ts)
+}
+
+// CheckSeriesAbsentAt verifies that an instant query at the given time returns no series.
+func (p PrometheusClient) CheckSeriesAbsentAt(ctx context.Context, t testing.TestingT, query string, ts time.Time) {
+	count, err := p.SeriesCountAt(ctx, query, ts)
+	require.NoError(t, err, "Failed to query %s at %s", query, ts)
+	require.Zero(t, count, "Expected %s to return no series at %s", query, ts)
+}
+
+// CheckSeriesStale verifies that series matching query are returned at lastSample
+// and disappear from instant queries starting at staleAt, where the staleness marker was written.
+func (p PrometheusClient) CheckSeriesStale(ctx context.Context, t testing.TestingT, query string, lastSample, staleAt time.Time) {
+	p.CheckSeriesPresentAt(ctx, t, query, lastSample)
+	p.CheckSeriesAbsentAt(ctx, t, query, staleAt)
+	p.CheckSeriesAbsentAt(ctx, t, query, staleAt.Add(staleCheckDelay))
+}
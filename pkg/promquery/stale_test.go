@@ -0,0 +1,48 @@
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staleHandler answers instant queries with one series before staleAt and none after.
+func staleHandler(t *testing.T, staleAt time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		ts, err := strconv.ParseFloat(r.Form.Get("time"), 64)
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		if ts < float64(staleAt.Unix()) {
+			_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"m"},"value":[%f,"1"]}]}}`, ts)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	})
+}
+
+func TestCheckSeriesStale(t *testing.T) {
+	t.Parallel()
+	staleAt := time.Unix(1000, 0)
+	client := newTestClient(t, staleHandler(t, staleAt))
+	ctx := context.Background()
+
+	count, err := client.SeriesCountAt(ctx, "m", staleAt.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	mockTest := &mockTestingT{}
+	client.CheckSeriesStale(ctx, mockTest, "m", staleAt.Add(-time.Second), staleAt)
+	assert.False(t, mockTest.failed, "Should not fail when series disappears at the stale point")
+
+	mockTest = &mockTestingT{}
+	client.CheckSeriesStale(ctx, mockTest, "m", staleAt.Add(-time.Second), staleAt.Add(-2*time.Second))
+	assert.True(t, mockTest.failed, "Should fail when series is still returned after the stale point")
+}
@@ -0,0 +1,279 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+)
+
+// Sources of alert transitions.
+const (
+	SourceAlertmanager = "alertmanager"
+	SourceALERTS       = "ALERTS"
+)
+
+// AlertStateResolved is the state of an alert that is no longer reported by its source.
+const AlertStateResolved = "resolved"
+
+// AlertTransition is a change of the state of an alert seen by a source.
+type AlertTransition struct {
+	Time   time.Time         `json:"time"`
+	Source string            `json:"source"`
+	Alert  string            `json:"alert"`
+	Labels map[string]string `json:"labels"`
+	// From is empty for alerts seen for the first time. States are Alertmanager states
+	// (active, suppressed, unprocessed), ALERTS alertstate values (pending, firing) or resolved.
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+}
+
+// AlertTimeline is the sequence of alert transitions recorded during a spec.
+type AlertTimeline struct {
+	Namespace   string            `json:"namespace"`
+	Start       time.Time         `json:"start"`
+	End         time.Time         `json:"end"`
+	Transitions []AlertTransition `json:"transitions"`
+	// Errors are failed polls. They are deduplicated, so a source that is down is reported once.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// JSON returns the timeline as indented JSON.
+func (tl AlertTimeline) JSON() ([]byte, error) {
+	return json.MarshalIndent(tl, "", "  ")
+}
+
+// String formats the timeline as a table with a row per transition.
+func (tl AlertTimeline) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Alerts in namespace %s from %s to %s\n", tl.Namespace, tl.Start.Format(time.RFC3339), tl.End.Format(time.RFC3339))
+	if len(tl.Transitions) == 0 {
+		sb.WriteString("no alert transitions\n")
+	}
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	if len(tl.Transitions) > 0 {
+		fmt.Fprintln(w, "time\tsource\talert\tfrom\tto\tlabels")
+	}
+	for _, tr := range tl.Transitions {
+		from := tr.From
+		if from == "" {
+			from = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", tr.Time.Format(time.TimeOnly), tr.Source, tr.Alert, from, tr.To, alertLabelsString(tr.Labels))
+	}
+	_ = w.Flush()
+	for _, e := range tl.Errors {
+		fmt.Fprintf(&sb, "error: %s\n", e)
+	}
+	return sb.String()
+}
+
+func alertLabelsString(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, k := range sortedLabelNames(labels) {
+		if k == "alertname" {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func sortedLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// alertState is the last seen state of an alert.
+type alertState struct {
+	labels map[string]string
+	state  string
+}
+
+// AlertRecorder polls Alertmanager and the overwatch ALERTS series in background
+// and records every alert transition in a namespace.
+type AlertRecorder struct {
+	client    PrometheusClient
+	namespace string
+	interval  time.Duration
+
+	mu       sync.Mutex
+	timeline AlertTimeline
+	// states maps a source and alert labels to the last seen state.
+	states map[string]alertState
+	errors map[string]bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAlertRecorder creates a recorder of alerts in namespace polling every interval.
+// A zero interval defaults to consts.PollingInterval.
+func (p PrometheusClient) NewAlertRecorder(namespace string, interval time.Duration) *AlertRecorder {
+	if interval <= 0 {
+		interval = consts.PollingInterval
+	}
+	return &AlertRecorder{
+		client:    p,
+		namespace: namespace,
+		interval:  interval,
+		timeline:  AlertTimeline{Namespace: namespace},
+		states:    map[string]alertState{},
+		errors:    map[string]bool{},
+	}
+}
+
+// Start polls in background until Stop is called or ctx is canceled. The first poll is made immediately.
+func (r *AlertRecorder) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	r.timeline.Start = time.Now()
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			r.Poll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops polling and returns the recorded timeline. It is safe to call Stop more than once.
+func (r *AlertRecorder) Stop() AlertTimeline {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+	r.mu.Lock()
+	if r.timeline.End.IsZero() {
+		r.timeline.End = time.Now()
+	}
+	r.mu.Unlock()
+	return r.Timeline()
+}
+
+// Timeline returns a copy of the transitions recorded so far.
+func (r *AlertRecorder) Timeline() AlertTimeline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tl := r.timeline
+	tl.Transitions = append([]AlertTransition(nil), tl.Transitions...)
+	tl.Errors = append([]string(nil), tl.Errors...)
+	if tl.End.IsZero() {
+		tl.End = time.Now()
+	}
+	return tl
+}
+
+// Poll queries both sources once and records transitions since the previous poll.
+// A failed source keeps its previous states, so an outage is not reported as resolved alerts.
+func (r *AlertRecorder) Poll(ctx context.Context) {
+	now := time.Now()
+
+	amURL, filters := r.client.alertmanagerRequest(r.namespace, "")
+	alerts, err := r.client.queryAlertmanager(ctx, amURL, filters)
+	if err != nil {
+		r.recordError(ctx, SourceAlertmanager, err)
+	} else {
+		seen := map[string]alertState{}
+		for _, a := range alerts {
+			state := ""
+			if a.Status != nil && a.Status.State != nil {
+				state = *a.Status.State
+			}
+			labels := map[string]string(a.Labels)
+			seen[alertKey(SourceAlertmanager, labels)] = alertState{labels: labels, state: state}
+		}
+		r.record(now, SourceAlertmanager, seen)
+	}
+
+	query := "ALERTS"
+	if r.namespace != "" {
+		query = fmt.Sprintf("ALERTS{namespace=%q}", r.namespace)
+	}
+	vec, err := r.client.QueryVectorAt(ctx, query, now)
+	if err != nil {
+		r.recordError(ctx, SourceALERTS, err)
+		return
+	}
+	seen := map[string]alertState{}
+	for _, s := range vec {
+		labels := map[string]string{}
+		for k, v := range s.Metric {
+			if k != prommodel.MetricNameLabel && k != "alertstate" {
+				labels[string(k)] = string(v)
+			}
+		}
+		seen[alertKey(SourceALERTS, labels)] = alertState{labels: labels, state: string(s.Metric["alertstate"])}
+	}
+	r.record(now, SourceALERTS, seen)
+}
+
+func (r *AlertRecorder) recordError(ctx context.Context, source string, err error) {
+	if ctx.Err() != nil {
+		// Polls interrupted by Stop are not failures.
+		return
+	}
+	msg := fmt.Sprintf("%s: %s", source, err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.errors[msg] {
+		r.errors[msg] = true
+		r.timeline.Errors = append(r.timeline.Errors, msg)
+	}
+}
+
+// record compares alerts seen by the source with the previous poll.
+func (r *AlertRecorder) record(now time.Time, source string, seen map[string]alertState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var transitions []AlertTransition
+	for key, cur := range seen {
+		prev, ok := r.states[key]
+		if ok && prev.state == cur.state {
+			continue
+		}
+		transitions = append(transitions, AlertTransition{
+			Time: now, Source: source, Alert: cur.labels["alertname"], Labels: cur.labels, From: prev.state, To: cur.state,
+		})
+		r.states[key] = cur
+	}
+	prefix := source + "/"
+	for key, prev := range r.states {
+		if _, ok := seen[key]; ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		transitions = append(transitions, AlertTransition{
+			Time: now, Source: source, Alert: prev.labels["alertname"], Labels: prev.labels, From: prev.state, To: AlertStateResolved,
+		})
+		delete(r.states, key)
+	}
+	// Transitions of a poll happen at the same time, order them for stable output.
+	sort.Slice(transitions, func(i, j int) bool {
+		return alertKey(source, transitions[i].Labels) < alertKey(source, transitions[j].Labels)
+	})
+	r.timeline.Transitions = append(r.timeline.Transitions, transitions...)
+}
+
+func alertKey(source string, labels map[string]string) string {
+	return source + "/" + goldenKey(labels)
+}
@@ -0,0 +1,120 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alertSource serves Alertmanager alerts and ALERTS series from the current step of a scenario.
+type alertSource struct {
+	mu   sync.Mutex
+	am   string
+	vec  string
+	down bool
+}
+
+func (s *alertSource) set(am, vec string, down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.am, s.vec, s.down = am, vec, down
+}
+
+func (s *alertSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/api/v2/alerts" {
+		_, _ = fmt.Fprint(w, s.am)
+		return
+	}
+	_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, s.vec)
+}
+
+func TestAlertRecorder(t *testing.T) {
+	t.Parallel()
+	source := &alertSource{am: `[]`, vec: `[]`}
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	client, err := NewPrometheusClient(server.URL)
+	require.NoError(t, err)
+	client.AlertManagerURL = server.URL
+	recorder := client.NewAlertRecorder("ns", time.Hour)
+	ctx := context.Background()
+
+	recorder.Poll(ctx)
+	source.set(`[]`, `[{"metric":{"__name__":"ALERTS","alertname":"ServiceDown","alertstate":"pending","job":"vminsert"},"value":[1,"1"]}]`, false)
+	recorder.Poll(ctx)
+	source.set(`[{"labels":{"alertname":"ServiceDown","job":"vminsert"},"status":{"state":"active"}}]`,
+		`[{"metric":{"__name__":"ALERTS","alertname":"ServiceDown","alertstate":"firing","job":"vminsert"},"value":[1,"1"]}]`, false)
+	recorder.Poll(ctx)
+	recorder.Poll(ctx)
+	// Outages of sources are not resolutions.
+	source.set("", "", true)
+	recorder.Poll(ctx)
+	recorder.Poll(ctx)
+	source.set(`[]`, `[]`, false)
+	recorder.Poll(ctx)
+
+	timeline := recorder.Stop()
+	assert.Equal(t, "ns", timeline.Namespace)
+	type step struct{ source, from, to string }
+	var steps []step
+	for _, tr := range timeline.Transitions {
+		assert.Equal(t, "ServiceDown", tr.Alert)
+		assert.Equal(t, "vminsert", tr.Labels["job"])
+		steps = append(steps, step{tr.Source, tr.From, tr.To})
+	}
+	assert.Equal(t, []step{
+		{SourceALERTS, "", "pending"},
+		{SourceAlertmanager, "", "active"},
+		{SourceALERTS, "pending", "firing"},
+		{SourceAlertmanager, "active", AlertStateResolved},
+		{SourceALERTS, "firing", AlertStateResolved},
+	}, steps)
+	require.Len(t, timeline.Errors, 2, "Errors of a source should be reported once")
+	assert.Contains(t, timeline.Errors[0], SourceAlertmanager)
+
+	data, err := timeline.JSON()
+	require.NoError(t, err)
+	var decoded AlertTimeline
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Len(t, decoded.Transitions, 5)
+
+	table := timeline.String()
+	assert.Contains(t, table, "Alerts in namespace ns")
+	assert.Regexp(t, `ALERTS\s+ServiceDown\s+pending\s+firing\s+\{job="vminsert"\}`, table)
+}
+
+func TestAlertRecorder_Start(t *testing.T) {
+	t.Parallel()
+	source := &alertSource{am: `[{"labels":{"alertname":"Watchdog"},"status":{"state":"active"}}]`, vec: `[]`}
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	client, err := NewPrometheusClient(server.URL)
+	require.NoError(t, err)
+	client.AlertManagerURL = server.URL
+
+	recorder := client.NewAlertRecorder("", 10*time.Millisecond)
+	recorder.Start(context.Background())
+	require.Eventually(t, func() bool { return len(recorder.Timeline().Transitions) > 0 }, time.Second, 5*time.Millisecond)
+	timeline := recorder.Stop()
+	assert.Equal(t, timeline, recorder.Stop(), "Stop should be idempotent")
+	require.Len(t, timeline.Transitions, 1)
+	assert.Equal(t, "Watchdog", timeline.Transitions[0].Alert)
+	assert.False(t, timeline.End.Before(timeline.Start))
+}
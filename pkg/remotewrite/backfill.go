@@ -0,0 +1,127 @@
+package remotewrite
+
+import (
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// DefaultFarFutureOffset is the offset after the end of the range used for far-future samples
+// when Irregularities.FarFutureOffset is not set.
+const DefaultFarFutureOffset = 7 * 24 * time.Hour
+
+// TimeRange describes a window of samples at a fixed step. Both Start and End are inclusive.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+// Timestamps returns millisecond timestamps from Start to End with the configured Step.
+func (r TimeRange) Timestamps() []int64 {
+	if r.Step <= 0 || r.End.Before(r.Start) {
+		return nil
+	}
+	var ts []int64
+	for t := r.Start; !t.After(r.End); t = t.Add(r.Step) {
+		ts = append(ts, t.UnixMilli())
+	}
+	return ts
+}
+
+// Irregularities configures injection of samples that storage has to handle specially.
+// Samples are picked evenly across the range, so generated data is deterministic.
+type Irregularities struct {
+	// OutOfOrder is the number of samples per series moved to the end of the series,
+	// so they are sent after samples with later timestamps.
+	OutOfOrder int
+	// Duplicates is the number of samples per series sent twice with the same timestamp and value.
+	Duplicates int
+	// FarFuture is the number of samples per series with timestamps after the end of the range.
+	FarFuture int
+	// FarFutureOffset is how far after the end of the range far-future samples are placed.
+	FarFutureOffset time.Duration
+}
+
+// GenTimeSeriesRange generates size series like GenTimeSeries, but with one sample per timestamp in r,
+// and injects the irregular samples configured in irr.
+func GenTimeSeriesRange(namePrefix string, size int, value float64, r TimeRange, irr Irregularities) []prompb.TimeSeries {
+	return GenTimeSeriesWithGenerator(namePrefix, size, Constant(value), r, irr)
+}
+
+// GenTimeSeriesWithGenerator is like GenTimeSeriesRange, but sample values are produced by gen.
+// All series get the same values.
+func GenTimeSeriesWithGenerator(namePrefix string, size int, gen ValueGenerator, r TimeRange, irr Irregularities) []prompb.TimeSeries {
+	samples := GenSamples(gen, r.Timestamps())
+	ts := make([]prompb.TimeSeries, 0, size)
+	for i := 0; i < size; i++ {
+		ts = append(ts, prompb.TimeSeries{
+			Labels:  seriesLabels(namePrefix, i),
+			Samples: applyIrregularities(samples, r.End, irr),
+		})
+	}
+	return ts
+}
+
+// applyIrregularities reorders, duplicates and extends samples according to irr.
+func applyIrregularities(samples []prompb.Sample, end time.Time, irr Irregularities) []prompb.Sample {
+	if len(samples) == 0 {
+		return samples
+	}
+
+	var result []prompb.Sample
+	if irr.OutOfOrder > 0 && len(samples) > 1 {
+		// Never move the last sample - it is already at the end.
+		moved := make(map[int]bool, irr.OutOfOrder)
+		for _, i := range pickEvenly(len(samples)-1, irr.OutOfOrder) {
+			moved[i] = true
+		}
+		var delayed []prompb.Sample
+		for i, s := range samples {
+			if moved[i] {
+				delayed = append(delayed, s)
+				continue
+			}
+			result = append(result, s)
+		}
+		result = append(result, delayed...)
+	} else {
+		result = append(result, samples...)
+	}
+
+	if irr.Duplicates > 0 {
+		for _, i := range pickEvenly(len(samples), irr.Duplicates) {
+			result = append(result, samples[i])
+		}
+	}
+
+	if irr.FarFuture > 0 {
+		offset := irr.FarFutureOffset
+		if offset <= 0 {
+			offset = DefaultFarFutureOffset
+		}
+		value := samples[len(samples)-1].Value
+		for i := 0; i < irr.FarFuture; i++ {
+			result = append(result, prompb.Sample{
+				Value:     value,
+				Timestamp: end.Add(offset).Add(time.Duration(i) * time.Second).UnixMilli(),
+			})
+		}
+	}
+	return result
+}
+
+// pickEvenly returns up to n ascending indices spread evenly over [0, total).
+func pickEvenly(total, n int) []int {
+	if total <= 0 || n <= 0 {
+		return nil
+	}
+	if n > total {
+		n = total
+	}
+	picked := make([]int, 0, n)
+	for k := 0; k < n; k++ {
+		picked = append(picked, k*total/n)
+	}
+	return picked
+}
@@ -0,0 +1,64 @@
+package remotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeRangeTimestamps(t *testing.T) {
+	t.Parallel()
+	start := time.UnixMilli(0)
+
+	assert.Equal(t, []int64{0, 1000, 2000}, TimeRange{Start: start, End: start.Add(2 * time.Second), Step: time.Second}.Timestamps())
+	assert.Equal(t, []int64{0, 2000}, TimeRange{Start: start, End: start.Add(3 * time.Second), Step: 2 * time.Second}.Timestamps())
+	assert.Nil(t, TimeRange{Start: start, End: start.Add(time.Second)}.Timestamps(), "Zero step should produce no timestamps")
+	assert.Nil(t, TimeRange{Start: start.Add(time.Second), End: start, Step: time.Second}.Timestamps(), "Inverted range should produce no timestamps")
+}
+
+func TestGenTimeSeriesRange(t *testing.T) {
+	t.Parallel()
+	start := time.UnixMilli(0)
+	r := TimeRange{Start: start, End: start.Add(9 * time.Second), Step: time.Second}
+
+	timestamps := func(samples []prompb.Sample) []int64 {
+		var res []int64
+		for _, s := range samples {
+			res = append(res, s.Timestamp)
+		}
+		return res
+	}
+
+	t.Run("regular", func(t *testing.T) {
+		t.Parallel()
+		ts := GenTimeSeriesRange("m", 2, 5, r, Irregularities{})
+		require.Len(t, ts, 2)
+		assert.Equal(t, "m_1", ts[1].Labels[0].Value)
+		assert.Equal(t, r.Timestamps(), timestamps(ts[0].Samples))
+		for _, s := range ts[0].Samples {
+			assert.Equal(t, float64(5), s.Value)
+		}
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		t.Parallel()
+		ts := GenTimeSeriesRange("m", 1, 1, r, Irregularities{OutOfOrder: 3})
+		assert.Equal(t, []int64{1000, 2000, 4000, 5000, 7000, 8000, 9000, 0, 3000, 6000}, timestamps(ts[0].Samples))
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		t.Parallel()
+		ts := GenTimeSeriesRange("m", 1, 1, r, Irregularities{Duplicates: 2})
+		assert.Equal(t, append(r.Timestamps(), 0, 5000), timestamps(ts[0].Samples))
+	})
+
+	t.Run("far future", func(t *testing.T) {
+		t.Parallel()
+		ts := GenTimeSeriesRange("m", 1, 1, r, Irregularities{FarFuture: 2})
+		end := r.End.Add(DefaultFarFutureOffset)
+		assert.Equal(t, append(r.Timestamps(), end.UnixMilli(), end.Add(time.Second).UnixMilli()), timestamps(ts[0].Samples))
+	})
+}
@@ -0,0 +1,108 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// BatchOptions configures splitting of time series into multiple remote write requests.
+// Zero values disable the corresponding limit.
+type BatchOptions struct {
+	// MaxSeries is the maximum number of series per request.
+	MaxSeries int
+	// MaxBytes is the maximum uncompressed size of series in a request, estimated with RW 1.0 encoding.
+	// A single series larger than MaxBytes is still sent in its own request.
+	MaxBytes int
+	// Concurrency is the number of requests sent in parallel. Defaults to 1.
+	Concurrency int
+}
+
+// BatchResult summarizes a batched write.
+type BatchResult struct {
+	Requests       int
+	FailedRequests int
+	// Series and Samples count data in successful requests only.
+	Series  int
+	Samples int
+}
+
+// SplitBatches splits ts into consecutive chunks satisfying the series count and size limits.
+func SplitBatches(ts []prompb.TimeSeries, maxSeries, maxBytes int) [][]prompb.TimeSeries {
+	if len(ts) == 0 {
+		return nil
+	}
+	var (
+		batches [][]prompb.TimeSeries
+		start   int
+		size    int
+	)
+	for i := range ts {
+		seriesSize := 0
+		if maxBytes > 0 {
+			seriesSize = seriesProtoSize(ts[i])
+		}
+		full := maxSeries > 0 && i-start >= maxSeries
+		tooBig := maxBytes > 0 && i > start && size+seriesSize > maxBytes
+		if full || tooBig {
+			batches = append(batches, ts[start:i])
+			start, size = i, 0
+		}
+		size += seriesSize
+	}
+	return append(batches, ts[start:])
+}
+
+// seriesProtoSize returns the size of the series in a RW 1.0 WriteRequest.
+func seriesProtoSize(ts prompb.TimeSeries) int {
+	wr := prompb.WriteRequest{Timeseries: []prompb.TimeSeries{ts}}
+	return len(wr.MarshalProtobuf(nil))
+}
+
+// RemoteWriteBatches splits ts according to batch and sends the chunks concurrently with RemoteWriteContext.
+// All chunks are attempted; failures are joined into the returned error.
+func RemoteWriteBatches(ctx context.Context, c *http.Client, ts []prompb.TimeSeries, url string, opts WriteOptions, batch BatchOptions) (BatchResult, error) {
+	batches := SplitBatches(ts, batch.MaxSeries, batch.MaxBytes)
+	workers := max(batch.Concurrency, 1)
+
+	var (
+		mu     sync.Mutex
+		result BatchResult
+		errs   []error
+		wg     sync.WaitGroup
+	)
+	work := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				err := RemoteWriteContext(ctx, c, batches[i], url, opts)
+
+				mu.Lock()
+				result.Requests++
+				if err != nil {
+					result.FailedRequests++
+					errs = append(errs, fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err))
+				} else {
+					result.Series += len(batches[i])
+					for _, s := range batches[i] {
+						result.Samples += len(s.Samples)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range batches {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	return result, errors.Join(errs...)
+}
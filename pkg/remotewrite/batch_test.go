@@ -0,0 +1,79 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBatches(t *testing.T) {
+	t.Parallel()
+	ts := GenTimeSeries("m", 10, 1)
+
+	assert.Nil(t, SplitBatches(nil, 2, 0))
+	assert.Len(t, SplitBatches(ts, 0, 0), 1, "No limits should produce a single batch")
+
+	batches := SplitBatches(ts, 4, 0)
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 4)
+	assert.Len(t, batches[2], 2)
+
+	size := seriesProtoSize(ts[0])
+	batches = SplitBatches(ts, 0, 3*size)
+	require.Len(t, batches, 4)
+	for _, b := range batches {
+		assert.LessOrEqual(t, len(b), 3)
+	}
+
+	batches = SplitBatches(ts, 0, 1)
+	assert.Len(t, batches, 10, "Series larger than the limit should be sent one by one")
+
+	total := 0
+	for _, b := range SplitBatches(ts, 3, 2*size) {
+		assert.LessOrEqual(t, len(b), 2)
+		total += len(b)
+	}
+	assert.Equal(t, len(ts), total)
+}
+
+func TestRemoteWriteBatches(t *testing.T) {
+	t.Parallel()
+	var requests, inFlight, maxInFlight atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			prev := maxInFlight.Load()
+			if cur <= prev || maxInFlight.CompareAndSwap(prev, cur) {
+				break
+			}
+		}
+		if requests.Add(1) == 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ts := GenTimeSeries("m", 100, 1)
+	result, err := RemoteWriteBatches(context.Background(), server.Client(), ts, server.URL, WriteOptions{}, BatchOptions{MaxSeries: 10, Concurrency: 4})
+	require.Error(t, err)
+
+	var we *WriteError
+	require.True(t, errors.As(err, &we), "Joined error should expose the WriteError")
+	assert.Equal(t, http.StatusBadRequest, we.StatusCode)
+
+	assert.Equal(t, 10, result.Requests)
+	assert.Equal(t, 1, result.FailedRequests)
+	assert.Equal(t, 90, result.Series)
+	assert.Equal(t, 90, result.Samples)
+	assert.Equal(t, int64(10), requests.Load())
+	assert.LessOrEqual(t, maxInFlight.Load(), int64(4))
+}
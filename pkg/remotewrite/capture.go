@@ -0,0 +1,267 @@
+package remotewrite
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// captureMagic starts every capture file, so replaying an unrelated file fails early.
+const captureMagic = "VMRWCAP2"
+
+const (
+	// maxCaptureFieldSize bounds a single field read from a capture file, so a corrupt file
+	// cannot make the reader allocate arbitrary amounts of memory. It is well above
+	// the default request size limit of VictoriaMetrics.
+	maxCaptureFieldSize = 64 << 20
+	// maxCaptureHeaders bounds the number of headers of a record.
+	maxCaptureHeaders = 1024
+)
+
+// CaptureRecord is a single remote write request saved by a Recorder.
+type CaptureRecord struct {
+	Time     time.Time
+	URL      string
+	Protocol Protocol
+	// Headers are the extra request headers, e.g. tenant and Authorization headers.
+	// Credentials are stored as is, so capture files must be handled as secrets.
+	Headers http.Header
+	// Payload is the encoded and compressed request body, exactly as it was sent.
+	Payload []byte
+}
+
+// Recorder appends remote write requests to a capture file.
+// Every record is written as uvarint-prefixed fields: unix nanoseconds, URL, protocol,
+// the number of header values followed by name and value of each, and payload.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu   sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+	path string
+}
+
+// NewRecorder opens the capture file at path for appending, creating it if needed.
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open capture file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("cannot stat capture file: %w", err)
+	}
+	r := &Recorder{f: f, w: bufio.NewWriter(f), path: path}
+	if info.Size() == 0 {
+		if _, err := r.w.WriteString(captureMagic); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("cannot write capture header: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Path returns the path of the capture file.
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Record appends rec to the capture file and flushes it, so the capture survives a failed spec.
+func (r *Recorder) Record(rec CaptureRecord) error {
+	var buf []byte
+	buf = binary.AppendUvarint(buf, uint64(rec.Time.UnixNano()))
+	buf = appendCaptureBytes(buf, []byte(rec.URL))
+	buf = appendCaptureBytes(buf, []byte(rec.Protocol))
+	var headers int
+	for _, values := range rec.Headers {
+		headers += len(values)
+	}
+	buf = binary.AppendUvarint(buf, uint64(headers))
+	for name, values := range rec.Headers {
+		for _, v := range values {
+			buf = appendCaptureBytes(buf, []byte(name))
+			buf = appendCaptureBytes(buf, []byte(v))
+		}
+	}
+	buf = appendCaptureBytes(buf, rec.Payload)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(buf); err != nil {
+		return fmt.Errorf("cannot write capture record: %w", err)
+	}
+	return r.w.Flush()
+}
+
+// Close flushes and closes the capture file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.w.Flush(); err != nil {
+		_ = r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+func appendCaptureBytes(dst, b []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// ReadCapture reads all records from the capture file at path.
+func ReadCapture(path string) ([]CaptureRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open capture file: %w", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != captureMagic {
+		return nil, fmt.Errorf("%s is not a remote write capture file", path)
+	}
+
+	var records []CaptureRecord
+	for {
+		ts, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read record %d: %w", len(records), err)
+		}
+		rec, err := readCaptureRecord(br)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read record %d: %w", len(records), err)
+		}
+		rec.Time = time.Unix(0, int64(ts))
+		records = append(records, rec)
+	}
+}
+
+// readCaptureRecord reads the fields of a record following its timestamp.
+func readCaptureRecord(br *bufio.Reader) (CaptureRecord, error) {
+	var rec CaptureRecord
+	url, err := readCaptureBytes(br)
+	if err != nil {
+		return rec, err
+	}
+	protocol, err := readCaptureBytes(br)
+	if err != nil {
+		return rec, err
+	}
+	rec.URL, rec.Protocol = string(url), Protocol(protocol)
+
+	headers, err := binary.ReadUvarint(br)
+	if err != nil {
+		return rec, err
+	}
+	if headers > maxCaptureHeaders {
+		return rec, fmt.Errorf("too many headers: %d", headers)
+	}
+	if headers > 0 {
+		rec.Headers = make(http.Header)
+	}
+	for i := uint64(0); i < headers; i++ {
+		name, err := readCaptureBytes(br)
+		if err != nil {
+			return rec, err
+		}
+		value, err := readCaptureBytes(br)
+		if err != nil {
+			return rec, err
+		}
+		rec.Headers.Add(string(name), string(value))
+	}
+
+	rec.Payload, err = readCaptureBytes(br)
+	return rec, err
+}
+
+func readCaptureBytes(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxCaptureFieldSize {
+		return nil, fmt.Errorf("field size %d exceeds the limit of %d bytes", n, maxCaptureFieldSize)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// URL overrides the target of every record. If empty, records are sent to their original URL.
+	URL string
+	// Speed scales delays between records: 1 keeps the original pace, 2 replays twice as fast.
+	// Zero or negative sends records back to back.
+	Speed float64
+	// Headers are added to every request on top of the recorded headers, replacing recorded
+	// headers with the same name, e.g. to authenticate against the new target.
+	Headers http.Header
+}
+
+// Replay re-sends captured payloads unchanged, keeping the original protocol of every record.
+// It stops at the first failed request.
+func Replay(ctx context.Context, c *http.Client, records []CaptureRecord, opts ReplayOptions) error {
+	if len(records) == 0 {
+		return nil
+	}
+	start := time.Now()
+	first := records[0].Time
+	for i, rec := range records {
+		if opts.Speed > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(first)) / opts.Speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("replay aborted at record %d: %w", i, ctx.Err())
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		url := rec.URL
+		if opts.URL != "" {
+			url = opts.URL
+		}
+		protocol := rec.Protocol
+		if protocol == "" {
+			protocol = ProtocolV1
+		}
+		headers := rec.Headers.Clone()
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		for name, values := range opts.Headers {
+			headers[http.CanonicalHeaderKey(name)] = values
+		}
+		if err := sendPayload(ctx, c, rec.Payload, url, protocol, headers); err != nil {
+			return fmt.Errorf("cannot replay record %d to %s: %w", i, url, err)
+		}
+	}
+	return nil
+}
+
+// ReplayFile reads the capture file at path and replays it with Replay.
+func ReplayFile(ctx context.Context, c *http.Client, path string, opts ReplayOptions) error {
+	records, err := ReadCapture(path)
+	if err != nil {
+		return err
+	}
+	return Replay(ctx, c, records, opts)
+}
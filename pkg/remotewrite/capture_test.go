@@ -0,0 +1,125 @@
+package remotewrite
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   [][]byte
+	versions []string
+	headers  []http.Header
+}
+
+func newCapturingServer(t *testing.T) *capturingServer {
+	s := &capturingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.versions = append(s.versions, r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return s
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	original := newCapturingServer(t)
+	defer original.Close()
+
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, RemoteWriteContext(ctx, original.Client(), GenTimeSeries("a", 2, 1), original.URL, WriteOptions{Recorder: recorder}))
+	headers := http.Header{"Accountid": {"3"}, "Authorization": {"Bearer old"}}
+	require.NoError(t, RemoteWriteContext(ctx, original.Client(), GenTimeSeries("b", 1, 2), original.URL, WriteOptions{Recorder: recorder, Protocol: ProtocolV2, Headers: headers}))
+	require.NoError(t, recorder.Close())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "Captures hold credentials and should be private")
+
+	// Reopening appends to the existing capture
+	recorder, err = NewRecorder(path)
+	require.NoError(t, err)
+	require.NoError(t, RemoteWriteContext(ctx, original.Client(), GenTimeSeries("c", 1, 3), original.URL, WriteOptions{Recorder: recorder}))
+	require.NoError(t, recorder.Close())
+
+	records, err := ReadCapture(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, original.URL, records[0].URL)
+	assert.Equal(t, ProtocolV2, records[1].Protocol)
+	assert.Equal(t, headers, records[1].Headers)
+	assert.Nil(t, records[0].Headers)
+	assert.False(t, records[2].Time.Before(records[0].Time))
+
+	replayed := newCapturingServer(t)
+	defer replayed.Close()
+	replayOpts := ReplayOptions{URL: replayed.URL, Headers: http.Header{"authorization": {"Bearer new"}}}
+	require.NoError(t, Replay(ctx, replayed.Client(), records, replayOpts))
+	assert.Equal(t, original.bodies, replayed.bodies, "Replayed payloads must be identical")
+	assert.Equal(t, original.versions, replayed.versions)
+	assert.Equal(t, "3", replayed.headers[1].Get("AccountID"), "Recorded tenant headers should be replayed")
+	assert.Equal(t, "Bearer new", replayed.headers[1].Get("Authorization"), "Replay headers should replace recorded ones")
+	assert.Equal(t, "Bearer new", replayed.headers[0].Get("Authorization"))
+}
+
+func TestReplaySpeed(t *testing.T) {
+	t.Parallel()
+	server := newCapturingServer(t)
+	defer server.Close()
+
+	start := time.Now()
+	payload := GenPayload(GenTimeSeries("m", 1, 1))
+	records := []CaptureRecord{
+		{Time: start, URL: server.URL, Payload: payload},
+		{Time: start.Add(200 * time.Millisecond), URL: server.URL, Payload: payload},
+	}
+
+	began := time.Now()
+	require.NoError(t, Replay(context.Background(), server.Client(), records, ReplayOptions{Speed: 2}))
+	assert.GreaterOrEqual(t, time.Since(began), 100*time.Millisecond, "Delays should be scaled, not skipped")
+	assert.Len(t, server.bodies, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Replay(ctx, server.Client(), records, ReplayOptions{Speed: 1})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReadCapture_InvalidFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "garbage.bin")
+	require.NoError(t, os.WriteFile(path, []byte("not a capture"), 0o644))
+
+	_, err := ReadCapture(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a remote write capture file")
+
+	// A corrupt length must not be allocated.
+	corrupt := binary.AppendUvarint([]byte(captureMagic), 1)
+	corrupt = binary.AppendUvarint(corrupt, 1<<40)
+	require.NoError(t, os.WriteFile(path, corrupt, 0o644))
+	_, err = ReadCapture(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the limit")
+}
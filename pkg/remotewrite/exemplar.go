@@ -0,0 +1,111 @@
+package remotewrite
+
+import (
+	"math"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the prometheus.WriteRequest message and its children.
+// prompb.WriteRequest cannot encode exemplars, so requests with exemplars are marshaled manually.
+// See https://github.com/prometheus/prometheus/blob/main/prompb/types.proto
+const (
+	v1RequestTimeseries = 1
+	v1RequestMetadata   = 3
+
+	v1SeriesLabels    = 1
+	v1SeriesSamples   = 2
+	v1SeriesExemplars = 3
+
+	v1LabelName  = 1
+	v1LabelValue = 2
+
+	v1SampleValue     = 1
+	v1SampleTimestamp = 2
+
+	v1ExemplarLabels    = 1
+	v1ExemplarValue     = 2
+	v1ExemplarTimestamp = 3
+
+	v1MetadataType       = 1
+	v1MetadataFamilyName = 2
+	v1MetadataHelp       = 4
+	v1MetadataUnit       = 5
+)
+
+// Exemplar is an exemplar attached to a series, e.g. a trace ID for a histogram bucket.
+type Exemplar struct {
+	Labels    []prompb.Label
+	Value     float64
+	Timestamp int64
+}
+
+// SeriesKey returns the key identifying a series in WriteOptions.Exemplars.
+func SeriesKey(labels []prompb.Label) string {
+	return prompb.LabelsToString(labels)
+}
+
+// genPayloadV1WithExemplars marshals a RW 1.0 WriteRequest including exemplars and snappy encodes it.
+func genPayloadV1WithExemplars(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata, exemplars map[string][]Exemplar) []byte {
+	var payload []byte
+	for _, ts := range timeseries {
+		var series []byte
+		for _, l := range ts.Labels {
+			series = protowire.AppendTag(series, v1SeriesLabels, protowire.BytesType)
+			series = protowire.AppendBytes(series, marshalLabelV1(l))
+		}
+		for _, s := range ts.Samples {
+			var sample []byte
+			sample = protowire.AppendTag(sample, v1SampleValue, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+			sample = protowire.AppendTag(sample, v1SampleTimestamp, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(s.Timestamp))
+
+			series = protowire.AppendTag(series, v1SeriesSamples, protowire.BytesType)
+			series = protowire.AppendBytes(series, sample)
+		}
+		for _, e := range exemplars[SeriesKey(ts.Labels)] {
+			var ex []byte
+			for _, l := range e.Labels {
+				ex = protowire.AppendTag(ex, v1ExemplarLabels, protowire.BytesType)
+				ex = protowire.AppendBytes(ex, marshalLabelV1(l))
+			}
+			ex = protowire.AppendTag(ex, v1ExemplarValue, protowire.Fixed64Type)
+			ex = protowire.AppendFixed64(ex, math.Float64bits(e.Value))
+			ex = protowire.AppendTag(ex, v1ExemplarTimestamp, protowire.VarintType)
+			ex = protowire.AppendVarint(ex, uint64(e.Timestamp))
+
+			series = protowire.AppendTag(series, v1SeriesExemplars, protowire.BytesType)
+			series = protowire.AppendBytes(series, ex)
+		}
+		payload = protowire.AppendTag(payload, v1RequestTimeseries, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, series)
+	}
+
+	for _, md := range metadata {
+		var meta []byte
+		meta = protowire.AppendTag(meta, v1MetadataType, protowire.VarintType)
+		meta = protowire.AppendVarint(meta, uint64(md.Type))
+		meta = protowire.AppendTag(meta, v1MetadataFamilyName, protowire.BytesType)
+		meta = protowire.AppendString(meta, md.MetricFamilyName)
+		meta = protowire.AppendTag(meta, v1MetadataHelp, protowire.BytesType)
+		meta = protowire.AppendString(meta, md.Help)
+		meta = protowire.AppendTag(meta, v1MetadataUnit, protowire.BytesType)
+		meta = protowire.AppendString(meta, md.Unit)
+
+		payload = protowire.AppendTag(payload, v1RequestMetadata, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, meta)
+	}
+	return snappy.Encode(nil, payload)
+}
+
+func marshalLabelV1(l prompb.Label) []byte {
+	var dst []byte
+	dst = protowire.AppendTag(dst, v1LabelName, protowire.BytesType)
+	dst = protowire.AppendString(dst, l.Name)
+	dst = protowire.AppendTag(dst, v1LabelValue, protowire.BytesType)
+	dst = protowire.AppendString(dst, l.Value)
+	return dst
+}
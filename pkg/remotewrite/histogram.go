@@ -0,0 +1,193 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// Distribution is a set of observations recorded into a classic histogram or a summary.
+type Distribution struct {
+	// Buckets are histogram upper bounds in ascending order. The +Inf bucket is always added.
+	Buckets []float64
+	// Observations are the observed values.
+	Observations []float64
+}
+
+// Count returns the number of observations.
+func (d Distribution) Count() float64 {
+	return float64(len(d.Observations))
+}
+
+// Sum returns the sum of observations.
+func (d Distribution) Sum() float64 {
+	var sum float64
+	for _, v := range d.Observations {
+		sum += v
+	}
+	return sum
+}
+
+// BucketCounts returns cumulative bucket counts for Buckets followed by the +Inf bucket.
+func (d Distribution) BucketCounts() []float64 {
+	counts := make([]float64, len(d.Buckets)+1)
+	for _, v := range d.Observations {
+		for i, le := range d.Buckets {
+			if v <= le {
+				counts[i]++
+			}
+		}
+		counts[len(d.Buckets)]++
+	}
+	return counts
+}
+
+// BucketFor returns the le label value of the bucket the value falls into.
+func (d Distribution) BucketFor(v float64) string {
+	for _, le := range d.Buckets {
+		if v <= le {
+			return formatBound(le)
+		}
+	}
+	return formatBound(math.Inf(1))
+}
+
+// HistogramQuantile returns the expected result of histogram_quantile(q, ...) over the bucket counts,
+// using the same linear interpolation within a bucket as Prometheus and VictoriaMetrics.
+func (d Distribution) HistogramQuantile(q float64) float64 {
+	switch {
+	case math.IsNaN(q):
+		return math.NaN()
+	case q < 0:
+		return math.Inf(-1)
+	case q > 1:
+		return math.Inf(1)
+	}
+	counts := d.BucketCounts()
+	total := counts[len(counts)-1]
+	if len(d.Buckets) == 0 || total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * total
+	b := 0
+	for b < len(counts)-1 && counts[b] < rank {
+		b++
+	}
+	if b == len(d.Buckets) {
+		// The quantile falls into the +Inf bucket.
+		return d.Buckets[len(d.Buckets)-1]
+	}
+	if b == 0 && d.Buckets[0] <= 0 {
+		return d.Buckets[0]
+	}
+
+	start, prevCount := 0.0, 0.0
+	if b > 0 {
+		start = d.Buckets[b-1]
+		prevCount = counts[b-1]
+	}
+	end := d.Buckets[b]
+	return start + (end-start)*((rank-prevCount)/(counts[b]-prevCount))
+}
+
+// Quantile returns the exact q-quantile of the observations using the nearest-rank method.
+// It is the value reported by summaries generated from the distribution.
+func (d Distribution) Quantile(q float64) float64 {
+	if len(d.Observations) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), d.Observations...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// GenHistogramTimeSeries generates size classic histogram families <namePrefix>_<i>_bucket, _sum and _count
+// with one sample per timestamp in r. The distribution is observed once more at every timestamp,
+// so histogram_quantile over both raw buckets and their rate matches d.HistogramQuantile.
+func GenHistogramTimeSeries(namePrefix string, size int, d Distribution, r TimeRange) []prompb.TimeSeries {
+	timestamps := r.Timestamps()
+	counts := d.BucketCounts()
+	sum := d.Sum()
+
+	var ts []prompb.TimeSeries
+	for i := 0; i < size; i++ {
+		labels := seriesLabels(namePrefix, i)
+		name := labels[0].Value
+		for b, count := range counts {
+			le := math.Inf(1)
+			if b < len(d.Buckets) {
+				le = d.Buckets[b]
+			}
+			ts = append(ts, prompb.TimeSeries{
+				Labels:  withName(labels, name+"_bucket", prompb.Label{Name: "le", Value: formatBound(le)}),
+				Samples: growingSamples(timestamps, count),
+			})
+		}
+		ts = append(ts,
+			prompb.TimeSeries{Labels: withName(labels, name+"_sum"), Samples: growingSamples(timestamps, sum)},
+			prompb.TimeSeries{Labels: withName(labels, name+"_count"), Samples: growingSamples(timestamps, d.Count())},
+		)
+	}
+	return ts
+}
+
+// GenSummaryTimeSeries generates size summary families <namePrefix>_<i>{quantile="..."}, _sum and _count
+// with one sample per timestamp in r. Quantile values are computed with d.Quantile.
+func GenSummaryTimeSeries(namePrefix string, size int, quantiles []float64, d Distribution, r TimeRange) []prompb.TimeSeries {
+	timestamps := r.Timestamps()
+	sum := d.Sum()
+
+	var ts []prompb.TimeSeries
+	for i := 0; i < size; i++ {
+		labels := seriesLabels(namePrefix, i)
+		name := labels[0].Value
+		for _, q := range quantiles {
+			samples := make([]prompb.Sample, len(timestamps))
+			for k, t := range timestamps {
+				samples[k] = prompb.Sample{Value: d.Quantile(q), Timestamp: t}
+			}
+			ts = append(ts, prompb.TimeSeries{
+				Labels:  withName(labels, name, prompb.Label{Name: "quantile", Value: formatBound(q)}),
+				Samples: samples,
+			})
+		}
+		ts = append(ts,
+			prompb.TimeSeries{Labels: withName(labels, name+"_sum"), Samples: growingSamples(timestamps, sum)},
+			prompb.TimeSeries{Labels: withName(labels, name+"_count"), Samples: growingSamples(timestamps, d.Count())},
+		)
+	}
+	return ts
+}
+
+// growingSamples returns a counter increased by value at every timestamp.
+func growingSamples(timestamps []int64, value float64) []prompb.Sample {
+	samples := make([]prompb.Sample, len(timestamps))
+	for k, t := range timestamps {
+		samples[k] = prompb.Sample{Value: value * float64(k+1), Timestamp: t}
+	}
+	return samples
+}
+
+// withName returns a copy of labels with __name__ replaced by name and extra labels appended.
+func withName(labels []prompb.Label, name string, extra ...prompb.Label) []prompb.Label {
+	res := make([]prompb.Label, 0, len(labels)+len(extra))
+	for _, l := range labels {
+		if l.Name == "__name__" {
+			l.Value = name
+		}
+		res = append(res, l)
+	}
+	return append(res, extra...)
+}
+
+func formatBound(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistribution(t *testing.T) {
+	t.Parallel()
+	d := Distribution{
+		Buckets:      []float64{0.1, 0.5, 1},
+		Observations: []float64{0.05, 0.2, 0.3, 0.4, 0.7, 2},
+	}
+
+	assert.Equal(t, float64(6), d.Count())
+	assert.InDelta(t, 3.65, d.Sum(), 1e-9)
+	assert.Equal(t, []float64{1, 4, 5, 6}, d.BucketCounts())
+	assert.Equal(t, "0.5", d.BucketFor(0.3))
+	assert.Equal(t, "+Inf", d.BucketFor(5))
+
+	// rank 3 falls into (0.1, 0.5] with 3 observations: 0.1 + 0.4*(2/3)
+	assert.InDelta(t, 0.1+0.4*2/3, d.HistogramQuantile(0.5), 1e-9)
+	// rank 0.6 falls into the first bucket, which starts at zero
+	assert.InDelta(t, 0.06, d.HistogramQuantile(0.1), 1e-9)
+	// rank 5.94 falls into +Inf, so the highest finite bound is returned
+	assert.Equal(t, float64(1), d.HistogramQuantile(0.99))
+	assert.True(t, math.IsInf(d.HistogramQuantile(2), 1))
+	assert.True(t, math.IsNaN(Distribution{Buckets: []float64{1}}.HistogramQuantile(0.5)))
+
+	assert.Equal(t, 0.3, d.Quantile(0.5))
+	assert.Equal(t, float64(2), d.Quantile(1))
+	assert.Equal(t, 0.05, d.Quantile(0))
+}
+
+func TestGenHistogramTimeSeries(t *testing.T) {
+	t.Parallel()
+	d := Distribution{Buckets: []float64{1, 2}, Observations: []float64{0.5, 1.5, 3}}
+	start := time.UnixMilli(0)
+	r := TimeRange{Start: start, End: start.Add(time.Second), Step: time.Second}
+
+	ts := GenHistogramTimeSeries("h", 1, d, r)
+	require.Len(t, ts, 5)
+
+	byName := map[string][]prompb.Sample{}
+	for _, s := range ts {
+		key := metricName(s.Labels)
+		for _, l := range s.Labels {
+			if l.Name == "le" {
+				key += "{le=" + l.Value + "}"
+			}
+		}
+		byName[key] = s.Samples
+	}
+	assert.Equal(t, []prompb.Sample{{Value: 1, Timestamp: 0}, {Value: 2, Timestamp: 1000}}, byName["h_0_bucket{le=1}"])
+	assert.Equal(t, []prompb.Sample{{Value: 2, Timestamp: 0}, {Value: 4, Timestamp: 1000}}, byName["h_0_bucket{le=2}"])
+	assert.Equal(t, []prompb.Sample{{Value: 3, Timestamp: 0}, {Value: 6, Timestamp: 1000}}, byName["h_0_bucket{le=+Inf}"])
+	assert.Equal(t, []prompb.Sample{{Value: 5, Timestamp: 0}, {Value: 10, Timestamp: 1000}}, byName["h_0_sum"])
+	assert.Equal(t, []prompb.Sample{{Value: 3, Timestamp: 0}, {Value: 6, Timestamp: 1000}}, byName["h_0_count"])
+}
+
+func TestGenSummaryTimeSeries(t *testing.T) {
+	t.Parallel()
+	d := Distribution{Observations: []float64{1, 2, 3, 4}}
+	start := time.UnixMilli(0)
+	r := TimeRange{Start: start, End: start, Step: time.Second}
+
+	ts := GenSummaryTimeSeries("s", 1, []float64{0.5, 0.99}, d, r)
+	require.Len(t, ts, 4)
+	assert.Equal(t, "s_0", metricName(ts[0].Labels))
+	assert.Equal(t, prompb.Label{Name: "quantile", Value: "0.5"}, ts[0].Labels[len(ts[0].Labels)-1])
+	assert.Equal(t, float64(2), ts[0].Samples[0].Value)
+	assert.Equal(t, float64(4), ts[1].Samples[0].Value)
+	assert.Equal(t, "s_0_sum", metricName(ts[2].Labels))
+	assert.Equal(t, float64(10), ts[2].Samples[0].Value)
+	assert.Equal(t, "s_0_count", metricName(ts[3].Labels))
+	assert.Equal(t, float64(4), ts[3].Samples[0].Value)
+}
+
+func TestGenPayloadWithExemplars(t *testing.T) {
+	t.Parallel()
+	ts := []prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "latency_bucket"}, {Name: "le", Value: "0.5"}},
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 1000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "latency_count"}},
+			Samples: []prompb.Sample{{Value: 4, Timestamp: 1000}},
+		},
+	}
+	metadata := []prompb.MetricMetadata{
+		{MetricFamilyName: "latency", Type: prompb.MetricTypeHistogram, Help: "Request latency", Unit: "seconds"},
+	}
+	exemplars := map[string][]Exemplar{
+		SeriesKey(ts[0].Labels): {{Labels: []prompb.Label{{Name: "trace_id", Value: "abc"}}, Value: 0.3, Timestamp: 1000}},
+	}
+	opts := WriteOptions{Metadata: metadata, Exemplars: exemplars}
+
+	t.Run("v1", func(t *testing.T) {
+		t.Parallel()
+		data, err := snappy.Decode(nil, GenPayloadWithOptions(ts, opts))
+		require.NoError(t, err)
+
+		wru := prompb.GetWriteRequestUnmarshaler()
+		defer prompb.PutWriteRequestUnmarshaler(wru)
+		wr, err := wru.UnmarshalProtobuf(data)
+		require.NoError(t, err, "Payload with exemplars must stay compatible with RW 1.0 decoders")
+		require.Len(t, wr.Timeseries, 2)
+		assert.Equal(t, ts[0].Labels, wr.Timeseries[0].Labels)
+		assert.Equal(t, ts[1].Samples, wr.Timeseries[1].Samples)
+		require.Len(t, wr.Metadata, 1)
+		assert.Equal(t, metadata[0].Help, wr.Metadata[0].Help)
+		assert.Contains(t, string(data), "trace_id")
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		t.Parallel()
+		opts := opts
+		opts.Protocol = ProtocolV2
+		_, series := decodeV2(t, GenPayloadWithOptions(ts, opts))
+		require.Len(t, series, 2)
+		assert.Equal(t, []float64{0.3}, series[0].exemplars)
+		assert.Empty(t, series[1].exemplars)
+		assert.Equal(t, uint64(prompb.MetricTypeHistogram), series[0].metadataType, "Metadata should match the histogram family")
+		assert.Equal(t, "seconds", series[1].unit)
+	})
+}
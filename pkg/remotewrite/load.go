@@ -0,0 +1,342 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// CardinalityProfile describes the shape of the series set written by the load generator.
+type CardinalityProfile struct {
+	// Metrics is the number of distinct metric names.
+	Metrics int
+	// SeriesPerMetric is the number of series per metric name.
+	SeriesPerMetric int
+	// ExtraLabels is the number of additional labels attached to every series.
+	ExtraLabels int
+}
+
+// Series returns the total number of active series in the profile.
+func (p CardinalityProfile) Series() int {
+	return p.Metrics * p.SeriesPerMetric
+}
+
+// LoadConfig configures a sustained-rate remote write load run.
+type LoadConfig struct {
+	// URL is the remote write endpoint.
+	URL string
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+	// MetricPrefix is used to generate metric names: <prefix>_<n>.
+	MetricPrefix string
+	// SamplesPerSecond is the target ingestion rate.
+	SamplesPerSecond int
+	// Duration is how long to generate load.
+	Duration time.Duration
+	// Workers is the number of concurrent senders.
+	Workers int
+	// BatchSize is the number of samples sent per request. It is capped at the number of series,
+	// so a request never carries two samples of a series with the same timestamp.
+	BatchSize int
+	// Cardinality is the shape of the active series set.
+	Cardinality CardinalityProfile
+	// ChurnInterval is how often a part of the series set is replaced with new series. Zero disables churn.
+	ChurnInterval time.Duration
+	// ChurnRate is the fraction (0..1) of series replaced every ChurnInterval.
+	ChurnRate float64
+	// Options are passed to every remote write request. Options.Stats is replaced by the run's own collector.
+	Options WriteOptions
+}
+
+// DefaultLoadConfig returns a LoadConfig writing 1000 samples/s over 1000 series for a minute.
+func DefaultLoadConfig(url string, client *http.Client) LoadConfig {
+	return LoadConfig{
+		URL:              url,
+		Client:           client,
+		MetricPrefix:     "load_test",
+		SamplesPerSecond: 1000,
+		Duration:         time.Minute,
+		Workers:          4,
+		BatchSize:        100,
+		Cardinality: CardinalityProfile{
+			Metrics:         10,
+			SeriesPerMetric: 100,
+		},
+	}
+}
+
+func (cfg LoadConfig) validate() error {
+	switch {
+	case cfg.URL == "":
+		return fmt.Errorf("no URL configured for load generator")
+	case cfg.Client == nil:
+		return fmt.Errorf("no HTTP client configured for load generator")
+	case cfg.SamplesPerSecond <= 0:
+		return fmt.Errorf("samples per second must be positive, got %d", cfg.SamplesPerSecond)
+	case cfg.Duration <= 0:
+		return fmt.Errorf("duration must be positive, got %s", cfg.Duration)
+	case cfg.Workers <= 0:
+		return fmt.Errorf("workers must be positive, got %d", cfg.Workers)
+	case cfg.BatchSize <= 0:
+		return fmt.Errorf("batch size must be positive, got %d", cfg.BatchSize)
+	case cfg.Cardinality.Series() <= 0:
+		return fmt.Errorf("cardinality profile must have at least one series")
+	case cfg.batchInterval() <= 0:
+		return fmt.Errorf("%d samples per second cannot be sent in batches of %d samples, increase the batch size",
+			cfg.SamplesPerSecond, cfg.batchSize())
+	case cfg.ChurnRate < 0 || cfg.ChurnRate > 1:
+		return fmt.Errorf("churn rate must be within [0, 1], got %f", cfg.ChurnRate)
+	}
+	return nil
+}
+
+// batchSize returns BatchSize capped at the number of series.
+func (cfg LoadConfig) batchSize() int {
+	if series := cfg.Cardinality.Series(); cfg.BatchSize > series {
+		return series
+	}
+	return cfg.BatchSize
+}
+
+// batchInterval returns how often a batch is sent to reach SamplesPerSecond.
+func (cfg LoadConfig) batchInterval() time.Duration {
+	return time.Duration(float64(time.Second) * float64(cfg.batchSize()) / float64(cfg.SamplesPerSecond))
+}
+
+// LoadResult summarizes a load run.
+type LoadResult struct {
+	// Elapsed is the actual duration of the run.
+	Elapsed time.Duration
+	// Requests is the total number of requests issued.
+	Requests int64
+	// FailedRequests is the number of requests that returned an error.
+	FailedRequests int64
+	// SamplesSent is the number of samples accepted by the server.
+	SamplesSent int64
+	// SamplesPerSecond is the achieved ingestion rate of accepted samples.
+	SamplesPerSecond float64
+	// SeriesCreated is the number of distinct series written, including churned ones.
+	SeriesCreated int64
+	// LatencyP50, LatencyP90, LatencyP99 and LatencyMax are request latency percentiles.
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+	LatencyMax time.Duration
+	// StatusErrors counts failed requests by HTTP status code.
+	StatusErrors map[int]int64
+	// TransportErrors counts requests which failed without an HTTP response.
+	TransportErrors int64
+	// Retried, Rejected and Dropped mirror WriteStats for the run.
+	Retried  int64
+	Rejected int64
+	Dropped  int64
+}
+
+// String returns a human-readable summary of the result.
+func (r LoadResult) String() string {
+	return fmt.Sprintf("elapsed=%s requests=%d failed=%d samples=%d rate=%.1f/s series=%d p50=%s p90=%s p99=%s max=%s",
+		r.Elapsed, r.Requests, r.FailedRequests, r.SamplesSent, r.SamplesPerSecond, r.SeriesCreated,
+		r.LatencyP50, r.LatencyP90, r.LatencyP99, r.LatencyMax)
+}
+
+// seriesSet generates batches over the configured cardinality profile,
+// replacing part of the series on every churn.
+type seriesSet struct {
+	mu     sync.Mutex
+	cfg    LoadConfig
+	cursor int
+	// churnCursor is the first series replaced by the next churn, so churns rotate over the whole set.
+	churnCursor int
+	generation  int
+	// generations and values hold the generation and the current counter value of every series.
+	generations []int
+	values      []float64
+	created     int64
+}
+
+func newSeriesSet(cfg LoadConfig) *seriesSet {
+	total := cfg.Cardinality.Series()
+	return &seriesSet{
+		cfg:         cfg,
+		generations: make([]int, total),
+		values:      make([]float64, total),
+		created:     int64(total),
+	}
+}
+
+// churn moves the next ChurnRate fraction of series to a new generation, creating new series.
+func (s *seriesSet) churn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := s.cfg.Cardinality.Series()
+	churned := int(float64(total) * s.cfg.ChurnRate)
+	s.generation++
+	for i := 0; i < churned; i++ {
+		idx := (s.churnCursor + i) % total
+		s.generations[idx] = s.generation
+		s.values[idx] = 0
+	}
+	s.churnCursor = (s.churnCursor + churned) % total
+	s.created += int64(churned)
+}
+
+func (s *seriesSet) nextBatch(now time.Time) []prompb.TimeSeries {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := s.cfg.Cardinality.Series()
+	size := s.cfg.batchSize()
+	ts := make([]prompb.TimeSeries, 0, size)
+	for i := 0; i < size; i++ {
+		idx := s.cursor
+		s.cursor = (s.cursor + 1) % total
+		ts = append(ts, s.series(idx, now))
+	}
+	return ts
+}
+
+// series returns the next sample of the series. Every series is a counter of its own samples,
+// so increase() over a series equals the number of samples written to it.
+func (s *seriesSet) series(idx int, now time.Time) prompb.TimeSeries {
+	metric := idx / s.cfg.Cardinality.SeriesPerMetric
+	generation := s.generations[idx]
+	s.values[idx]++
+	labels := []prompb.Label{
+		{Name: "__name__", Value: fmt.Sprintf("%s_%d", s.cfg.MetricPrefix, metric)},
+		{Name: "series", Value: fmt.Sprintf("%d", idx)},
+		{Name: "generation", Value: fmt.Sprintf("%d", generation)},
+	}
+	for l := 0; l < s.cfg.Cardinality.ExtraLabels; l++ {
+		labels = append(labels, prompb.Label{
+			Name:  fmt.Sprintf("label_%d", l),
+			Value: fmt.Sprintf("value_%d_%d", l, idx),
+		})
+	}
+	return prompb.TimeSeries{
+		Labels: labels,
+		Samples: []prompb.Sample{
+			{Value: s.values[idx], Timestamp: now.UnixMilli()},
+		},
+	}
+}
+
+// RunLoad writes samples at the configured rate until cfg.Duration elapses or ctx is done.
+// Requests are sent concurrently by cfg.Workers workers. If workers can't keep up,
+// the achieved rate reported in LoadResult is lower than the target.
+func RunLoad(ctx context.Context, cfg LoadConfig) (LoadResult, error) {
+	if err := cfg.validate(); err != nil {
+		return LoadResult{}, err
+	}
+
+	// In-flight requests use the parent ctx, so they are not aborted when the run ends.
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	stats := &WriteStats{}
+	opts := cfg.Options
+	opts.Stats = stats
+
+	set := newSeriesSet(cfg)
+	batches := make(chan []prompb.TimeSeries, cfg.Workers)
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		result    = LoadResult{StatusErrors: map[int]int64{}}
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ts := range batches {
+				start := time.Now()
+				err := RemoteWriteContext(ctx, cfg.Client, ts, cfg.URL, opts)
+				latency := time.Since(start)
+
+				mu.Lock()
+				latencies = append(latencies, latency)
+				result.Requests++
+				if err != nil {
+					result.FailedRequests++
+					var we *WriteError
+					if errors.As(err, &we) {
+						result.StatusErrors[we.StatusCode]++
+					} else {
+						result.TransportErrors++
+					}
+				} else {
+					result.SamplesSent += int64(len(ts))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(cfg.batchInterval())
+	defer ticker.Stop()
+
+	var churnC <-chan time.Time
+	if cfg.ChurnInterval > 0 && cfg.ChurnRate > 0 {
+		churnTicker := time.NewTicker(cfg.ChurnInterval)
+		defer churnTicker.Stop()
+		churnC = churnTicker.C
+	}
+
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-runCtx.Done():
+			break loop
+		case <-churnC:
+			set.churn()
+		case now := <-ticker.C:
+			select {
+			case batches <- set.nextBatch(now):
+			case <-runCtx.Done():
+				break loop
+			}
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	result.Elapsed = time.Since(start)
+	if secs := result.Elapsed.Seconds(); secs > 0 {
+		result.SamplesPerSecond = float64(result.SamplesSent) / secs
+	}
+	result.SeriesCreated = set.created
+	result.Retried = stats.Retried.Load()
+	result.Rejected = stats.Rejected.Load()
+	result.Dropped = stats.Dropped.Load()
+	result.LatencyP50 = percentile(latencies, 0.5)
+	result.LatencyP90 = percentile(latencies, 0.9)
+	result.LatencyP99 = percentile(latencies, 0.99)
+	result.LatencyMax = percentile(latencies, 1)
+	return result, nil
+}
+
+// percentile returns the q-th (0..1) percentile of durations using the nearest-rank method.
+func percentile(durations []time.Duration, q float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,136 @@
+package remotewrite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLoad(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := LoadConfig{
+		URL:              server.URL,
+		Client:           server.Client(),
+		MetricPrefix:     "load",
+		SamplesPerSecond: 2000,
+		Duration:         500 * time.Millisecond,
+		Workers:          2,
+		BatchSize:        20,
+		Cardinality:      CardinalityProfile{Metrics: 2, SeriesPerMetric: 10},
+		ChurnInterval:    100 * time.Millisecond,
+		ChurnRate:        0.5,
+	}
+	result, err := RunLoad(context.Background(), cfg)
+	require.NoError(t, err)
+
+	assert.Greater(t, result.Requests, int64(10))
+	assert.Equal(t, requests.Load(), result.Requests)
+	assert.Equal(t, result.Requests-result.FailedRequests, result.SamplesSent/int64(cfg.BatchSize))
+	assert.Equal(t, result.FailedRequests, result.StatusErrors[http.StatusServiceUnavailable])
+	assert.Equal(t, result.FailedRequests, result.Dropped)
+	assert.Greater(t, result.SeriesCreated, int64(cfg.Cardinality.Series()), "Churn should create new series")
+	assert.Greater(t, result.SamplesPerSecond, float64(0))
+	assert.LessOrEqual(t, result.LatencyP50, result.LatencyP99)
+	assert.LessOrEqual(t, result.LatencyP99, result.LatencyMax)
+}
+
+func TestRunLoad_InvalidConfig(t *testing.T) {
+	t.Parallel()
+	cfg := DefaultLoadConfig("", http.DefaultClient)
+	_, err := RunLoad(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no URL configured")
+
+	cfg = DefaultLoadConfig("http://localhost", http.DefaultClient)
+	cfg.ChurnRate = 2
+	_, err = RunLoad(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "churn rate")
+
+	cfg = DefaultLoadConfig("http://localhost", http.DefaultClient)
+	cfg.BatchSize = 1
+	cfg.SamplesPerSecond = 2_000_000_000
+	_, err = RunLoad(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "increase the batch size")
+}
+
+func TestSeriesSetChurn(t *testing.T) {
+	t.Parallel()
+	cfg := LoadConfig{
+		MetricPrefix: "m",
+		BatchSize:    4,
+		Cardinality:  CardinalityProfile{Metrics: 2, SeriesPerMetric: 2, ExtraLabels: 1},
+		ChurnRate:    0.5,
+	}
+	set := newSeriesSet(cfg)
+	now := time.Now()
+
+	keys := func(ts []prompb.TimeSeries) map[string]struct{} {
+		res := map[string]struct{}{}
+		for _, s := range ts {
+			res[prompb.LabelsToString(s.Labels)] = struct{}{}
+		}
+		return res
+	}
+
+	before := keys(set.nextBatch(now))
+	require.Len(t, before, 4)
+	assert.Len(t, set.nextBatch(now)[0].Labels, 4, "Expected name, series, generation and one extra label")
+
+	set.churn()
+	after := keys(set.nextBatch(now))
+
+	common := 0
+	for k := range after {
+		if _, ok := before[k]; ok {
+			common++
+		}
+	}
+	assert.Equal(t, 2, common, "Half of the series should be replaced")
+	assert.Equal(t, int64(6), set.created)
+
+	set.churn()
+	common = 0
+	for k := range keys(set.nextBatch(now)) {
+		if _, ok := before[k]; ok {
+			common++
+		}
+	}
+	assert.Equal(t, 0, common, "The second churn should replace the other half")
+	assert.Equal(t, int64(8), set.created)
+}
+
+func TestSeriesSetBatch(t *testing.T) {
+	t.Parallel()
+	cfg := LoadConfig{
+		MetricPrefix: "m",
+		BatchSize:    10,
+		Cardinality:  CardinalityProfile{Metrics: 1, SeriesPerMetric: 3},
+	}
+	set := newSeriesSet(cfg)
+	now := time.Now()
+
+	batch := set.nextBatch(now)
+	require.Len(t, batch, 3, "Batch should be capped at the number of series")
+	assert.Equal(t, 1.0, batch[0].Samples[0].Value)
+	batch = set.nextBatch(now.Add(time.Second))
+	assert.Equal(t, 2.0, batch[0].Samples[0].Value, "Series values should count written samples")
+}
@@ -0,0 +1,149 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// WriteError is returned when the remote write endpoint responds with a non-2xx status code.
+type WriteError struct {
+	// StatusCode is the HTTP status code returned by the server.
+	StatusCode int
+	// Body is the response body, usually containing the reason of the rejection.
+	Body string
+	// Retryable reports whether the request may succeed if sent again (429 and 5xx).
+	Retryable bool
+	// RetryAfter is the delay requested by the server via the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("remote write failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsRetryable reports whether err is a transport error or a retryable WriteError.
+// Requests interrupted because their context is done are not retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var we *WriteError
+	if errors.As(err, &we) {
+		return we.Retryable
+	}
+	// Transport errors (connection refused, resets, timeouts) are worth retrying.
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+func newWriteError(resp *http.Response, body []byte) *WriteError {
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	we := &WriteError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Retryable:  retryable,
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		we.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return we
+}
+
+// parseRetryAfter parses Retry-After header value, which is either delay in seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryPolicy configures how failed remote write requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff and delays requested via Retry-After,
+	// so a misbehaving server cannot stall the writer.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the backoff grows by after each attempt.
+	Multiplier float64
+}
+
+// DefaultRetryPolicy returns a retry policy suitable for writes during chaos scenarios.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+	}
+}
+
+// Backoff returns the delay before the given retry attempt (starting at 1).
+// A positive retryAfter requested by the server takes precedence, up to MaxBackoff.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if p.MaxBackoff > 0 && retryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return retryAfter
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	// Compare in float64 first: without MaxBackoff the product overflows time.Duration after enough attempts.
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	if backoff >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// WriteStats counts the outcome of remote write batches. It is safe for concurrent use.
+type WriteStats struct {
+	// Sent is the number of batches accepted by the server.
+	Sent atomic.Int64
+	// Retried is the number of retry attempts made.
+	Retried atomic.Int64
+	// Rejected is the number of batches rejected with a non-retryable error.
+	Rejected atomic.Int64
+	// Dropped is the number of batches given up after exhausting retries or because the context is done.
+	Dropped atomic.Int64
+}
+
+// String returns a human-readable summary of the stats.
+func (s *WriteStats) String() string {
+	return fmt.Sprintf("sent=%d retried=%d rejected=%d dropped=%d",
+		s.Sent.Load(), s.Retried.Load(), s.Rejected.Load(), s.Dropped.Load())
+}
@@ -0,0 +1,186 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	assert.Equal(t, 5*time.Second, parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("garbage", now))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+	p := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1, 0))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2, 0))
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(3, 0), "Backoff should be capped")
+	assert.Equal(t, 250*time.Millisecond, p.Backoff(1, 250*time.Millisecond), "Retry-After should take precedence")
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(1, time.Hour), "Retry-After should be capped")
+
+	p.MaxBackoff = 0
+	assert.Equal(t, time.Duration(math.MaxInt64), p.Backoff(200, 0), "Uncapped backoff should not overflow")
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsRetryable(&url.Error{Op: "Post", URL: "http://vm", Err: errors.New("connection refused")}))
+	assert.False(t, IsRetryable(&url.Error{Op: "Post", URL: "http://vm", Err: context.Canceled}))
+	assert.False(t, IsRetryable(&url.Error{Op: "Post", URL: "http://vm", Err: context.DeadlineExceeded}))
+	assert.False(t, IsRetryable(errors.New("cannot encode")))
+}
+
+func TestRemoteWriteContext_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name              string
+		status            int
+		expectedRetryable bool
+	}{
+		{name: "bad request", status: http.StatusBadRequest, expectedRetryable: false},
+		{name: "too many requests", status: http.StatusTooManyRequests, expectedRetryable: true},
+		{name: "service unavailable", status: http.StatusServiceUnavailable, expectedRetryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(tt.status)
+				_, _ = fmt.Fprint(w, "rejected")
+			}))
+			defer server.Close()
+
+			stats := &WriteStats{}
+			err := RemoteWriteContext(context.Background(), server.Client(), GenTimeSeries("m", 1, 1), server.URL, WriteOptions{Stats: stats})
+			require.Error(t, err)
+
+			var we *WriteError
+			require.True(t, errors.As(err, &we), "Expected WriteError, got %T", err)
+			assert.Equal(t, tt.status, we.StatusCode)
+			assert.Equal(t, "rejected", we.Body)
+			assert.Equal(t, tt.expectedRetryable, we.Retryable)
+			assert.Equal(t, tt.expectedRetryable, IsRetryable(err))
+			if tt.expectedRetryable {
+				assert.Equal(t, 7*time.Second, we.RetryAfter)
+				assert.Equal(t, int64(1), stats.Dropped.Load())
+			} else {
+				assert.Equal(t, time.Duration(0), we.RetryAfter)
+				assert.Equal(t, int64(1), stats.Rejected.Load())
+			}
+		})
+	}
+}
+
+func TestRemoteWriteContext_Retry(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	stats := &WriteStats{}
+	opts := WriteOptions{
+		Retry: &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, Multiplier: 2},
+		Stats: stats,
+	}
+	err := RemoteWriteContext(context.Background(), server.Client(), GenTimeSeries("m", 1, 1), server.URL, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int64(1), stats.Sent.Load())
+	assert.Equal(t, int64(2), stats.Retried.Load())
+	assert.Equal(t, int64(0), stats.Dropped.Load())
+}
+
+func TestRemoteWriteContext_RetriesExhausted(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	stats := &WriteStats{}
+	opts := WriteOptions{
+		Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		Stats: stats,
+	}
+	err := RemoteWriteContext(context.Background(), server.Client(), GenTimeSeries("m", 1, 1), server.URL, opts)
+	require.Error(t, err)
+
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int64(2), stats.Retried.Load())
+	assert.Equal(t, int64(1), stats.Dropped.Load())
+	assert.Equal(t, "sent=0 retried=2 rejected=0 dropped=1", stats.String())
+}
+
+func TestRemoteWriteContext_ContextCanceled(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stats := &WriteStats{}
+	policy := DefaultRetryPolicy()
+	err := RemoteWriteContext(ctx, server.Client(), GenTimeSeries("m", 1, 1), server.URL, WriteOptions{Retry: &policy, Stats: stats})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int64(1), stats.Dropped.Load())
+}
+
+func TestRemoteWriteContext_CanceledInFlight(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	stats := &WriteStats{}
+	policy := DefaultRetryPolicy()
+	err := RemoteWriteContext(ctx, server.Client(), GenTimeSeries("m", 1, 1), server.URL, WriteOptions{Retry: &policy, Stats: stats})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(0), stats.Rejected.Load(), "Canceled writes should not be counted as rejected")
+	assert.Equal(t, int64(1), stats.Dropped.Load())
+}
@@ -0,0 +1,31 @@
+package remotewrite
+
+import (
+	"math"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// StaleNaNBits is the bit pattern of the NaN value Prometheus uses as a staleness marker.
+// It differs from math.NaN(), which storage treats as a regular value.
+const StaleNaNBits uint64 = 0x7ff0000000000002
+
+// StaleNaN returns the staleness marker value.
+func StaleNaN() float64 {
+	return math.Float64frombits(StaleNaNBits)
+}
+
+// IsStaleNaN reports whether v is a staleness marker.
+func IsStaleNaN(v float64) bool {
+	return math.Float64bits(v) == StaleNaNBits
+}
+
+// AppendStaleMarkers ends every series with a staleness marker at the given time.
+// The marker is placed after existing samples, so it must be later than the last sample to take effect.
+func AppendStaleMarkers(ts []prompb.TimeSeries, at time.Time) []prompb.TimeSeries {
+	for i := range ts {
+		ts[i].Samples = append(ts[i].Samples, prompb.Sample{Value: StaleNaN(), Timestamp: at.UnixMilli()})
+	}
+	return ts
+}
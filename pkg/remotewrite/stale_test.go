@@ -0,0 +1,41 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleNaN(t *testing.T) {
+	t.Parallel()
+	assert.True(t, math.IsNaN(StaleNaN()))
+	assert.True(t, IsStaleNaN(StaleNaN()))
+	assert.False(t, IsStaleNaN(math.NaN()), "Regular NaN is not a staleness marker")
+	assert.False(t, IsStaleNaN(1))
+}
+
+func TestAppendStaleMarkers(t *testing.T) {
+	t.Parallel()
+	at := time.UnixMilli(5000)
+	ts := AppendStaleMarkers(GenTimeSeries("m", 2, 1), at)
+
+	data, err := snappy.Decode(nil, GenPayload(ts))
+	require.NoError(t, err)
+	wru := prompb.GetWriteRequestUnmarshaler()
+	defer prompb.PutWriteRequestUnmarshaler(wru)
+	wr, err := wru.UnmarshalProtobuf(data)
+	require.NoError(t, err)
+
+	require.Len(t, wr.Timeseries, 2)
+	for _, s := range wr.Timeseries {
+		require.Len(t, s.Samples, 2)
+		last := s.Samples[1]
+		assert.Equal(t, at.UnixMilli(), last.Timestamp)
+		assert.True(t, IsStaleNaN(last.Value), "Stale marker bit pattern must survive encoding")
+	}
+}
@@ -2,7 +2,10 @@ package remotewrite
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -11,6 +14,9 @@ import (
 	"github.com/klauspost/compress/snappy"
 )
 
+// maxErrorBodySize limits how much of an error response body is kept in WriteError.
+const maxErrorBodySize = 4096
+
 // GenTimeSeries generates a slice of Prometheus time series with generated labels and sample values.
 // The name_prefix is used to generate the metric name, and size determines the number of series.
 // All series will have the same sample value and current timestamp.
@@ -18,12 +24,7 @@ func GenTimeSeries(name_prefix string, size int, value float64) []prompb.TimeSer
 	ts := []prompb.TimeSeries{}
 	for i := 0; i < size; i++ {
 		ts = append(ts, prompb.TimeSeries{
-			Labels: []prompb.Label{
-				{Name: "__name__", Value: fmt.Sprintf(`%s_%d`, name_prefix, i)},
-				{Name: "foo", Value: fmt.Sprintf("fooVal_%d", i)},
-				{Name: "bar", Value: fmt.Sprintf("barVal_%d", i)},
-				{Name: "baz", Value: fmt.Sprintf("bazVal_%d", i)},
-			},
+			Labels: seriesLabels(name_prefix, i),
 			Samples: []prompb.Sample{
 				{Value: value, Timestamp: time.Now().UnixMilli()},
 			},
@@ -32,6 +33,16 @@ func GenTimeSeries(name_prefix string, size int, value float64) []prompb.TimeSer
 	return ts
 }
 
+// seriesLabels returns the labels of the i-th generated series.
+func seriesLabels(namePrefix string, i int) []prompb.Label {
+	return []prompb.Label{
+		{Name: "__name__", Value: fmt.Sprintf(`%s_%d`, namePrefix, i)},
+		{Name: "foo", Value: fmt.Sprintf("fooVal_%d", i)},
+		{Name: "bar", Value: fmt.Sprintf("barVal_%d", i)},
+		{Name: "baz", Value: fmt.Sprintf("bazVal_%d", i)},
+	}
+}
+
 // GenPayload marshals the time series into a WriteRequest protobuf and snappy encodes it.
 // This matches the format expected by the Prometheus remote write API.
 func GenPayload(timeseries []prompb.TimeSeries) []byte {
@@ -41,17 +52,164 @@ func GenPayload(timeseries []prompb.TimeSeries) []byte {
 	return snappy.Encode(nil, payload)
 }
 
+// Protocol is the version of the Prometheus remote write protocol used to encode payloads.
+type Protocol string
+
+const (
+	// ProtocolV1 is Prometheus Remote Write 1.0 (prometheus.WriteRequest).
+	ProtocolV1 Protocol = "0.1.0"
+	// ProtocolV2 is Prometheus Remote Write 2.0 (io.prometheus.write.v2.Request).
+	ProtocolV2 Protocol = "2.0.0"
+)
+
+// ContentType returns the Content-Type header value for the protocol.
+func (p Protocol) ContentType() string {
+	if p == ProtocolV2 {
+		return "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+	}
+	return "application/x-protobuf"
+}
+
+// WriteOptions configures how RemoteWriteWithOptions encodes and sends the payload.
+type WriteOptions struct {
+	// Protocol selects the payload encoding. Defaults to ProtocolV1.
+	Protocol Protocol
+	// Metadata is sent as WriteRequest metadata for RW 1.0 and attached to matching series for RW 2.0.
+	Metadata []prompb.MetricMetadata
+	// CreatedTimestamps maps metric names to created timestamps in milliseconds. Only used by RW 2.0.
+	CreatedTimestamps map[string]int64
+	// Exemplars maps series keys (see SeriesKey) to exemplars attached to the series.
+	Exemplars map[string][]Exemplar
+	// Retry configures retries of failed requests. If nil, the request is sent once.
+	Retry *RetryPolicy
+	// Stats, if set, is updated with the outcome of every request.
+	Stats *WriteStats
+	// Recorder, if set, saves every payload before it is sent, so it can be replayed later.
+	Recorder *Recorder
+	// Headers are added to every request and override the default ones, e.g. User-Agent.
+	// Use them for Authorization and tenant headers.
+	Headers http.Header
+}
+
+func (o WriteOptions) protocol() Protocol {
+	if o.Protocol == "" {
+		return ProtocolV1
+	}
+	return o.Protocol
+}
+
+// GenPayloadWithOptions encodes the time series according to the protocol selected in opts.
+func GenPayloadWithOptions(timeseries []prompb.TimeSeries, opts WriteOptions) []byte {
+	if opts.protocol() == ProtocolV2 {
+		return genPayloadV2(timeseries, opts.Metadata, opts.CreatedTimestamps, opts.Exemplars)
+	}
+	if len(opts.Exemplars) > 0 {
+		return genPayloadV1WithExemplars(timeseries, opts.Metadata, opts.Exemplars)
+	}
+	r := &prompb.WriteRequest{
+		Timeseries: timeseries,
+		Metadata:   opts.Metadata,
+	}
+	return snappy.Encode(nil, r.MarshalProtobuf(nil))
+}
+
 // RemoteWrite sends the time series to the specified remote write URL using the provided HTTP client.
 // It constructs the payload using GenPayload and sets the appropriate headers.
 func RemoteWrite(c *http.Client, ts []prompb.TimeSeries, url string) error {
-	payload := GenPayload(ts)
-	req, _ := http.NewRequest("POST", url, bytes.NewReader(payload))
-	req.Header.Set("Content-Type", "application/x-protobuf")
+	return RemoteWriteWithOptions(c, ts, url, WriteOptions{})
+}
+
+// RemoteWriteWithOptions sends the time series to the specified remote write URL
+// using the protocol and metadata configured in opts.
+func RemoteWriteWithOptions(c *http.Client, ts []prompb.TimeSeries, url string, opts WriteOptions) error {
+	return RemoteWriteContext(context.Background(), c, ts, url, opts)
+}
+
+// RemoteWriteContext sends the time series like RemoteWriteWithOptions, retrying failed requests
+// according to opts.Retry until ctx is done.
+// A non-2xx response is returned as *WriteError.
+func RemoteWriteContext(ctx context.Context, c *http.Client, ts []prompb.TimeSeries, url string, opts WriteOptions) error {
+	payload := GenPayloadWithOptions(ts, opts)
+	if opts.Recorder != nil {
+		rec := CaptureRecord{Time: time.Now(), URL: url, Protocol: opts.protocol(), Headers: opts.Headers, Payload: payload}
+		if err := opts.Recorder.Record(rec); err != nil {
+			return fmt.Errorf("cannot record remote write payload: %w", err)
+		}
+	}
+
+	policy := RetryPolicy{MaxAttempts: 1}
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = sendPayload(ctx, c, payload, url, opts.protocol(), opts.Headers)
+		if err == nil {
+			if opts.Stats != nil {
+				opts.Stats.Sent.Add(1)
+			}
+			return nil
+		}
+		if !IsRetryable(err) {
+			if opts.Stats != nil {
+				// A request interrupted by ctx was not refused by the server.
+				if ctx.Err() != nil {
+					opts.Stats.Dropped.Add(1)
+				} else {
+					opts.Stats.Rejected.Add(1)
+				}
+			}
+			return err
+		}
+		if attempt >= policy.attempts() {
+			break
+		}
+
+		var retryAfter time.Duration
+		var we *WriteError
+		if errors.As(err, &we) {
+			retryAfter = we.RetryAfter
+		}
+		backoff := policy.Backoff(attempt, retryAfter)
+		log.Printf("remote write attempt %d to %s failed, retrying in %s: %v", attempt, url, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			if opts.Stats != nil {
+				opts.Stats.Dropped.Add(1)
+			}
+			return fmt.Errorf("remote write to %s aborted: %w (last error: %v)", url, ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		if opts.Stats != nil {
+			opts.Stats.Retried.Add(1)
+		}
+	}
+
+	if opts.Stats != nil {
+		opts.Stats.Dropped.Add(1)
+	}
+	return err
+}
+
+func sendPayload(ctx context.Context, c *http.Client, payload []byte, url string, protocol Protocol, headers http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create remote write request: %w", err)
+	}
+	req.Header.Set("Content-Type", protocol.ContentType())
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("User-Agent", "aUserAgent")
 	req.Header.Set("Content-Encoding", "snappy")
-	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", string(protocol))
 	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(payload)))
+	for name, values := range headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
 
 	resp, err := c.Do(req)
 	if err != nil {
@@ -60,8 +218,10 @@ func RemoteWrite(c *http.Client, ts []prompb.TimeSeries, url string) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 204 {
-		log.Println("http: do: ", resp.Status)
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return newWriteError(resp, body)
 	}
+	_, _ = io.Copy(io.Discard, resp.Body)
 	return nil
 }
@@ -0,0 +1,84 @@
+package remotewrite
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenTimeSeries(t *testing.T) {
+	t.Parallel()
+	ts := GenTimeSeries("metric", 3, 42)
+
+	require.Len(t, ts, 3)
+	for i, series := range ts {
+		assert.Equal(t, prompb.Label{Name: "__name__", Value: fmt.Sprintf("metric_%d", i)}, series.Labels[0])
+		require.Len(t, series.Samples, 1)
+		assert.Equal(t, float64(42), series.Samples[0].Value)
+	}
+}
+
+func TestRemoteWriteWithOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		opts            WriteOptions
+		expectedType    string
+		expectedVersion string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:            "default protocol",
+			opts:            WriteOptions{},
+			expectedType:    "application/x-protobuf",
+			expectedVersion: "0.1.0",
+		},
+		{
+			name:            "remote write 2.0",
+			opts:            WriteOptions{Protocol: ProtocolV2},
+			expectedType:    "application/x-protobuf;proto=io.prometheus.write.v2.Request",
+			expectedVersion: "2.0.0",
+		},
+		{
+			name: "custom headers",
+			opts: WriteOptions{Headers: http.Header{
+				"Authorization": {"Bearer secret"},
+				"User-Agent":    {"e2e-tests"},
+			}},
+			expectedType:    "application/x-protobuf",
+			expectedVersion: "0.1.0",
+			expectedHeaders: map[string]string{"Authorization": "Bearer secret", "User-Agent": "e2e-tests"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ts := GenTimeSeries("metric", 2, 1)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedType, r.Header.Get("Content-Type"))
+				assert.Equal(t, tt.expectedVersion, r.Header.Get("X-Prometheus-Remote-Write-Version"))
+				assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+				for name, value := range tt.expectedHeaders {
+					assert.Equal(t, value, r.Header.Get(name))
+				}
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, GenPayloadWithOptions(ts, tt.opts), body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			err := RemoteWriteWithOptions(server.Client(), ts, server.URL, tt.opts)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package remotewrite
+
+import (
+	"math"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the io.prometheus.write.v2.Request message and its children.
+// See https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/#protobuf-message
+const (
+	v2RequestSymbols    = 4
+	v2RequestTimeseries = 5
+
+	v2SeriesLabelsRefs       = 1
+	v2SeriesSamples          = 2
+	v2SeriesExemplars        = 4
+	v2SeriesMetadata         = 5
+	v2SeriesCreatedTimestamp = 6
+
+	v2SampleValue     = 1
+	v2SampleTimestamp = 2
+
+	v2ExemplarLabelsRefs = 1
+	v2ExemplarValue      = 2
+	v2ExemplarTimestamp  = 3
+
+	v2MetadataType    = 1
+	v2MetadataHelpRef = 3
+	v2MetadataUnitRef = 4
+)
+
+// symbolTable deduplicates strings referenced by a RW 2.0 request.
+// The first symbol is always an empty string, as required by the spec.
+type symbolTable struct {
+	symbols []string
+	refs    map[string]uint32
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{
+		symbols: []string{""},
+		refs:    map[string]uint32{"": 0},
+	}
+}
+
+func (st *symbolTable) ref(s string) uint32 {
+	if ref, ok := st.refs[s]; ok {
+		return ref
+	}
+	ref := uint32(len(st.symbols))
+	st.symbols = append(st.symbols, s)
+	st.refs[s] = ref
+	return ref
+}
+
+// GenPayloadV2 marshals the time series into a Remote Write 2.0 Request protobuf and snappy encodes it.
+// Metadata is attached to every series whose __name__ matches MetricFamilyName
+// (histogram and summary series are matched without the _bucket, _sum and _count suffixes),
+// and createdTimestamps (metric name -> unix milliseconds) sets the created timestamp for matching series.
+func GenPayloadV2(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata, createdTimestamps map[string]int64) []byte {
+	return genPayloadV2(timeseries, metadata, createdTimestamps, nil)
+}
+
+func genPayloadV2(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata, createdTimestamps map[string]int64, exemplars map[string][]Exemplar) []byte {
+	mdByName := make(map[string]prompb.MetricMetadata, len(metadata))
+	for _, md := range metadata {
+		mdByName[md.MetricFamilyName] = md
+	}
+
+	st := newSymbolTable()
+	var series []byte
+	for _, ts := range timeseries {
+		name := metricName(ts.Labels)
+		md, hasMetadata := mdByName[name]
+		if !hasMetadata {
+			md, hasMetadata = mdByName[familyName(name)]
+		}
+		series = protowire.AppendTag(series, v2RequestTimeseries, protowire.BytesType)
+		series = protowire.AppendBytes(series, marshalSeriesV2(st, ts, md, hasMetadata, createdTimestamps[name], exemplars[SeriesKey(ts.Labels)]))
+	}
+
+	var payload []byte
+	for _, s := range st.symbols {
+		payload = protowire.AppendTag(payload, v2RequestSymbols, protowire.BytesType)
+		payload = protowire.AppendString(payload, s)
+	}
+	payload = append(payload, series...)
+	return snappy.Encode(nil, payload)
+}
+
+func marshalSeriesV2(st *symbolTable, ts prompb.TimeSeries, md prompb.MetricMetadata, hasMetadata bool, createdTimestamp int64, exemplars []Exemplar) []byte {
+	var refs []byte
+	for _, l := range ts.Labels {
+		refs = protowire.AppendVarint(refs, uint64(st.ref(l.Name)))
+		refs = protowire.AppendVarint(refs, uint64(st.ref(l.Value)))
+	}
+
+	var dst []byte
+	dst = protowire.AppendTag(dst, v2SeriesLabelsRefs, protowire.BytesType)
+	dst = protowire.AppendBytes(dst, refs)
+
+	for _, s := range ts.Samples {
+		var sample []byte
+		sample = protowire.AppendTag(sample, v2SampleValue, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+		sample = protowire.AppendTag(sample, v2SampleTimestamp, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.Timestamp))
+
+		dst = protowire.AppendTag(dst, v2SeriesSamples, protowire.BytesType)
+		dst = protowire.AppendBytes(dst, sample)
+	}
+
+	for _, e := range exemplars {
+		var refs []byte
+		for _, l := range e.Labels {
+			refs = protowire.AppendVarint(refs, uint64(st.ref(l.Name)))
+			refs = protowire.AppendVarint(refs, uint64(st.ref(l.Value)))
+		}
+		var ex []byte
+		ex = protowire.AppendTag(ex, v2ExemplarLabelsRefs, protowire.BytesType)
+		ex = protowire.AppendBytes(ex, refs)
+		ex = protowire.AppendTag(ex, v2ExemplarValue, protowire.Fixed64Type)
+		ex = protowire.AppendFixed64(ex, math.Float64bits(e.Value))
+		ex = protowire.AppendTag(ex, v2ExemplarTimestamp, protowire.VarintType)
+		ex = protowire.AppendVarint(ex, uint64(e.Timestamp))
+
+		dst = protowire.AppendTag(dst, v2SeriesExemplars, protowire.BytesType)
+		dst = protowire.AppendBytes(dst, ex)
+	}
+
+	if hasMetadata {
+		var meta []byte
+		meta = protowire.AppendTag(meta, v2MetadataType, protowire.VarintType)
+		meta = protowire.AppendVarint(meta, uint64(md.Type))
+		meta = protowire.AppendTag(meta, v2MetadataHelpRef, protowire.VarintType)
+		meta = protowire.AppendVarint(meta, uint64(st.ref(md.Help)))
+		meta = protowire.AppendTag(meta, v2MetadataUnitRef, protowire.VarintType)
+		meta = protowire.AppendVarint(meta, uint64(st.ref(md.Unit)))
+
+		dst = protowire.AppendTag(dst, v2SeriesMetadata, protowire.BytesType)
+		dst = protowire.AppendBytes(dst, meta)
+	}
+
+	if createdTimestamp != 0 {
+		dst = protowire.AppendTag(dst, v2SeriesCreatedTimestamp, protowire.VarintType)
+		dst = protowire.AppendVarint(dst, uint64(createdTimestamp))
+	}
+	return dst
+}
+
+func metricName(labels []prompb.Label) string {
+	for _, l := range labels {
+		if l.Name == "__name__" {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// familyName strips histogram and summary suffixes from the metric name.
+func familyName(name string) string {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			return trimmed
+		}
+	}
+	return name
+}
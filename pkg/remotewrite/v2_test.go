@@ -0,0 +1,180 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type decodedSeriesV2 struct {
+	labels           map[string]string
+	samples          []prompb.Sample
+	metadataType     uint64
+	help             string
+	unit             string
+	createdTimestamp int64
+	exemplars        []float64
+}
+
+// decodeV2 is a minimal RW 2.0 decoder used to verify GenPayloadV2 output.
+func decodeV2(t *testing.T, payload []byte) ([]string, []decodedSeriesV2) {
+	t.Helper()
+	data, err := snappy.Decode(nil, payload)
+	require.NoError(t, err)
+
+	var symbols []string
+	var rawSeries [][]byte
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		require.GreaterOrEqual(t, n, 0)
+		data = data[n:]
+		require.Equal(t, protowire.BytesType, typ)
+		v, n := protowire.ConsumeBytes(data)
+		require.GreaterOrEqual(t, n, 0)
+		data = data[n:]
+		switch num {
+		case v2RequestSymbols:
+			symbols = append(symbols, string(v))
+		case v2RequestTimeseries:
+			rawSeries = append(rawSeries, v)
+		}
+	}
+
+	var series []decodedSeriesV2
+	for _, raw := range rawSeries {
+		s := decodedSeriesV2{labels: map[string]string{}}
+		for len(raw) > 0 {
+			num, typ, n := protowire.ConsumeTag(raw)
+			require.GreaterOrEqual(t, n, 0)
+			raw = raw[n:]
+			switch num {
+			case v2SeriesLabelsRefs:
+				v, n := protowire.ConsumeBytes(raw)
+				raw = raw[n:]
+				var refs []uint64
+				for len(v) > 0 {
+					ref, n := protowire.ConsumeVarint(v)
+					v = v[n:]
+					refs = append(refs, ref)
+				}
+				require.Zero(t, len(refs)%2)
+				for i := 0; i < len(refs); i += 2 {
+					s.labels[symbols[refs[i]]] = symbols[refs[i+1]]
+				}
+			case v2SeriesSamples:
+				v, n := protowire.ConsumeBytes(raw)
+				raw = raw[n:]
+				var sample prompb.Sample
+				for len(v) > 0 {
+					fnum, _, n := protowire.ConsumeTag(v)
+					v = v[n:]
+					if fnum == v2SampleValue {
+						bits, n := protowire.ConsumeFixed64(v)
+						v = v[n:]
+						sample.Value = math.Float64frombits(bits)
+					} else {
+						ts, n := protowire.ConsumeVarint(v)
+						v = v[n:]
+						sample.Timestamp = int64(ts)
+					}
+				}
+				s.samples = append(s.samples, sample)
+			case v2SeriesMetadata:
+				v, n := protowire.ConsumeBytes(raw)
+				raw = raw[n:]
+				for len(v) > 0 {
+					fnum, _, n := protowire.ConsumeTag(v)
+					v = v[n:]
+					val, n := protowire.ConsumeVarint(v)
+					v = v[n:]
+					switch fnum {
+					case v2MetadataType:
+						s.metadataType = val
+					case v2MetadataHelpRef:
+						s.help = symbols[val]
+					case v2MetadataUnitRef:
+						s.unit = symbols[val]
+					}
+				}
+			case v2SeriesExemplars:
+				v, n := protowire.ConsumeBytes(raw)
+				raw = raw[n:]
+				for len(v) > 0 {
+					fnum, ftyp, n := protowire.ConsumeTag(v)
+					v = v[n:]
+					if fnum == v2ExemplarValue {
+						bits, _ := protowire.ConsumeFixed64(v)
+						s.exemplars = append(s.exemplars, math.Float64frombits(bits))
+					}
+					v = v[protowire.ConsumeFieldValue(fnum, ftyp, v):]
+				}
+			case v2SeriesCreatedTimestamp:
+				v, n := protowire.ConsumeVarint(raw)
+				raw = raw[n:]
+				s.createdTimestamp = int64(v)
+			default:
+				n := protowire.ConsumeFieldValue(num, typ, raw)
+				raw = raw[n:]
+			}
+		}
+		series = append(series, s)
+	}
+	return symbols, series
+}
+
+func TestGenPayloadV2(t *testing.T) {
+	t.Parallel()
+	ts := []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "requests_total"},
+				{Name: "job", Value: "api"},
+			},
+			Samples: []prompb.Sample{
+				{Value: 1, Timestamp: 1000},
+				{Value: 2.5, Timestamp: 2000},
+			},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "temperature"},
+				{Name: "job", Value: "api"},
+			},
+			Samples: []prompb.Sample{
+				{Value: -3, Timestamp: 1000},
+			},
+		},
+	}
+	metadata := []prompb.MetricMetadata{
+		{MetricFamilyName: "requests_total", Type: prompb.MetricTypeCounter, Help: "Total requests", Unit: "requests"},
+	}
+	created := map[string]int64{"requests_total": 500}
+
+	symbols, series := decodeV2(t, GenPayloadV2(ts, metadata, created))
+
+	require.NotEmpty(t, symbols)
+	assert.Equal(t, "", symbols[0], "First symbol must be empty string")
+	seen := map[string]bool{}
+	for _, s := range symbols {
+		assert.False(t, seen[s], "Symbol %q is duplicated", s)
+		seen[s] = true
+	}
+
+	require.Len(t, series, 2)
+	assert.Equal(t, map[string]string{"__name__": "requests_total", "job": "api"}, series[0].labels)
+	assert.Equal(t, ts[0].Samples, series[0].samples)
+	assert.Equal(t, uint64(prompb.MetricTypeCounter), series[0].metadataType)
+	assert.Equal(t, "Total requests", series[0].help)
+	assert.Equal(t, "requests", series[0].unit)
+	assert.Equal(t, int64(500), series[0].createdTimestamp)
+
+	assert.Equal(t, map[string]string{"__name__": "temperature", "job": "api"}, series[1].labels)
+	assert.Equal(t, ts[1].Samples, series[1].samples)
+	assert.Zero(t, series[1].metadataType)
+	assert.Zero(t, series[1].createdTimestamp)
+}
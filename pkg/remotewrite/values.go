@@ -0,0 +1,220 @@
+package remotewrite
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+)
+
+// ValueGenerator produces sample values for the given millisecond timestamps.
+// Implementations must be deterministic, so expected query results can be computed locally.
+type ValueGenerator interface {
+	Values(timestamps []int64) []float64
+}
+
+// Constant generates the same value for every sample.
+type Constant float64
+
+// Values implements ValueGenerator.
+func (c Constant) Values(timestamps []int64) []float64 {
+	values := make([]float64, len(timestamps))
+	for i := range values {
+		values[i] = float64(c)
+	}
+	return values
+}
+
+// Counter generates a monotonically increasing counter.
+type Counter struct {
+	// Start is the value of the first sample and of the first sample after every reset.
+	Start float64
+	// Increment is added on every sample.
+	Increment float64
+	// ResetEvery resets the counter to Start every ResetEvery samples. Zero disables resets.
+	ResetEvery int
+}
+
+// Values implements ValueGenerator.
+func (c Counter) Values(timestamps []int64) []float64 {
+	values := make([]float64, len(timestamps))
+	for i := range values {
+		k := i
+		if c.ResetEvery > 0 {
+			k = i % c.ResetEvery
+		}
+		values[i] = c.Start + c.Increment*float64(k)
+	}
+	return values
+}
+
+// Sine generates a gauge shaped as a sine wave of the sample timestamp.
+type Sine struct {
+	Amplitude float64
+	Offset    float64
+	Period    time.Duration
+}
+
+// Values implements ValueGenerator.
+func (s Sine) Values(timestamps []int64) []float64 {
+	values := make([]float64, len(timestamps))
+	for i, ts := range timestamps {
+		if s.Period <= 0 {
+			values[i] = s.Offset
+			continue
+		}
+		phase := 2 * math.Pi * float64(ts) / float64(s.Period.Milliseconds())
+		values[i] = s.Offset + s.Amplitude*math.Sin(phase)
+	}
+	return values
+}
+
+// RandomWalk generates a gauge which changes by a random amount in [-MaxStep, MaxStep] on every sample.
+// The same Seed always produces the same values.
+type RandomWalk struct {
+	Start   float64
+	MaxStep float64
+	Seed    uint64
+}
+
+// Values implements ValueGenerator.
+func (w RandomWalk) Values(timestamps []int64) []float64 {
+	rnd := rand.New(rand.NewPCG(w.Seed, w.Seed))
+	values := make([]float64, len(timestamps))
+	v := w.Start
+	for i := range values {
+		if i > 0 {
+			v += (rnd.Float64()*2 - 1) * w.MaxStep
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// Step generates a step function cycling through Levels, switching every Every samples.
+type Step struct {
+	Levels []float64
+	Every  int
+}
+
+// Values implements ValueGenerator.
+func (s Step) Values(timestamps []int64) []float64 {
+	values := make([]float64, len(timestamps))
+	if len(s.Levels) == 0 {
+		return values
+	}
+	every := s.Every
+	if every <= 0 {
+		every = 1
+	}
+	for i := range values {
+		values[i] = s.Levels[(i/every)%len(s.Levels)]
+	}
+	return values
+}
+
+// GenSamples generates samples for the timestamps using gen.
+func GenSamples(gen ValueGenerator, timestamps []int64) []prompb.Sample {
+	values := gen.Values(timestamps)
+	samples := make([]prompb.Sample, len(timestamps))
+	for i, ts := range timestamps {
+		samples[i] = prompb.Sample{Value: values[i], Timestamp: ts}
+	}
+	return samples
+}
+
+// Rollups holds expected results of MetricsQL rollup functions over a window.
+type Rollups struct {
+	Increase    float64
+	Rate        float64
+	AvgOverTime float64
+}
+
+// ExpectedRollups computes increase, rate and avg_over_time for the window (end-window, end]
+// the way VictoriaMetrics does:
+//   - counter resets are corrected, but a drop by less than 1/8 of the previous value is treated
+//     as jitter of a counter scraped from several replicas and adds nothing;
+//   - the last sample before the window is the base for increase and rate;
+//   - without such a sample, rate uses the first sample in the window as the base, while increase
+//     assumes the series started from 0 unless the first value is large compared to the next delta,
+//     in which case the first sample is the base.
+//
+// The last sample before the window is always used as the base. VictoriaMetrics ignores it if it is
+// older than its lookbehind window, so the samples should be regularly spaced.
+// Samples must be sorted by timestamp. Values are NaN if the window has no samples.
+func ExpectedRollups(samples []prompb.Sample, end time.Time, window time.Duration) Rollups {
+	nan := math.NaN()
+	res := Rollups{Increase: nan, Rate: nan, AvgOverTime: nan}
+
+	endMs := end.UnixMilli()
+	startMs := end.Add(-window).UnixMilli()
+
+	var (
+		prev     *prompb.Sample
+		next     *prompb.Sample
+		inWindow []prompb.Sample
+	)
+	for i := range samples {
+		s := samples[i]
+		switch {
+		case s.Timestamp <= startMs:
+			prev = &samples[i]
+		case s.Timestamp <= endMs:
+			inWindow = append(inWindow, s)
+		case next == nil:
+			next = &samples[i]
+		}
+	}
+	if len(inWindow) == 0 {
+		return res
+	}
+
+	var sum float64
+	for _, s := range inWindow {
+		sum += s.Value
+	}
+	res.AvgOverTime = sum / float64(len(inWindow))
+
+	first, last := inWindow[0], inWindow[len(inWindow)-1]
+	if prev != nil {
+		res.Increase = counterIncrease(*prev, inWindow)
+		res.Rate = res.Increase / (float64(last.Timestamp-prev.Timestamp) / 1e3)
+		return res
+	}
+
+	res.Increase = counterIncrease(first, inWindow[1:])
+	if len(inWindow) > 1 {
+		res.Rate = res.Increase / (float64(last.Timestamp-first.Timestamp) / 1e3)
+	}
+	var d float64
+	if len(inWindow) > 1 {
+		d = inWindow[1].Value - first.Value
+	} else if next != nil {
+		d = next.Value - first.Value
+	}
+	if math.Abs(first.Value) < 10*(math.Abs(d)+1) {
+		// The series is assumed to start from 0 right before the first sample.
+		res.Increase += first.Value
+	}
+	return res
+}
+
+// counterIncrease sums deltas between consecutive samples starting at base, correcting counter resets.
+func counterIncrease(base prompb.Sample, samples []prompb.Sample) float64 {
+	var increase float64
+	last := base.Value
+	for _, s := range samples {
+		d := s.Value - last
+		switch {
+		case d >= 0:
+			increase += d
+		case -d*8 < last:
+			// Partial reset, the corrected counter stays at the previous value.
+		default:
+			increase += s.Value
+		}
+		last = s.Value
+	}
+	return increase
+}
@@ -0,0 +1,90 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueGenerators(t *testing.T) {
+	t.Parallel()
+	timestamps := []int64{0, 1000, 2000, 3000, 4000, 5000}
+
+	assert.Equal(t, []float64{3, 3, 3, 3, 3, 3}, Constant(3).Values(timestamps))
+	assert.Equal(t, []float64{0, 2, 4, 0, 2, 4}, Counter{Increment: 2, ResetEvery: 3}.Values(timestamps))
+	assert.Equal(t, []float64{1, 1, 2, 2, 1, 1}, Step{Levels: []float64{1, 2}, Every: 2}.Values(timestamps))
+
+	sine := Sine{Amplitude: 10, Offset: 5, Period: 4 * time.Second}.Values(timestamps)
+	for i, expected := range []float64{5, 15, 5, -5, 5, 15} {
+		assert.InDelta(t, expected, sine[i], 1e-9)
+	}
+
+	walk := RandomWalk{Start: 100, MaxStep: 1, Seed: 42}
+	values := walk.Values(timestamps)
+	assert.Equal(t, values, walk.Values(timestamps), "Random walk must be deterministic")
+	assert.Equal(t, float64(100), values[0])
+	for i := 1; i < len(values); i++ {
+		assert.LessOrEqual(t, math.Abs(values[i]-values[i-1]), float64(1))
+	}
+}
+
+func TestExpectedRollups(t *testing.T) {
+	t.Parallel()
+	// Samples every 10s: 0, 10, 20, 5 (reset), 15, 25
+	samples := GenSamples(Counter{Increment: 10, ResetEvery: 3}, []int64{0, 10000, 20000, 30000, 40000, 50000})
+	samples[3].Value = 5
+	samples[4].Value = 15
+	samples[5].Value = 25
+
+	t.Run("with previous sample", func(t *testing.T) {
+		t.Parallel()
+		// Window (10s, 50s]: base is sample at 10s
+		r := ExpectedRollups(samples, time.UnixMilli(50000), 40*time.Second)
+		// 10 -> 20 (+10), 20 -> 5 (reset, +5), 5 -> 15 (+10), 15 -> 25 (+10)
+		assert.Equal(t, float64(35), r.Increase)
+		assert.Equal(t, float64(35)/40, r.Rate)
+		assert.Equal(t, float64(20+5+15+25)/4, r.AvgOverTime)
+	})
+
+	t.Run("without previous sample", func(t *testing.T) {
+		t.Parallel()
+		r := ExpectedRollups(samples, time.UnixMilli(20000), time.Minute)
+		assert.Equal(t, float64(20), r.Increase)
+		assert.Equal(t, float64(1), r.Rate)
+		assert.Equal(t, float64(10), r.AvgOverTime)
+	})
+
+	t.Run("partial reset", func(t *testing.T) {
+		t.Parallel()
+		// 100 -> 95 is jitter, 95 -> 105 adds 10 on top of the corrected 100.
+		jitter := []prompb.Sample{{Value: 100, Timestamp: 0}, {Value: 95, Timestamp: 10000}, {Value: 105, Timestamp: 20000}}
+		r := ExpectedRollups(jitter, time.UnixMilli(20000), 20*time.Second)
+		assert.Equal(t, float64(10), r.Increase)
+		assert.Equal(t, float64(10)/20, r.Rate)
+	})
+
+	t.Run("large first value without previous sample", func(t *testing.T) {
+		t.Parallel()
+		// 1000 is large compared to the next delta, so it is the base instead of 0.
+		large := []prompb.Sample{{Value: 1000, Timestamp: 10000}, {Value: 1010, Timestamp: 20000}, {Value: 1030, Timestamp: 30000}}
+		r := ExpectedRollups(large, time.UnixMilli(30000), time.Minute)
+		assert.Equal(t, float64(30), r.Increase)
+		assert.Equal(t, float64(30)/20, r.Rate)
+
+		// A single sample in the window is compared with the delta to the next one.
+		r = ExpectedRollups(large, time.UnixMilli(10000), 5*time.Second)
+		assert.Equal(t, float64(0), r.Increase)
+		assert.True(t, math.IsNaN(r.Rate))
+	})
+
+	t.Run("empty window", func(t *testing.T) {
+		t.Parallel()
+		r := ExpectedRollups([]prompb.Sample{{Value: 1, Timestamp: 0}}, time.UnixMilli(100000), time.Second)
+		assert.True(t, math.IsNaN(r.Increase))
+		assert.True(t, math.IsNaN(r.Rate))
+		assert.True(t, math.IsNaN(r.AvgOverTime))
+	})
+}
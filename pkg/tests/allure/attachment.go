@@ -27,6 +27,8 @@ type MimeType string
 
 const (
 	MimeTypeGZIP MimeType = "application/gzip"
+	MimeTypeJSON MimeType = "application/json"
+	MimeTypeText MimeType = "text/plain"
 )
 
 const attachmentReportEntryName = "ATTACHMENT"
@@ -78,6 +80,10 @@ func resolveExtension(mimeType MimeType) string {
 	switch mimeType {
 	case MimeTypeGZIP:
 		return "tar.gz"
+	case MimeTypeJSON:
+		return "json"
+	case MimeTypeText:
+		return "txt"
 	default:
 		return ""
 	}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// authConfig holds authentication settings shared by RemoteWriteBuilder and PromClientBuilder.
+type authConfig struct {
+	headers   http.Header
+	tlsConfig *tls.Config
+	// err is a deferred error from loading certificates, returned when the client is built.
+	err error
+}
+
+func (a *authConfig) setHeader(name, value string) {
+	if a.headers == nil {
+		a.headers = make(http.Header)
+	}
+	a.headers.Set(name, value)
+}
+
+func (a *authConfig) setBasicAuth(username, password string) {
+	a.setHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
+func (a *authConfig) setBearerToken(token string) {
+	a.setHeader("Authorization", "Bearer "+token)
+}
+
+// setTenantHeaders sets AccountID and ProjectID headers used to select a tenant
+// instead of the tenant path in the URL.
+func (a *authConfig) setTenantHeaders(accountID, projectID int) {
+	a.setHeader("AccountID", strconv.Itoa(accountID))
+	a.setHeader("ProjectID", strconv.Itoa(projectID))
+}
+
+// setClientCertificate loads a client certificate for mTLS.
+// If caFile is not empty, the server certificate is verified against it.
+func (a *authConfig) setClientCertificate(certFile, keyFile, caFile string) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		a.err = fmt.Errorf("cannot load client certificate: %w", err)
+		return
+	}
+	cfg := a.tls()
+	cfg.Certificates = []tls.Certificate{cert}
+
+	if caFile == "" {
+		return
+	}
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		a.err = fmt.Errorf("cannot read CA file: %w", err)
+		return
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		a.err = fmt.Errorf("no certificates found in CA file %s", caFile)
+		return
+	}
+	cfg.RootCAs = pool
+}
+
+func (a *authConfig) tls() *tls.Config {
+	if a.tlsConfig == nil {
+		a.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return a.tlsConfig
+}
+
+// httpClient returns client configured with the TLS settings.
+// The original client is not modified.
+func (a *authConfig) httpClient(client *http.Client) *http.Client {
+	if a.tlsConfig == nil {
+		return client
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t, ok := client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+	transport.TLSClientConfig = a.tlsConfig
+
+	c := *client
+	c.Transport = transport
+	return &c
+}
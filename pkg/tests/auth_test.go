@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientCertificate writes a self-signed client certificate and key to dir.
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "e2e-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestRemoteWriteBuilderAuth(t *testing.T) {
+	t.Run("headers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "pass", password)
+			assert.Equal(t, "3", r.Header.Get("AccountID"))
+			assert.Equal(t, "7", r.Header.Get("ProjectID"))
+			assert.Equal(t, "e2e-tests", r.Header.Get("User-Agent"))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := NewRemoteWriteBuilder().
+			WithHTTPClient(server.Client()).
+			WithURL(server.URL).
+			WithBasicAuth("user", "pass").
+			WithTenantHeaders(3, 7).
+			WithHeader("User-Agent", "e2e-tests").
+			Send(context.Background(), NewTimeSeriesBuilder("auth").WithCount(1).Build())
+		require.NoError(t, err)
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NotEmpty(t, r.TLS.PeerCertificates)
+			assert.Equal(t, "e2e-client", r.TLS.PeerCertificates[0].Subject.CommonName)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+		server.StartTLS()
+		defer server.Close()
+
+		dir := t.TempDir()
+		certFile, keyFile := writeClientCertificate(t, dir)
+		caFile := filepath.Join(dir, "ca.crt")
+		require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+		shared := &tls.Config{MinVersion: tls.VersionTLS12}
+		err := NewRemoteWriteBuilder().
+			WithURL(server.URL).
+			WithTLSConfig(shared).
+			WithClientCertificate(certFile, keyFile, caFile).
+			Send(context.Background(), NewTimeSeriesBuilder("mtls").WithCount(1).Build())
+		require.NoError(t, err)
+		assert.Empty(t, shared.Certificates, "The caller's TLS config should not be modified")
+		assert.Nil(t, shared.RootCAs)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		err := NewRemoteWriteBuilder().
+			WithURL("https://example.com").
+			WithClientCertificate("missing.crt", "missing.key", "").
+			Send(context.Background(), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot load client certificate")
+	})
+}
+
+func TestPromClientBuilderAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "1", r.Header.Get("AccountID"))
+		assert.Equal(t, "0", r.Header.Get("ProjectID"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"1"]}]}}`)
+	}))
+	defer server.Close()
+
+	client := NewPromClientBuilder().
+		WithBaseURL(server.URL).
+		WithBearerToken("token").
+		WithTenantHeaders(1, 0).
+		MustBuild()
+	_, _, err := client.VectorScan(context.Background(), "up")
+	require.NoError(t, err)
+
+	_, err = NewPromClientBuilder().
+		WithBaseURL(server.URL).
+		WithClientCertificate("missing.crt", "missing.key", "").
+		build()
+	require.Error(t, err)
+}
@@ -2,9 +2,11 @@ package tests
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
@@ -18,6 +20,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/install"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/promquery"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
 )
@@ -80,6 +83,9 @@ func (b *ConfigMapBuilder) Apply(t terratesting.TestingT, kubeOpts *k8s.KubectlO
 // JSONPatchBuilder provides a fluent interface for building JSON patches.
 type JSONPatchBuilder struct {
 	operations []patchOperation
+	// extraArgs reports whether /spec/extraArgs was already initialized,
+	// so repeated extra args do not reset each other.
+	extraArgs bool
 }
 
 type patchOperation struct {
@@ -119,17 +125,40 @@ func (b *JSONPatchBuilder) WithVMSingleConfig(cfgMapName, extraArgKey, configFil
 	// Ensure parent paths exist before adding child entries to avoid
 	// "doc is missing path" errors when applying the JSON patch.
 	return b.
-		Add("/spec/extraArgs", map[string]string{}).
-		Add("/spec/extraArgs/"+extraArgKey, configPath).
+		WithExtraArg(extraArgKey, configPath).
 		Add("/spec/configMaps", []string{}).
 		Add("/spec/configMaps/-", cfgMapName)
 }
 
 // WithExtraArg adds an extra argument to the VMSingle configuration.
 func (b *JSONPatchBuilder) WithExtraArg(key, value string) *JSONPatchBuilder {
-	return b.
-		Add("/spec/extraArgs", map[string]string{}).
-		Add("/spec/extraArgs/"+key, value)
+	if !b.extraArgs {
+		b.Add("/spec/extraArgs", map[string]string{})
+		b.extraArgs = true
+	}
+	return b.Add("/spec/extraArgs/"+key, value)
+}
+
+// WithVMInsertExtraArg adds an extra argument to vminsert of a VMCluster.
+// The VMCluster manifest already defines vminsert extra args, so they are extended.
+func (b *JSONPatchBuilder) WithVMInsertExtraArg(key, value string) *JSONPatchBuilder {
+	return b.Add("/spec/vminsert/extraArgs/"+key, value)
+}
+
+// WithListeners enables plaintext ingestion listeners on VMSingle.
+func (b *JSONPatchBuilder) WithListeners(listeners ...install.Listener) *JSONPatchBuilder {
+	for _, l := range listeners {
+		b.WithExtraArg(l.Flag, l.Addr())
+	}
+	return b
+}
+
+// WithVMInsertListeners enables plaintext ingestion listeners on vminsert of a VMCluster.
+func (b *JSONPatchBuilder) WithVMInsertListeners(listeners ...install.Listener) *JSONPatchBuilder {
+	for _, l := range listeners {
+		b.WithVMInsertExtraArg(l.Flag, l.Addr())
+	}
+	return b
 }
 
 func (b *JSONPatchBuilder) build() (jsonpatch.Patch, error) {
@@ -156,6 +185,7 @@ type PromClientBuilder struct {
 	namespace string
 	startTime time.Time
 	timeout   time.Duration
+	auth      authConfig
 }
 
 // NewPromClientBuilder creates a new PromClientBuilder.
@@ -201,6 +231,44 @@ func (b *PromClientBuilder) ForVMSingle(namespace string) *PromClientBuilder {
 	return b
 }
 
+// WithBasicAuth sets basic authentication credentials for every query.
+func (b *PromClientBuilder) WithBasicAuth(username, password string) *PromClientBuilder {
+	b.auth.setBasicAuth(username, password)
+	return b
+}
+
+// WithBearerToken sets a bearer token for every query.
+func (b *PromClientBuilder) WithBearerToken(token string) *PromClientBuilder {
+	b.auth.setBearerToken(token)
+	return b
+}
+
+// WithHeader sets a custom header for every query.
+func (b *PromClientBuilder) WithHeader(name, value string) *PromClientBuilder {
+	b.auth.setHeader(name, value)
+	return b
+}
+
+// WithTenantHeaders selects the tenant with AccountID and ProjectID headers instead of the URL path.
+func (b *PromClientBuilder) WithTenantHeaders(accountID, projectID int) *PromClientBuilder {
+	b.auth.setTenantHeaders(accountID, projectID)
+	return b
+}
+
+// WithClientCertificate configures mTLS with the given client certificate and key.
+// If caFile is not empty, the server certificate is verified against it.
+func (b *PromClientBuilder) WithClientCertificate(certFile, keyFile, caFile string) *PromClientBuilder {
+	b.auth.setClientCertificate(certFile, keyFile, caFile)
+	return b
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS connections.
+// cfg is copied, so later options such as WithClientCertificate do not modify it.
+func (b *PromClientBuilder) WithTLSConfig(cfg *tls.Config) *PromClientBuilder {
+	b.auth.tlsConfig = cfg.Clone()
+	return b
+}
+
 func (b *PromClientBuilder) build() (promquery.PrometheusClient, error) {
 	url := b.baseURL
 	if url == "" && b.namespace != "" {
@@ -214,8 +282,14 @@ func (b *PromClientBuilder) build() (promquery.PrometheusClient, error) {
 	if url == "" {
 		return promquery.PrometheusClient{}, fmt.Errorf("no URL configured for Prometheus client")
 	}
+	if b.auth.err != nil {
+		return promquery.PrometheusClient{}, b.auth.err
+	}
 
-	client, err := promquery.NewPrometheusClient(url)
+	client, err := promquery.NewPrometheusClientWithOptions(url, promquery.ClientOptions{
+		Headers:   b.auth.headers,
+		TLSConfig: b.auth.tlsConfig,
+	})
 	if err != nil {
 		return promquery.PrometheusClient{}, err
 	}
@@ -238,11 +312,24 @@ func (b *PromClientBuilder) MustBuild() promquery.PrometheusClient {
 
 // TimeSeriesBuilder provides a fluent interface for building time series data.
 type TimeSeriesBuilder struct {
-	prefix     string
-	count      int
-	value      float64
-	labels     map[string]string
-	httpClient *http.Client
+	prefix         string
+	count          int
+	value          float64
+	labels         map[string]string
+	httpClient     *http.Client
+	timeRange      *remotewrite.TimeRange
+	irregularities remotewrite.Irregularities
+	valueGen       remotewrite.ValueGenerator
+	histogram      *remotewrite.Distribution
+	summary        []float64
+	exemplars      []exemplarSpec
+	metadata       *prompb.MetricMetadata
+	staleAt        *time.Time
+}
+
+type exemplarSpec struct {
+	value  float64
+	labels map[string]string
 }
 
 // NewTimeSeriesBuilder creates a new TimeSeriesBuilder.
@@ -286,9 +373,127 @@ func (b *TimeSeriesBuilder) WithHTTPClient(client *http.Client) *TimeSeriesBuild
 	return b
 }
 
+// WithTimeRange generates one sample per series for every step between start and end (inclusive)
+// instead of a single sample at the current time.
+func (b *TimeSeriesBuilder) WithTimeRange(start, end time.Time, step time.Duration) *TimeSeriesBuilder {
+	b.timeRange = &remotewrite.TimeRange{Start: start, End: end, Step: step}
+	return b
+}
+
+// WithBackfill generates samples for the past lookback period up to now with the given step.
+func (b *TimeSeriesBuilder) WithBackfill(lookback, step time.Duration) *TimeSeriesBuilder {
+	now := time.Now()
+	return b.WithTimeRange(now.Add(-lookback), now, step)
+}
+
+// WithOutOfOrder moves n samples per series to the end of the series, so they are sent out of order.
+func (b *TimeSeriesBuilder) WithOutOfOrder(n int) *TimeSeriesBuilder {
+	b.irregularities.OutOfOrder = n
+	return b
+}
+
+// WithDuplicates sends n samples per series twice with the same timestamp and value.
+func (b *TimeSeriesBuilder) WithDuplicates(n int) *TimeSeriesBuilder {
+	b.irregularities.Duplicates = n
+	return b
+}
+
+// WithFarFuture adds n samples per series with timestamps offset after the end of the time range.
+func (b *TimeSeriesBuilder) WithFarFuture(n int, offset time.Duration) *TimeSeriesBuilder {
+	b.irregularities.FarFuture = n
+	b.irregularities.FarFutureOffset = offset
+	return b
+}
+
+// WithValueGenerator sets a generator producing sample values instead of the constant set by WithValue.
+// It is most useful together with WithTimeRange or WithBackfill.
+func (b *TimeSeriesBuilder) WithValueGenerator(gen remotewrite.ValueGenerator) *TimeSeriesBuilder {
+	b.valueGen = gen
+	return b
+}
+
+// ExpectedRollups computes the expected increase, rate and avg_over_time results
+// for the window (end-window, end] over the samples generated by the builder.
+// Irregular samples are not taken into account.
+func (b *TimeSeriesBuilder) ExpectedRollups(end time.Time, window time.Duration) remotewrite.Rollups {
+	if b.timeRange == nil {
+		return remotewrite.ExpectedRollups(nil, end, window)
+	}
+	samples := remotewrite.GenSamples(b.generator(), b.timeRange.Timestamps())
+	return remotewrite.ExpectedRollups(samples, end, window)
+}
+
+func (b *TimeSeriesBuilder) generator() remotewrite.ValueGenerator {
+	if b.valueGen != nil {
+		return b.valueGen
+	}
+	return remotewrite.Constant(b.value)
+}
+
+// WithHistogram generates classic histogram families <prefix>_<i>_bucket, _sum and _count
+// observing the given values into buckets with the given upper bounds, instead of plain samples.
+func (b *TimeSeriesBuilder) WithHistogram(buckets []float64, observations []float64) *TimeSeriesBuilder {
+	b.histogram = &remotewrite.Distribution{Buckets: buckets, Observations: observations}
+	b.summary = nil
+	return b
+}
+
+// WithSummary generates summary families <prefix>_<i>{quantile="..."}, _sum and _count
+// over the given observations, instead of plain samples.
+func (b *TimeSeriesBuilder) WithSummary(quantiles []float64, observations []float64) *TimeSeriesBuilder {
+	b.histogram = &remotewrite.Distribution{Observations: observations}
+	b.summary = quantiles
+	return b
+}
+
+// WithExemplar attaches an exemplar with the given value and labels to the last sample of every series.
+// For histograms, it is attached only to the bucket the value falls into.
+func (b *TimeSeriesBuilder) WithExemplar(value float64, labels map[string]string) *TimeSeriesBuilder {
+	b.exemplars = append(b.exemplars, exemplarSpec{value: value, labels: labels})
+	return b
+}
+
+// WithMetadata sets metric metadata sent for every generated metric family.
+func (b *TimeSeriesBuilder) WithMetadata(metricType prompb.MetricType, help, unit string) *TimeSeriesBuilder {
+	b.metadata = &prompb.MetricMetadata{Type: metricType, Help: help, Unit: unit}
+	return b
+}
+
+// WithStaleMarker ends every series with a Prometheus staleness marker at the given time.
+// It must be later than the last generated sample.
+func (b *TimeSeriesBuilder) WithStaleMarker(at time.Time) *TimeSeriesBuilder {
+	b.staleAt = &at
+	return b
+}
+
+// Distribution returns the distribution set by WithHistogram or WithSummary.
+// It can be used to compute expected histogram_quantile results.
+func (b *TimeSeriesBuilder) Distribution() remotewrite.Distribution {
+	if b.histogram == nil {
+		return remotewrite.Distribution{}
+	}
+	return *b.histogram
+}
+
 // Build generates the time series data.
 func (b *TimeSeriesBuilder) Build() []prompb.TimeSeries {
-	ts := remotewrite.GenTimeSeries(b.prefix, b.count, b.value)
+	var ts []prompb.TimeSeries
+	switch {
+	case b.histogram != nil:
+		r := b.sampleRange()
+		if b.summary != nil {
+			ts = remotewrite.GenSummaryTimeSeries(b.prefix, b.count, b.summary, *b.histogram, r)
+		} else {
+			ts = remotewrite.GenHistogramTimeSeries(b.prefix, b.count, *b.histogram, r)
+		}
+	case b.timeRange != nil || b.valueGen != nil || b.irregularities != (remotewrite.Irregularities{}):
+		ts = remotewrite.GenTimeSeriesWithGenerator(b.prefix, b.count, b.generator(), b.sampleRange(), b.irregularities)
+	default:
+		ts = remotewrite.GenTimeSeries(b.prefix, b.count, b.value)
+	}
+	if b.staleAt != nil {
+		ts = remotewrite.AppendStaleMarkers(ts, *b.staleAt)
+	}
 
 	// Add custom labels if any
 	if len(b.labels) > 0 {
@@ -305,17 +510,79 @@ func (b *TimeSeriesBuilder) Build() []prompb.TimeSeries {
 	return ts
 }
 
+// BuildWithOptions generates the time series data together with write options
+// carrying the metadata and exemplars configured for them.
+func (b *TimeSeriesBuilder) BuildWithOptions() ([]prompb.TimeSeries, remotewrite.WriteOptions) {
+	ts := b.Build()
+	var opts remotewrite.WriteOptions
+
+	if b.metadata != nil {
+		for i := 0; i < b.count; i++ {
+			md := *b.metadata
+			md.MetricFamilyName = fmt.Sprintf("%s_%d", b.prefix, i)
+			opts.Metadata = append(opts.Metadata, md)
+		}
+	}
+
+	for _, e := range b.exemplars {
+		var exemplarLabels []prompb.Label
+		for k, v := range e.labels {
+			exemplarLabels = append(exemplarLabels, prompb.Label{Name: k, Value: v})
+		}
+		sort.Slice(exemplarLabels, func(i, j int) bool { return exemplarLabels[i].Name < exemplarLabels[j].Name })
+		for _, series := range ts {
+			if len(series.Samples) == 0 || !b.exemplarMatches(series, e.value) {
+				continue
+			}
+			if opts.Exemplars == nil {
+				opts.Exemplars = make(map[string][]remotewrite.Exemplar)
+			}
+			key := remotewrite.SeriesKey(series.Labels)
+			opts.Exemplars[key] = append(opts.Exemplars[key], remotewrite.Exemplar{
+				Labels:    exemplarLabels,
+				Value:     e.value,
+				Timestamp: series.Samples[len(series.Samples)-1].Timestamp,
+			})
+		}
+	}
+	return ts, opts
+}
+
+// exemplarMatches reports whether an exemplar with the given value belongs to the series.
+func (b *TimeSeriesBuilder) exemplarMatches(series prompb.TimeSeries, value float64) bool {
+	if b.histogram == nil || b.summary != nil {
+		return true
+	}
+	for _, l := range series.Labels {
+		if l.Name == "le" {
+			return l.Value == b.histogram.BucketFor(value)
+		}
+	}
+	return false
+}
+
+// sampleRange returns the configured time range, defaulting to a single sample at the current time.
+func (b *TimeSeriesBuilder) sampleRange() remotewrite.TimeRange {
+	if b.timeRange != nil {
+		return *b.timeRange
+	}
+	now := time.Now()
+	return remotewrite.TimeRange{Start: now, End: now, Step: time.Second}
+}
+
 // Send generates and sends the time series to the specified URL.
 func (b *TimeSeriesBuilder) Send(ctx context.Context, url string) error {
-
-	ts := b.Build()
-	return remotewrite.RemoteWrite(b.httpClient, ts, url)
+	ts, opts := b.BuildWithOptions()
+	return remotewrite.RemoteWriteContext(ctx, b.httpClient, ts, url, opts)
 }
 
 // RemoteWriteBuilder provides a fluent interface for remote write operations.
 type RemoteWriteBuilder struct {
 	httpClient *http.Client
 	url        string
+	opts       remotewrite.WriteOptions
+	batch      remotewrite.BatchOptions
+	auth       authConfig
 }
 
 // NewRemoteWriteBuilder creates a new RemoteWriteBuilder.
@@ -349,12 +616,155 @@ func (b *RemoteWriteBuilder) ForMultitenant(namespace string) *RemoteWriteBuilde
 	return b
 }
 
+// WithProtocol sets the remote write protocol version used to encode payloads.
+func (b *RemoteWriteBuilder) WithProtocol(protocol remotewrite.Protocol) *RemoteWriteBuilder {
+	b.opts.Protocol = protocol
+	return b
+}
+
+// WithMetadata adds metric metadata (type, help, unit) to every request.
+func (b *RemoteWriteBuilder) WithMetadata(metadata ...prompb.MetricMetadata) *RemoteWriteBuilder {
+	b.opts.Metadata = append(b.opts.Metadata, metadata...)
+	return b
+}
+
+// WithCreatedTimestamp sets the created timestamp for series with the given metric name.
+// It is only sent with Remote Write 2.0.
+func (b *RemoteWriteBuilder) WithCreatedTimestamp(metricName string, created time.Time) *RemoteWriteBuilder {
+	if b.opts.CreatedTimestamps == nil {
+		b.opts.CreatedTimestamps = make(map[string]int64)
+	}
+	b.opts.CreatedTimestamps[metricName] = created.UnixMilli()
+	return b
+}
+
+// WithExemplars adds exemplars keyed by remotewrite.SeriesKey to every request.
+func (b *RemoteWriteBuilder) WithExemplars(exemplars map[string][]remotewrite.Exemplar) *RemoteWriteBuilder {
+	if b.opts.Exemplars == nil {
+		b.opts.Exemplars = make(map[string][]remotewrite.Exemplar)
+	}
+	for k, v := range exemplars {
+		b.opts.Exemplars[k] = append(b.opts.Exemplars[k], v...)
+	}
+	return b
+}
+
+// WithRetryPolicy enables retries of failed requests with the given policy.
+func (b *RemoteWriteBuilder) WithRetryPolicy(policy remotewrite.RetryPolicy) *RemoteWriteBuilder {
+	b.opts.Retry = &policy
+	return b
+}
+
+// WithStats sets the stats collector updated with the outcome of every request.
+func (b *RemoteWriteBuilder) WithStats(stats *remotewrite.WriteStats) *RemoteWriteBuilder {
+	b.opts.Stats = stats
+	return b
+}
+
+// WithRecorder saves every payload sent by the builder to the recorder's capture file.
+// The capture can be replayed with remotewrite.ReplayFile to reproduce a failure.
+func (b *RemoteWriteBuilder) WithRecorder(recorder *remotewrite.Recorder) *RemoteWriteBuilder {
+	b.opts.Recorder = recorder
+	return b
+}
+
+// WithBasicAuth sets basic authentication credentials for every request.
+func (b *RemoteWriteBuilder) WithBasicAuth(username, password string) *RemoteWriteBuilder {
+	b.auth.setBasicAuth(username, password)
+	return b
+}
+
+// WithBearerToken sets a bearer token for every request.
+func (b *RemoteWriteBuilder) WithBearerToken(token string) *RemoteWriteBuilder {
+	b.auth.setBearerToken(token)
+	return b
+}
+
+// WithHeader sets a custom header for every request. It overrides default headers such as User-Agent.
+func (b *RemoteWriteBuilder) WithHeader(name, value string) *RemoteWriteBuilder {
+	b.auth.setHeader(name, value)
+	return b
+}
+
+// WithTenantHeaders selects the tenant with AccountID and ProjectID headers instead of the URL path.
+func (b *RemoteWriteBuilder) WithTenantHeaders(accountID, projectID int) *RemoteWriteBuilder {
+	b.auth.setTenantHeaders(accountID, projectID)
+	return b
+}
+
+// WithClientCertificate configures mTLS with the given client certificate and key.
+// If caFile is not empty, the server certificate is verified against it.
+func (b *RemoteWriteBuilder) WithClientCertificate(certFile, keyFile, caFile string) *RemoteWriteBuilder {
+	b.auth.setClientCertificate(certFile, keyFile, caFile)
+	return b
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS connections.
+// cfg is copied, so later options such as WithClientCertificate do not modify it.
+func (b *RemoteWriteBuilder) WithTLSConfig(cfg *tls.Config) *RemoteWriteBuilder {
+	b.auth.tlsConfig = cfg.Clone()
+	return b
+}
+
+// writeOptions returns write options with authentication headers applied.
+func (b *RemoteWriteBuilder) writeOptions() remotewrite.WriteOptions {
+	opts := b.opts
+	opts.Headers = b.auth.headers
+	return opts
+}
+
+// WithMaxSeriesPerRequest splits sent time series into requests of at most n series.
+func (b *RemoteWriteBuilder) WithMaxSeriesPerRequest(n int) *RemoteWriteBuilder {
+	b.batch.MaxSeries = n
+	return b
+}
+
+// WithMaxRequestSize splits sent time series into requests of at most size uncompressed bytes.
+// Keep it below vminsert's -maxInsertRequestSize when sending many series.
+func (b *RemoteWriteBuilder) WithMaxRequestSize(size int) *RemoteWriteBuilder {
+	b.batch.MaxBytes = size
+	return b
+}
+
+// WithConcurrency sets the number of split requests sent in parallel.
+func (b *RemoteWriteBuilder) WithConcurrency(n int) *RemoteWriteBuilder {
+	b.batch.Concurrency = n
+	return b
+}
+
 // Send sends the time series to the configured URL.
-func (b *RemoteWriteBuilder) Send(ts []prompb.TimeSeries) error {
+// A request rejected by the server is returned as *remotewrite.WriteError.
+// If request limits are configured, time series are split into multiple requests
+// and failures of all requests are joined into the returned error.
+func (b *RemoteWriteBuilder) Send(ctx context.Context, ts []prompb.TimeSeries) error {
+	_, err := b.SendWithResult(ctx, ts)
+	return err
+}
+
+// SendWithResult sends the time series like Send and returns a summary of the requests.
+func (b *RemoteWriteBuilder) SendWithResult(ctx context.Context, ts []prompb.TimeSeries) (remotewrite.BatchResult, error) {
 	if b.url == "" {
-		return fmt.Errorf("no URL configured for remote write")
+		return remotewrite.BatchResult{}, fmt.Errorf("no URL configured for remote write")
 	}
-	return remotewrite.RemoteWrite(b.httpClient, ts, b.url)
+	if b.auth.err != nil {
+		return remotewrite.BatchResult{}, b.auth.err
+	}
+	return remotewrite.RemoteWriteBatches(ctx, b.auth.httpClient(b.httpClient), ts, b.url, b.writeOptions(), b.batch)
+}
+
+// RunLoad generates sustained write load against the configured URL.
+// URL, HTTP client and write options of the builder override the ones in cfg.
+func (b *RemoteWriteBuilder) RunLoad(ctx context.Context, cfg remotewrite.LoadConfig) (remotewrite.LoadResult, error) {
+	if b.url == "" {
+		return remotewrite.LoadResult{}, fmt.Errorf("no URL configured for remote write")
+	}
+	if b.auth.err != nil {
+		return remotewrite.LoadResult{}, b.auth.err
+	}
+	cfg.URL = b.url
+	cfg.Client = b.auth.httpClient(b.httpClient)
+	cfg.Options = b.writeOptions()
+	return remotewrite.RunLoad(ctx, cfg)
 }
 
 // ForVMAgent configures the builder for a VMAgent instance.
@@ -417,20 +827,6 @@ func (b *RelabelConfigBuilder) build() (string, error) {
 	return string(data), nil
 }
 
-// DatadogSeries represents the top-level structure for Datadog ingestion.
-type DatadogSeries struct {
-	Series []DatadogMetric `json:"series"`
-}
-
-// DatadogMetric represents a single metric in Datadog format.
-type DatadogMetric struct {
-	Metric string          `json:"metric"`
-	Points [][]interface{} `json:"points"`
-	Tags   []string        `json:"tags,omitempty"`
-	Host   string          `json:"host,omitempty"`
-	Type   string          `json:"type,omitempty"`
-}
-
 // MustBuild generates the YAML configuration, panicking on error.
 func (b *RelabelConfigBuilder) MustBuild() string {
 	config, err := b.build()
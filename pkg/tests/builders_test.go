@@ -1,11 +1,20 @@
 package tests
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/install"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
 )
 
 func TestConfigMapBuilder(t *testing.T) {
@@ -35,6 +44,27 @@ func TestJSONPatchBuilder(t *testing.T) {
 		assert.NotNil(t, patch)
 	})
 
+	t.Run("WithListeners", func(t *testing.T) {
+		patch := NewJSONPatchBuilder().
+			WithExtraArg("foo", "bar").
+			WithListeners(install.GraphiteListener, install.OpenTSDBListener).
+			MustBuild()
+
+		doc, err := patch.Apply([]byte(`{"spec":{}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"spec":{"extraArgs":{"foo":"bar","graphiteListenAddr":":2003","opentsdbListenAddr":":4242"}}}`, string(doc))
+	})
+
+	t.Run("WithVMInsertListeners", func(t *testing.T) {
+		patch := NewJSONPatchBuilder().
+			WithVMInsertListeners(install.OpenTSDBHTTPListener).
+			MustBuild()
+
+		doc, err := patch.Apply([]byte(`{"spec":{"vminsert":{"extraArgs":{"maxLabelsPerTimeseries":"50"}}}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"spec":{"vminsert":{"extraArgs":{"maxLabelsPerTimeseries":"50","opentsdbHTTPListenAddr":":4243"}}}}`, string(doc))
+	})
+
 	t.Run("WithVMSingleConfig", func(t *testing.T) {
 		builder := NewJSONPatchBuilder().
 			WithVMSingleConfig("my-cm", "config-key", "file.yml")
@@ -103,6 +133,95 @@ func TestTimeSeriesBuilder(t *testing.T) {
 	}
 }
 
+func TestTimeSeriesBuilderBackfill(t *testing.T) {
+	end := time.Now()
+	start := end.Add(-10 * time.Minute)
+
+	ts := NewTimeSeriesBuilder("backfill").
+		WithCount(2).
+		WithTimeRange(start, end, time.Minute).
+		WithOutOfOrder(2).
+		WithDuplicates(1).
+		WithFarFuture(1, 24*time.Hour).
+		Build()
+
+	require.Len(t, ts, 2)
+	for _, series := range ts {
+		// 11 samples in range, 1 duplicate and 1 far-future sample
+		require.Len(t, series.Samples, 13)
+		assert.Equal(t, end.Add(24*time.Hour).UnixMilli(), series.Samples[12].Timestamp)
+	}
+}
+
+func TestTimeSeriesBuilderStaleMarker(t *testing.T) {
+	start := time.UnixMilli(0)
+	staleAt := start.Add(time.Minute)
+
+	ts := NewTimeSeriesBuilder("stale").
+		WithCount(2).
+		WithTimeRange(start, start.Add(30*time.Second), 15*time.Second).
+		WithStaleMarker(staleAt).
+		Build()
+
+	require.Len(t, ts, 2)
+	for _, series := range ts {
+		require.Len(t, series.Samples, 4)
+		last := series.Samples[3]
+		assert.Equal(t, staleAt.UnixMilli(), last.Timestamp)
+		assert.True(t, remotewrite.IsStaleNaN(last.Value))
+	}
+}
+
+func TestTimeSeriesBuilderValueGenerator(t *testing.T) {
+	start := time.UnixMilli(0)
+	end := start.Add(5 * time.Minute)
+
+	builder := NewTimeSeriesBuilder("counter").
+		WithCount(1).
+		WithTimeRange(start, end, time.Minute).
+		WithValueGenerator(remotewrite.Counter{Increment: 10, ResetEvery: 3})
+
+	ts := builder.Build()
+	require.Len(t, ts, 1)
+	values := []float64{}
+	for _, s := range ts[0].Samples {
+		values = append(values, s.Value)
+	}
+	assert.Equal(t, []float64{0, 10, 20, 0, 10, 20}, values)
+
+	// (1m, 5m]: 10 -> 20 -> 0 -> 10 -> 20
+	expected := builder.ExpectedRollups(end, 4*time.Minute)
+	assert.Equal(t, float64(30), expected.Increase)
+	assert.Equal(t, float64(30)/240, expected.Rate)
+	assert.Equal(t, float64(12.5), expected.AvgOverTime)
+}
+
+func TestTimeSeriesBuilderHistogram(t *testing.T) {
+	builder := NewTimeSeriesBuilder("latency").
+		WithCount(2).
+		WithHistogram([]float64{0.1, 1}, []float64{0.05, 0.5, 0.7}).
+		WithExemplar(0.5, map[string]string{"trace_id": "abc"}).
+		WithMetadata(prompb.MetricTypeHistogram, "Request latency", "seconds")
+
+	ts, opts := builder.BuildWithOptions()
+	// 3 buckets, _sum and _count per family
+	require.Len(t, ts, 10)
+
+	require.Len(t, opts.Metadata, 2)
+	assert.Equal(t, "latency_0", opts.Metadata[0].MetricFamilyName)
+	assert.Equal(t, prompb.MetricTypeHistogram, opts.Metadata[1].Type)
+
+	require.Len(t, opts.Exemplars, 2, "Exemplar should be attached to one bucket per family")
+	for key, exemplars := range opts.Exemplars {
+		assert.Contains(t, key, `le="1"`)
+		require.Len(t, exemplars, 1)
+		assert.Equal(t, 0.5, exemplars[0].Value)
+		assert.Equal(t, []prompb.Label{{Name: "trace_id", Value: "abc"}}, exemplars[0].Labels)
+	}
+
+	assert.Equal(t, []float64{1, 3, 3}, builder.Distribution().BucketCounts())
+}
+
 func TestRemoteWriteBuilder(t *testing.T) {
 	t.Run("WithURL", func(t *testing.T) {
 		url := "http://rw.example.com"
@@ -110,9 +229,41 @@ func TestRemoteWriteBuilder(t *testing.T) {
 		assert.Equal(t, url, builder.url)
 	})
 
+	t.Run("WithProtocol", func(t *testing.T) {
+		created := time.UnixMilli(1000)
+		builder := NewRemoteWriteBuilder().
+			WithProtocol(remotewrite.ProtocolV2).
+			WithMetadata(prompb.MetricMetadata{MetricFamilyName: "foo", Type: prompb.MetricTypeCounter}).
+			WithCreatedTimestamp("foo", created)
+		assert.Equal(t, remotewrite.ProtocolV2, builder.opts.Protocol)
+		assert.Len(t, builder.opts.Metadata, 1)
+		assert.Equal(t, int64(1000), builder.opts.CreatedTimestamps["foo"])
+	})
+
+	t.Run("SendWithResult", func(t *testing.T) {
+		var requests atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		ts := NewTimeSeriesBuilder("batch").WithCount(25).Build()
+		result, err := NewRemoteWriteBuilder().
+			WithHTTPClient(server.Client()).
+			WithURL(server.URL).
+			WithMaxSeriesPerRequest(10).
+			WithConcurrency(2).
+			SendWithResult(context.Background(), ts)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Requests)
+		assert.Equal(t, 25, result.Series)
+		assert.Equal(t, int64(3), requests.Load())
+	})
+
 	t.Run("Send Error", func(t *testing.T) {
 		builder := NewRemoteWriteBuilder()
-		err := builder.Send(nil)
+		err := builder.Send(context.Background(), nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "no URL configured")
 	})
@@ -42,6 +42,7 @@ var (
 	licenseFile             string
 	distributedRegion       string
 	distributedZones        string
+	updateGolden            bool
 )
 
 func init() {
@@ -79,6 +80,7 @@ func init() {
 	flag.StringVar(&licenseFile, "license-file", "", "Path to license file")
 	flag.StringVar(&distributedRegion, "distributed-region", "europe-central2", "Region for distributed tests")
 	flag.StringVar(&distributedZones, "distributed-zones", "europe-central2-a,europe-central2-b,europe-central2-c", "Zones for distributed tests")
+	flag.BoolVar(&updateGolden, "update-golden", false, "Regenerate golden files of query results instead of comparing with them")
 }
 
 // Init initializes test configuration by parsing flags and setting up constants.
@@ -125,4 +127,5 @@ func Init() {
 	consts.SetLicenseFile(licenseFile)
 	consts.SetDistributedRegion(distributedRegion)
 	consts.SetDistributedZones(distributedZones)
+	consts.SetUpdateGolden(updateGolden)
 }
@@ -19,8 +19,8 @@ import (
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/gather"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/install"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/promquery"
-
-	prommodel "github.com/prometheus/common/model"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/tests/allure"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/vmalert"
 )
 
 // OverwatchStart records the time tests started collecting metrics via overwatch.
@@ -105,6 +105,88 @@ func GatherOnFailure(ctx context.Context, t terratesting.TestingT, kubeOpts *k8s
 	}
 }
 
+// NewVMAlertClient creates a client for the VMAlert of the k8s-stack release in namespace.
+func NewVMAlertClient(namespace string) *vmalert.Client {
+	return vmalert.NewClient(NewHTTPClient(), fmt.Sprintf("http://%s", consts.VMAlertHost(namespace)))
+}
+
+// CheckVMAlertRulesHealthy verifies that rules from consts.CustomAlertsYaml and the chart default rules
+// are loaded by VMAlert in namespace and evaluated without errors. Custom rules must fetch series,
+// default recording rules must fetch series unless listed in vmalert.DefaultNoMatchExceptions.
+// Rules are given consts.ResourceWaitTimeout to be loaded and evaluated.
+func CheckVMAlertRulesHealthy(ctx context.Context, t terratesting.TestingT, namespace string) {
+	customGroups, err := vmalert.ManifestGroups(consts.CustomAlertsYaml)
+	require.NoError(t, err)
+
+	client := NewVMAlertClient(namespace)
+	checks := []vmalert.HealthOptions{
+		{
+			Groups:        customGroups,
+			RequireSeries: []string{vmalert.RuleTypeAlerting, vmalert.RuleTypeRecording},
+		},
+		{
+			RequireSeries:     []string{vmalert.RuleTypeRecording},
+			NoMatchExceptions: vmalert.DefaultNoMatchExceptions,
+		},
+	}
+	for _, opts := range checks {
+		client.CheckRulesHealthy(ctx, t, opts, consts.ResourceWaitTimeout, consts.PollingInterval)
+	}
+}
+
+// StartAlertRecorder starts recording alert transitions in the namespace via the overwatch client.
+// The recorder outlives the node it is started in, pass it to AttachAlertTimeline in AfterEach.
+func StartAlertRecorder(overwatch promquery.PrometheusClient, namespace string) *promquery.AlertRecorder {
+	recorder := overwatch.NewAlertRecorder(namespace, consts.PollingInterval)
+	recorder.Start(context.Background())
+	return recorder
+}
+
+// AttachAlertTimeline stops the recorder and attaches the recorded alert timeline
+// to the Allure report as JSON and as a table. The table is logged as well if the spec failed.
+func AttachAlertTimeline(t terratesting.TestingT, recorder *promquery.AlertRecorder) {
+	if recorder == nil {
+		return
+	}
+	timeline := recorder.Stop()
+	data, err := timeline.JSON()
+	if err != nil {
+		logger.Default.Logf(t, "failed to marshal alert timeline: %v", err)
+	} else {
+		allure.AddAttachment("alert-timeline.json", allure.MimeTypeJSON, data)
+	}
+	allure.AddAttachment("alert-timeline.txt", allure.MimeTypeText, []byte(timeline.String()))
+	if CurrentSpecReport().Failed() {
+		logger.Default.Logf(t, "%s", timeline)
+	}
+}
+
+// SilenceAlerts silences alerts expected to fire in the namespace during the current spec
+// and returns the silence IDs. Call ExpireSilences in AfterEach to remove them.
+func SilenceAlerts(ctx context.Context, t terratesting.TestingT, overwatch promquery.PrometheusClient, namespace string, alerts ...string) []string {
+	ids := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		id, err := overwatch.CreateSilence(ctx, namespace, alert, promquery.SilenceOptions{Spec: CurrentSpecReport().FullText()})
+		require.NoError(t, err, "Failed to silence %s in namespace %s", alert, namespace)
+		logger.Default.Logf(t, "Silenced %s in namespace %s with silence %s", alert, namespace, id)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ExpireSilences expires silences created by SilenceAlerts for the current spec.
+// This should be called in AfterEach blocks.
+func ExpireSilences(ctx context.Context, t terratesting.TestingT, overwatch promquery.PrometheusClient) {
+	expired, err := overwatch.ExpireSilences(ctx, "", CurrentSpecReport().FullText())
+	if err != nil {
+		logger.Default.Logf(t, "failed to expire silences: %v", err)
+		return
+	}
+	if expired > 0 {
+		logger.Default.Logf(t, "Expired %d silences", expired)
+	}
+}
+
 // NewTenantPromClient creates a new Prometheus client for a specific tenant.
 // The startTime is typically obtained from the overwatch setup.
 func NewTenantPromClient(t terratesting.TestingT, namespace string, tenantID int, startTime time.Time) (promquery.PrometheusClient, error) {
@@ -273,29 +355,3 @@ func DefaultChaosMeshConfig() ChaosMeshConfig {
 		ReleaseName: consts.ChaosMeshReleaseName,
 	}
 }
-
-// RetryVectorScan retries a VectorScan query up to a specified number of times,
-// waiting for data propagation between attempts. It is useful for intermittent 502s
-// or data propagation delays.
-func RetryVectorScan(ctx context.Context, t terratesting.TestingT, namespace string, prom promquery.PrometheusClient, query string, maxRetries int) (prommodel.Metric, prommodel.SampleValue, error) {
-	var lastErr error
-	var lastMetric prommodel.Metric
-	var lastValue prommodel.SampleValue
-
-	for i := 0; i < maxRetries; i++ {
-		metric, value, err := prom.VectorScan(ctx, query)
-		lastErr = err
-		lastMetric = metric
-		lastValue = value
-		if err == nil {
-			return metric, value, nil
-		}
-		logger.Default.Logf(t, "Attempt %d: VectorScan for %q failed: %v", i+1, query, err)
-		WaitForDataPropagation()
-	}
-
-	if lastErr != nil {
-		logger.Default.Logf(t, "Final VectorScan failure for %q: %v", query, lastErr)
-	}
-	return lastMetric, lastValue, lastErr
-}
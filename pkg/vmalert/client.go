@@ -0,0 +1,132 @@
+package vmalert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response body is included in errors.
+const maxErrorBodySize = 4096
+
+// Client queries the vmalert HTTP API.
+type Client struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewClient creates a new Client sending requests to url, e.g. http://vmalert:8080.
+func NewClient(httpClient *http.Client, url string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		url:        strings.TrimSuffix(url, "/"),
+	}
+}
+
+// apiResponse is the envelope of vmalert API responses.
+type apiResponse struct {
+	Status string          `json:"status"`
+	Error  string          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Groups returns all loaded rule groups with their rules.
+func (c *Client) Groups(ctx context.Context) ([]Group, error) {
+	var data struct {
+		Groups []Group `json:"groups"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/rules", &data); err != nil {
+		return nil, err
+	}
+	return data.Groups, nil
+}
+
+// Group returns the loaded group with the given name.
+func (c *Client) Group(ctx context.Context, name string) (Group, error) {
+	groups, err := c.Groups(ctx)
+	if err != nil {
+		return Group{}, err
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return Group{}, fmt.Errorf("group %q is not loaded by vmalert", name)
+}
+
+// Rule returns the rule with the given name from the group.
+func (c *Client) Rule(ctx context.Context, group, name string) (Rule, error) {
+	g, err := c.Group(ctx, group)
+	if err != nil {
+		return Rule{}, err
+	}
+	r, ok := g.Rule(name)
+	if !ok {
+		return Rule{}, fmt.Errorf("rule %q is not found in group %q", name, group)
+	}
+	return r, nil
+}
+
+// Alerts returns pending and firing alerts of all alerting rules.
+func (c *Client) Alerts(ctx context.Context) ([]Alert, error) {
+	var data struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/alerts", &data); err != nil {
+		return nil, err
+	}
+	return data.Alerts, nil
+}
+
+// Reload makes vmalert re-read rule files.
+func (c *Client) Reload(ctx context.Context) error {
+	resp, err := c.get(ctx, "/-/reload")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, data any) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("cannot decode response of %s%s: %w", c.url, path, err)
+	}
+	if r.Status != "success" {
+		return fmt.Errorf("request to %s%s failed with status %q: %s", c.url, path, r.Status, r.Error)
+	}
+	if err := json.Unmarshal(r.Data, data); err != nil {
+		return fmt.Errorf("cannot decode data of %s%s: %w", c.url, path, err)
+	}
+	return nil
+}
+
+// get sends a GET request to path. A non-2xx response is returned as an error.
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vmalert request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, fmt.Errorf("request to %s%s failed with status %d: %s", c.url, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package vmalert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rulesResponse = `{"status":"success","data":{"groups":[
+{"id":"1","name":"custom-rules","file":"/etc/vmalert/custom.yaml","type":"prometheus","interval":30,"lastEvaluation":"2026-01-02T03:04:05Z","concurrency":1,"rules":[
+ {"id":"11","group_id":"1","name":"CustomHighMemoryUsage","type":"alerting","query":"sum(x) > 1.5","state":"firing","duration":60,"health":"ok","lastError":"","evaluationTime":0.01,"lastEvaluation":"2026-01-02T03:04:05Z","lastSamples":1,"lastSeriesFetched":4,
+  "alerts":[{"id":"111","rule_id":"11","group_id":"1","name":"CustomHighMemoryUsage","state":"firing","value":"2","labels":{"namespace":"vm"},"activeAt":"2026-01-02T03:00:00Z"}]},
+ {"id":"12","group_id":"1","name":"custom:broken","type":"recording","query":"sum(","health":"err","lastError":"cannot parse","lastSeriesFetched":null}
+]}]}}`
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	reloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/rules":
+			_, _ = fmt.Fprint(w, rulesResponse)
+		case "/api/v1/alerts":
+			_, _ = fmt.Fprint(w, `{"status":"success","data":{"alerts":[{"id":"111","name":"CustomHighMemoryUsage","state":"firing","labels":{"namespace":"vm"},"restored":true}]}}`)
+		case "/-/reload":
+			reloads++
+		default:
+			http.Error(w, "unsupported path", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), server.URL+"/")
+	ctx := context.Background()
+
+	groups, err := client.Groups(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	g := groups[0]
+	assert.Equal(t, "custom-rules", g.Name)
+	assert.Equal(t, 30.0, g.Interval)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), g.LastEvaluation)
+	require.Len(t, g.Rules, 2)
+	assert.Equal(t, RuleTypeAlerting, g.Rules[0].Type)
+	assert.Equal(t, 4, *g.Rules[0].LastSeriesFetched)
+	require.Len(t, g.Rules[0].Alerts, 1)
+	assert.Equal(t, "vm", g.Rules[0].Alerts[0].Labels["namespace"])
+	assert.Nil(t, g.Rules[1].LastSeriesFetched)
+	assert.False(t, g.Rules[1].NoMatch(), "Rules without series stats should not be reported as no match")
+
+	r, err := client.Rule(ctx, "custom-rules", "custom:broken")
+	require.NoError(t, err)
+	assert.Equal(t, HealthErr, r.Health)
+	assert.Equal(t, "cannot parse", r.LastError)
+	_, err = client.Rule(ctx, "custom-rules", "Missing")
+	require.EqualError(t, err, `rule "Missing" is not found in group "custom-rules"`)
+	_, err = client.Group(ctx, "missing")
+	require.EqualError(t, err, `group "missing" is not loaded by vmalert`)
+
+	alerts, err := client.Alerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.True(t, alerts[0].Restored)
+
+	require.NoError(t, client.Reload(ctx))
+	assert.Equal(t, 1, reloads)
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/alerts" {
+			_, _ = fmt.Fprint(w, `{"status":"error","errorType":"server_error","error":"datasource is down"}`)
+			return
+		}
+		http.Error(w, "reload is disabled", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	_, err := client.Alerts(ctx)
+	require.ErrorContains(t, err, "datasource is down")
+	err = client.Reload(ctx)
+	require.ErrorContains(t, err, "failed with status 403: reload is disabled")
+	_, err = client.Groups(ctx)
+	require.ErrorContains(t, err, "failed with status 403")
+}
@@ -0,0 +1,153 @@
+package vmalert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	vmv1beta1 "github.com/VictoriaMetrics/operator/api/operator/v1beta1"
+)
+
+// DefaultNoMatchExceptions are groups of the chart default rules for control plane components
+// that are not scraped in kind clusters.
+var DefaultNoMatchExceptions = []string{
+	"etcd", "kube-scheduler.rules",
+}
+
+// HealthOptions configures which rules RuleProblems inspects.
+type HealthOptions struct {
+	// Groups limits the check to groups with these names and requires them to be loaded.
+	// All loaded groups are checked if empty.
+	Groups []string
+	// RequireSeries lists rule types, RuleTypeAlerting or RuleTypeRecording, whose last evaluation
+	// must fetch series. Alerting rules often select series that exist only while something is broken,
+	// so the check is opt-in per type.
+	RequireSeries []string
+	// NoMatchExceptions are names of rules or groups allowed to fetch no series,
+	// e.g. groups for components that are not scraped in the test cluster.
+	NoMatchExceptions []string
+}
+
+// RuleProblem is a rule that is not healthy.
+type RuleProblem struct {
+	Group   string
+	Rule    string
+	Problem string
+}
+
+// String formats the problem as group/rule: problem.
+func (p RuleProblem) String() string {
+	if p.Rule == "" {
+		return fmt.Sprintf("%s: %s", p.Group, p.Problem)
+	}
+	return fmt.Sprintf("%s/%s: %s", p.Group, p.Rule, p.Problem)
+}
+
+// RuleProblems returns groups that are not loaded, rules that were not evaluated yet,
+// rules failing evaluation and rules required to fetch series that fetched none.
+func RuleProblems(groups []Group, opts HealthOptions) []RuleProblem {
+	var problems []RuleProblem
+	checked := groups
+	if len(opts.Groups) > 0 {
+		byName := make(map[string]Group, len(groups))
+		for _, g := range groups {
+			byName[g.Name] = g
+		}
+		checked = nil
+		for _, name := range opts.Groups {
+			g, ok := byName[name]
+			if !ok {
+				problems = append(problems, RuleProblem{Group: name, Problem: "group is not loaded"})
+				continue
+			}
+			checked = append(checked, g)
+		}
+	}
+
+	for _, g := range checked {
+		for _, r := range g.Rules {
+			problem := ""
+			switch {
+			case r.Health == HealthErr:
+				problem = fmt.Sprintf("evaluation failed: %s", r.LastError)
+			case !r.Evaluated():
+				problem = "rule is not evaluated yet"
+			case r.Health != HealthOK:
+				problem = fmt.Sprintf("rule has health %q", r.Health)
+			case r.NoMatch() && slices.Contains(opts.RequireSeries, r.Type) &&
+				!slices.Contains(opts.NoMatchExceptions, r.Name) && !slices.Contains(opts.NoMatchExceptions, g.Name):
+				problem = fmt.Sprintf("no series match the query %s", strings.TrimSpace(r.Query))
+			}
+			if problem != "" {
+				problems = append(problems, RuleProblem{Group: g.Name, Rule: r.Name, Problem: problem})
+			}
+		}
+	}
+	return problems
+}
+
+// RuleProblems returns problems of rules loaded by vmalert.
+func (c *Client) RuleProblems(ctx context.Context, opts HealthOptions) ([]RuleProblem, error) {
+	groups, err := c.Groups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return RuleProblems(groups, opts), nil
+}
+
+// WaitRulesHealthy polls RuleProblems every interval until it reports no problems, timeout passes
+// or ctx is done, and returns the result of the last attempt. A zero timeout makes a single attempt.
+func (c *Client) WaitRulesHealthy(ctx context.Context, opts HealthOptions, timeout, interval time.Duration) ([]RuleProblem, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		problems, err := c.RuleProblems(ctx, opts)
+		if (err == nil && len(problems) == 0) || !time.Now().Before(deadline) {
+			return problems, err
+		}
+		select {
+		case <-ctx.Done():
+			return problems, err
+		case <-time.After(interval):
+		}
+	}
+}
+
+// CheckRulesHealthy verifies that rules loaded by vmalert have no evaluation errors
+// and fetch series as configured in opts, waiting up to timeout for them to be loaded and evaluated.
+func (c *Client) CheckRulesHealthy(ctx context.Context, t testing.TestingT, opts HealthOptions, timeout, interval time.Duration) {
+	problems, err := c.WaitRulesHealthy(ctx, opts, timeout, interval)
+	require.NoError(t, err, "Failed to get rules from vmalert")
+	require.Empty(t, problems, problemsMessage(problems))
+}
+
+func problemsMessage(problems []RuleProblem) string {
+	lines := make([]string, 0, len(problems))
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	return fmt.Sprintf("%d unhealthy rules:\n%s", len(problems), strings.Join(lines, "\n"))
+}
+
+// ManifestGroups returns names of rule groups defined in a VMRule manifest.
+func ManifestGroups(path string) ([]string, error) {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rule vmv1beta1.VMRule
+	if err := yaml.Unmarshal(manifest, &rule); err != nil {
+		return nil, fmt.Errorf("cannot parse VMRule %s: %w", path, err)
+	}
+	names := make([]string, 0, len(rule.Spec.Groups))
+	for _, g := range rule.Spec.Groups {
+		names = append(names, g.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,122 @@
+package vmalert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seriesFetched(n int) *int { return &n }
+
+func TestRuleProblems(t *testing.T) {
+	t.Parallel()
+	evaluated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	groups := []Group{
+		{Name: "custom-rules", Rules: []Rule{
+			{Name: "CustomOK", Type: RuleTypeAlerting, Health: HealthOK, LastEvaluation: evaluated, LastSeriesFetched: seriesFetched(3)},
+			{Name: "CustomTypo", Type: RuleTypeAlerting, Health: HealthOK, LastEvaluation: evaluated, Query: "rate(vm_typo_total[5m]) > 0\n", LastSeriesFetched: seriesFetched(0)},
+			{Name: "custom:broken", Type: RuleTypeRecording, Health: HealthErr, LastEvaluation: evaluated, LastError: "cannot parse"},
+		}},
+		{Name: "etcd", Rules: []Rule{
+			{Name: "etcd:requests", Type: RuleTypeRecording, Health: HealthOK, LastEvaluation: evaluated, LastSeriesFetched: seriesFetched(0)},
+		}},
+		{Name: "kubernetes-apps", Rules: []Rule{
+			{Name: "KubePodCrashLooping", Type: RuleTypeAlerting, Health: HealthOK, LastEvaluation: evaluated, LastSeriesFetched: seriesFetched(0)},
+			// vmalert reports rules that were not evaluated yet as healthy.
+			{Name: "KubePodNotReady", Type: RuleTypeAlerting, Health: HealthOK},
+		}},
+	}
+
+	problems := RuleProblems(groups, HealthOptions{})
+	assert.Equal(t, []RuleProblem{
+		{Group: "custom-rules", Rule: "custom:broken", Problem: "evaluation failed: cannot parse"},
+		{Group: "kubernetes-apps", Rule: "KubePodNotReady", Problem: "rule is not evaluated yet"},
+	}, problems)
+
+	problems = RuleProblems(groups, HealthOptions{
+		Groups:        []string{"custom-rules", "missing"},
+		RequireSeries: []string{RuleTypeAlerting, RuleTypeRecording},
+	})
+	require.Len(t, problems, 3)
+	assert.Equal(t, "missing: group is not loaded", problems[0].String())
+	assert.Equal(t, "custom-rules/CustomTypo: no series match the query rate(vm_typo_total[5m]) > 0", problems[1].String())
+	assert.Equal(t, "custom:broken", problems[2].Rule)
+
+	problems = RuleProblems(groups[1:2], HealthOptions{RequireSeries: []string{RuleTypeRecording}})
+	require.Len(t, problems, 1)
+	problems = RuleProblems(groups[1:], HealthOptions{RequireSeries: []string{RuleTypeRecording}, NoMatchExceptions: []string{"etcd"}})
+	require.Len(t, problems, 1, "Only the rule that was not evaluated should be reported")
+	assert.Equal(t, "KubePodNotReady", problems[0].Rule)
+}
+
+const (
+	notEvaluatedResponse = `{"status":"success","data":{"groups":[{"name":"custom-rules","rules":[
+ {"name":"CustomOK","type":"alerting","health":"ok","lastEvaluation":"0001-01-01T00:00:00Z","lastSeriesFetched":null}
+]}]}}`
+	evaluatedResponse = `{"status":"success","data":{"groups":[{"name":"custom-rules","rules":[
+ {"name":"CustomOK","type":"alerting","health":"ok","lastEvaluation":"2026-01-02T03:04:05Z","lastSeriesFetched":3}
+]}]}}`
+)
+
+// newRulesClient returns a client of a server answering the n-th rules request with responses[n],
+// repeating the last response afterwards, and the number of served requests.
+func newRulesClient(t *testing.T, responses ...string) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := min(int(calls.Add(1))-1, len(responses)-1)
+		_, _ = fmt.Fprint(w, responses[n])
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.Client(), server.URL), &calls
+}
+
+func TestWaitRulesHealthy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	client, calls := newRulesClient(t, notEvaluatedResponse, evaluatedResponse)
+	problems, err := client.WaitRulesHealthy(ctx, HealthOptions{}, time.Minute, time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, int32(2), calls.Load())
+
+	client, calls = newRulesClient(t, notEvaluatedResponse)
+	problems, err = client.WaitRulesHealthy(ctx, HealthOptions{}, 0, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []RuleProblem{{Group: "custom-rules", Rule: "CustomOK", Problem: "rule is not evaluated yet"}}, problems)
+	assert.Equal(t, int32(1), calls.Load(), "A zero timeout should make a single attempt")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = client.WaitRulesHealthy(canceled, HealthOptions{}, time.Hour, time.Hour)
+	require.ErrorIs(t, err, context.Canceled, "Polling should stop once ctx is done")
+}
+
+func TestCheckRulesHealthy(t *testing.T) {
+	t.Parallel()
+	client, _ := newRulesClient(t, notEvaluatedResponse, evaluatedResponse)
+	client.CheckRulesHealthy(context.Background(), t, HealthOptions{RequireSeries: []string{RuleTypeAlerting}}, time.Minute, time.Millisecond)
+
+	assert.Equal(t, "2 unhealthy rules:\nmissing: group is not loaded\ncustom-rules/custom:broken: evaluation failed: cannot parse", problemsMessage([]RuleProblem{
+		{Group: "missing", Problem: "group is not loaded"},
+		{Group: "custom-rules", Rule: "custom:broken", Problem: "evaluation failed: cannot parse"},
+	}))
+}
+
+func TestManifestGroups(t *testing.T) {
+	t.Parallel()
+	groups, err := ManifestGroups("../../manifests/custom-alerts.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"custom-rules"}, groups)
+
+	_, err = ManifestGroups("missing.yaml")
+	require.Error(t, err)
+}
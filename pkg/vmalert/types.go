@@ -0,0 +1,98 @@
+package vmalert
+
+import "time"
+
+// Rule types reported by vmalert.
+const (
+	RuleTypeAlerting  = "alerting"
+	RuleTypeRecording = "recording"
+)
+
+// Rule health values reported by vmalert.
+const (
+	HealthOK      = "ok"
+	HealthErr     = "err"
+	HealthUnknown = "unknown"
+)
+
+// Group is a rule group as returned by /api/v1/rules.
+type Group struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	File string `json:"file"`
+	// Type is the datasource type of the group, e.g. prometheus.
+	Type string `json:"type"`
+	// Interval is the evaluation interval in seconds.
+	Interval       float64   `json:"interval"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	Concurrency    int       `json:"concurrency"`
+	Rules          []Rule    `json:"rules"`
+}
+
+// Rule returns the rule with the given name.
+func (g Group) Rule(name string) (Rule, bool) {
+	for _, r := range g.Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Rule is an alerting or recording rule with the state of its last evaluation.
+type Rule struct {
+	ID      string `json:"id"`
+	GroupID string `json:"group_id"`
+	Name    string `json:"name"`
+	// Type is RuleTypeAlerting or RuleTypeRecording.
+	Type  string `json:"type"`
+	Query string `json:"query"`
+	// State is inactive, pending or firing for alerting rules and empty for recording rules.
+	State string `json:"state"`
+	// Duration is the for duration of alerting rules in seconds.
+	Duration    float64           `json:"duration"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	// Health is HealthOK, HealthErr or HealthUnknown. vmalert reports HealthOK before
+	// the first evaluation as well, use Evaluated to tell such rules apart.
+	Health    string `json:"health"`
+	LastError string `json:"lastError"`
+	// EvaluationTime is the duration of the last evaluation in seconds.
+	EvaluationTime float64   `json:"evaluationTime"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	// LastSamples is the number of samples returned by the last evaluation.
+	LastSamples int `json:"lastSamples"`
+	// LastSeriesFetched is the number of series the last evaluation read from the datasource.
+	// It is nil before the first evaluation or if the datasource does not report it.
+	LastSeriesFetched *int    `json:"lastSeriesFetched"`
+	Alerts            []Alert `json:"alerts"`
+}
+
+// Evaluated reports whether the rule was evaluated at least once.
+func (r Rule) Evaluated() bool {
+	return !r.LastEvaluation.IsZero()
+}
+
+// NoMatch reports whether the last evaluation fetched no series, which vmalert shows as "no match".
+// It usually means a typo in a metric name or a target that is not scraped.
+// It is false before the first evaluation.
+func (r Rule) NoMatch() bool {
+	return r.LastSeriesFetched != nil && *r.LastSeriesFetched == 0
+}
+
+// Alert is an active alert of an alerting rule.
+type Alert struct {
+	ID      string `json:"id"`
+	RuleID  string `json:"rule_id"`
+	GroupID string `json:"group_id"`
+	Name    string `json:"name"`
+	// State is pending or firing.
+	State       string            `json:"state"`
+	Value       string            `json:"value"`
+	Expression  string            `json:"expression"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	ActiveAt    time.Time         `json:"activeAt"`
+	// Restored is set for alerts whose state was restored from the datasource after a restart.
+	Restored bool `json:"restored"`
+}
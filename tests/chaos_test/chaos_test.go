@@ -10,12 +10,15 @@ import (
 	terratesting "github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/vmimport"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/install"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/integrity"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/promquery"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/tests"
 )
@@ -80,17 +83,33 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 		Category     string
 		ChaosType    string
 		CheckAlerts  []string
+		// SilenceAlerts are expected to fire while the fault is injected. They are silenced
+		// in the scenario namespace for the duration of the spec.
+		SilenceAlerts []string
+		// LossBudget is the data loss tolerated while the fault is injected. The zero value tolerates none.
+		LossBudget integrity.Budget
 	}
 
-	// Helper function to run a chaos scenario
-	runChaosScenario := func(ctx context.Context, scenario ChaosScenario) {
-		overwatch, err := tests.SetupOverwatchClient(ctx, t)
+	var overwatch promquery.PrometheusClient
+
+	BeforeEach(func(ctx context.Context) {
+		var err error
+		overwatch, err = tests.SetupOverwatchClient(ctx, t)
 		require.NoError(t, err)
+	})
+
+	AfterEach(func(ctx context.Context) {
+		tests.ExpireSilences(ctx, t, overwatch)
+	})
 
+	// Helper function to run a chaos scenario
+	runChaosScenario := func(ctx context.Context, scenario ChaosScenario) {
 		namespace := fmt.Sprintf("vm-%s", scenario.ScenarioName)
 		kubeOpts := k8s.NewKubectlOptions("", "", namespace)
 
+		alertRecorder := tests.StartAlertRecorder(overwatch, namespace)
 		defer func() {
+			tests.AttachAlertTimeline(t, alertRecorder)
 			tests.GatherOnFailure(ctx, t, kubeOpts, namespace, consts.DefaultReleaseName)
 			install.DeleteVMCluster(t, kubeOpts, namespace)
 			tests.CleanupNamespace(t, kubeOpts, namespace)
@@ -100,6 +119,7 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 		tests.EnsureNamespaceExists(t, kubeOpts, namespace)
 
 		overwatch.CheckNoAlertsFiring(ctx, t, namespace, promquery.DefaultExceptions)
+		tests.SilenceAlerts(ctx, t, overwatch, namespace, scenario.SilenceAlerts...)
 
 		// Create new VMCluster object
 		vmclient := install.GetVMClient(t, kubeOpts)
@@ -142,8 +162,29 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 		logger.Default.Logf(t, "Setting vmagent remote write URL to %s", remoteWriteURL)
 		install.EnsureVMAgentRemoteWriteURL(ctx, t, vmclient, kubeOpts, consts.DefaultVMNamespace, consts.DefaultReleaseName, remoteWriteURL)
 
+		// Write sequence-numbered samples during the whole scenario to detect lost data
+		httpClient := tests.NewHTTPClient()
+		writer := tests.NewRemoteWriteBuilder().WithHTTPClient(httpClient).ForTenant(namespace, 0)
+		checker := integrity.NewChecker(func(ctx context.Context, ts []prompb.TimeSeries) error {
+			_, err := writer.SendWithResult(ctx, ts)
+			return err
+		}, integrity.Options{Labels: map[string]string{"scenario": scenario.ScenarioName}})
+		checker.Start(ctx)
+		defer checker.Stop()
+
 		By(fmt.Sprintf("Running %s scenario", scenario.ScenarioName))
 		install.RunChaosScenario(ctx, t, namespace, scenario.Category, scenario.ScenarioName, scenario.ChaosType)
+		checker.Stop()
+
+		By("Acknowledged samples are stored exactly once")
+		exporter := vmimport.NewClient(httpClient, vmimport.VMClusterEndpoints(consts.VMInsertHost(namespace), consts.VMSelectHost(namespace), 0))
+		report, err := checker.Verify(ctx, exporter)
+		require.NoError(t, err)
+		logger.Default.Logf(t, "Data integrity report for %s scenario:\n%s", scenario.ScenarioName, report)
+		if err := checker.LastError(); err != nil {
+			logger.Default.Logf(t, "Last failed integrity check write: %v", err)
+		}
+		require.NoError(t, scenario.LossBudget.Check(report))
 
 		if len(scenario.CheckAlerts) > 0 {
 			for _, alert := range scenario.CheckAlerts {
@@ -177,6 +218,8 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 					Category:     "pods",
 					ChaosType:    "podchaos",
 					CheckAlerts:  []string{"ServiceDown"},
+					// Samples buffered by vminsert for the failed vmstorage pod may be lost
+					LossBudget: integrity.Budget{MaxMissingRatio: 0.01},
 				},
 			),
 			Entry("vmselect pod failure",
@@ -203,6 +246,7 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 					Category:     "cpu",
 					ChaosType:    "stresschaos",
 					// CheckAlerts:  []string{"CustomCPUThrottlingHigh"},
+					SilenceAlerts: []string{"CPUThrottlingHigh"},
 				},
 			),
 			Entry("vmstorage CPU stress",
@@ -212,6 +256,7 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 					Category:     "cpu",
 					ChaosType:    "stresschaos",
 					// CheckAlerts:  []string{"CustomCPUThrottlingHigh"},
+					SilenceAlerts: []string{"CPUThrottlingHigh"},
 				},
 			),
 			Entry("vmselect CPU stress",
@@ -221,6 +266,7 @@ var _ = Describe("Chaos tests", Label("chaos-test"), func() {
 					Category:     "cpu",
 					ChaosType:    "stresschaos",
 					// CheckAlerts:  []string{"CustomCPUThrottlingHigh"},
+					SilenceAlerts: []string{"CPUThrottlingHigh"},
 				},
 			),
 		)
@@ -34,6 +34,8 @@ var (
 	namespace string
 	overwatch promquery.PrometheusClient
 	c         *http.Client
+	// alertRecorder records alerts of the namespace during every spec
+	alertRecorder *promquery.AlertRecorder
 )
 
 // Install VM from helm chart for the first process, set namespace for the rest
@@ -74,11 +76,13 @@ var _ = Describe("Distributed chart", Label("vmcluster"), func() {
 		var err error
 		overwatch, err = tests.SetupOverwatchClient(ctx, t)
 		require.NoError(t, err)
+		alertRecorder = tests.StartAlertRecorder(overwatch, namespace)
 
 		c = tests.NewHTTPClient()
 	})
 
 	AfterEach(func(ctx context.Context) {
+		tests.AttachAlertTimeline(t, alertRecorder)
 		kubeOpts := k8s.NewKubectlOptions("", "", namespace)
 		tests.GatherOnFailure(ctx, t, kubeOpts, namespace, consts.DefaultReleaseName)
 
@@ -111,7 +115,7 @@ var _ = Describe("Distributed chart", Label("vmcluster"), func() {
 			WithCount(10).
 			WithValue(1).
 			Build()
-		err := globalWriter.Send(fooTimeSeries)
+		err := globalWriter.Send(ctx, fooTimeSeries)
 		require.NoError(t, err)
 
 		By("Read data from global read endpoint")
@@ -120,9 +124,7 @@ var _ = Describe("Distributed chart", Label("vmcluster"), func() {
 			WithStartTime(overwatch.Start).
 			MustBuild()
 
-		_, value, err := tests.RetryVectorScan(ctx, t, namespace, globalProm, "foo_2", 5)
-		require.NoError(t, err)
-		require.Equal(t, value, model.SampleValue(1))
+		globalProm.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
 
 		for _, zone := range strings.Split(consts.DistributedZones(), ",") {
 			if zone == "" {
@@ -134,9 +136,24 @@ var _ = Describe("Distributed chart", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, zoneProm, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
+			zoneProm.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
+
+			By(fmt.Sprintf("Zone %s endpoint returns the same results as the global one", zone))
+			differ := promquery.Differ{
+				Left:      globalProm,
+				Right:     zoneProm,
+				LeftName:  "global",
+				RightName: zone,
+				Options:   promquery.GoldenOptions{Tolerance: 1e-9},
+			}
+			differ.CheckEqual(ctx, t,
+				`{__name__=~"foo_.*"}`,
+				`sum({__name__=~"foo_.*"})`,
+				`count({__name__=~"foo_.*"}) by (foo)`,
+				`max_over_time({__name__=~"foo_.*"}[5m])`,
+				`sum(count_over_time({__name__=~"foo_.*"}[5m]))`,
+				`label_replace(foo_2, "zone", "$1", "foo", "fooVal_(.*)")`,
+			)
 		}
 	})
 
@@ -1,10 +1,10 @@
 package functional_test
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
 	"testing"
@@ -15,18 +15,23 @@ import (
 	terratesting "github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
-	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
-	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
-	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
-	"google.golang.org/protobuf/proto"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/consts"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/datadog"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/graphite"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/influx"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/opentsdb"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/otlp"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/ingest/vmimport"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/install"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/promquery"
+	"github.com/VictoriaMetrics/end-to-end-tests/pkg/remotewrite"
 	"github.com/VictoriaMetrics/end-to-end-tests/pkg/tests"
 )
 
@@ -42,6 +47,8 @@ var (
 	namespace string
 	overwatch promquery.PrometheusClient
 	c         *http.Client
+	// alertRecorder records alerts of the namespace during every spec
+	alertRecorder *promquery.AlertRecorder
 )
 
 // Install VM from helm chart for the first process, set namespace for the rest
@@ -74,6 +81,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 		var err error
 		overwatch, err = tests.SetupOverwatchClient(ctx, t)
 		require.NoError(t, err)
+		alertRecorder = tests.StartAlertRecorder(overwatch, namespace)
 
 		// Create new VMCluster object
 		kubeOpts := k8s.NewKubectlOptions("", "", namespace)
@@ -84,6 +92,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 	})
 
 	AfterEach(func(ctx context.Context) {
+		tests.AttachAlertTimeline(t, alertRecorder)
 		kubeOpts := k8s.NewKubectlOptions("", "", namespace)
 		tests.GatherOnFailure(ctx, t, kubeOpts, namespace, consts.DefaultReleaseName)
 
@@ -91,6 +100,29 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 		tests.CleanupNamespace(t, kubeOpts, namespace)
 	})
 
+	Describe("Import and export", func() {
+		It("should export exactly the imported samples in every format per tenant", Label("id=4c1e8a57-9b2d-4f63-8e0a-7d5b3c9f1a26"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			tenant1 := vmimport.NewClient(c, vmimport.VMClusterEndpoints(consts.VMInsertHost(namespace), consts.VMSelectHost(namespace), 1))
+			tenant0 := vmimport.NewClient(c, vmimport.VMClusterEndpoints(consts.VMInsertHost(namespace), consts.VMSelectHost(namespace), 0))
+
+			end := time.Now().Add(-time.Minute)
+			for _, format := range []vmimport.Format{vmimport.FormatJSONLines, vmimport.FormatCSV, vmimport.FormatPrometheus, vmimport.FormatNative} {
+				By(fmt.Sprintf("Round-tripping data in %s format via tenant 1", format))
+				series := vmimport.GenSeries("cluster_import_"+string(format), 3, 20, end, 15*time.Second)
+				err := tenant1.RoundTrip(ctx, format, series)
+				require.NoError(t, err)
+
+				By(fmt.Sprintf("Verifying data in %s format is not visible in tenant 0", format))
+				exported, err := tenant0.Export(ctx, vmimport.FormatJSONLines, series)
+				require.NoError(t, err)
+				require.Empty(t, exported)
+			}
+		})
+	})
+
 	Describe("Multitenancy", func() {
 		It("should not mix data sent to different tenants", Label("id=66618081-b150-4b48-8180-ae1f53512117"), func(ctx context.Context) {
 			// Build remote write helpers for each tenant
@@ -107,7 +139,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(1).
 				Build()
-			err := tenant0Writer.Send(fooTimeSeries)
+			err := tenant0Writer.Send(ctx, fooTimeSeries)
 			require.NoError(t, err)
 
 			By("Inserting data into tenant 1")
@@ -115,7 +147,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(5).
 				Build()
-			err = tenant1Writer.Send(barTimeSeries)
+			err = tenant1Writer.Send(ctx, barTimeSeries)
 			require.NoError(t, err)
 
 			By("Verifying tenant 0 data is isolated")
@@ -125,11 +157,9 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, tenant0Prom, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
+			tenant0Prom.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
 
-			_, value, err = tenant0Prom.VectorScan(ctx, "bar_2")
+			_, value, err := tenant0Prom.VectorScan(ctx, "bar_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
 
@@ -140,9 +170,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err = tests.RetryVectorScan(ctx, t, namespace, tenant1Prom, "bar_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			tenant1Prom.CheckEventually(ctx, t, "bar_2", promquery.ValueEquals(5), promquery.PollOptions{})
 
 			_, value, err = tenant1Prom.VectorScan(ctx, "foo_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
@@ -155,13 +183,12 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err = tests.RetryVectorScan(ctx, t, namespace, multitenantProm, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
+			multitenantProm.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
+			multitenantProm.CheckEventually(ctx, t, "bar_2", promquery.ValueEquals(5), promquery.PollOptions{})
 
-			_, value, err = tests.RetryVectorScan(ctx, t, namespace, multitenantProm, "bar_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			By("Verifying series of both tenants are labeled with their tenant")
+			multitenantProm.CheckLabelValues(ctx, t, "vm_account_id", `{__name__=~"foo_.*|bar_.*"}`, "0", "1")
+			multitenantProm.CheckSeriesCount(ctx, t, `{__name__=~"bar_.*",vm_account_id="1"}`, 10)
 		})
 
 		It("should accept data via multitenant URL", Label("id=16c08934-9e25-45ed-a94b-4fbbbe3170ef"), func(ctx context.Context) {
@@ -176,7 +203,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithValue(1).
 				WithTenantLabel(0).
 				Build()
-			err := multitenantWriter.Send(fooTimeSeries)
+			err := multitenantWriter.Send(ctx, fooTimeSeries)
 			require.NoError(t, err)
 
 			By("Inserting data into tenant 1 via multitenant endpoint")
@@ -185,7 +212,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithValue(5).
 				WithTenantLabel(1).
 				Build()
-			err = multitenantWriter.Send(barTimeSeries)
+			err = multitenantWriter.Send(ctx, barTimeSeries)
 			require.NoError(t, err)
 
 			By("Verifying tenant 0 data is isolated")
@@ -195,11 +222,9 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, tenant0Prom, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
+			tenant0Prom.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
 
-			_, value, err = tenant0Prom.VectorScan(ctx, "bar_2")
+			_, value, err := tenant0Prom.VectorScan(ctx, "bar_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
 
@@ -210,9 +235,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err = tests.RetryVectorScan(ctx, t, namespace, tenant1Prom, "bar_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			tenant1Prom.CheckEventually(ctx, t, "bar_2", promquery.ValueEquals(5), promquery.PollOptions{})
 
 			_, value, err = tenant1Prom.VectorScan(ctx, "foo_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
@@ -234,7 +257,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(1).
 				Build()
-			err := tenant0Writer.Send(fooTimeSeries)
+			err := tenant0Writer.Send(ctx, fooTimeSeries)
 			require.NoError(t, err)
 
 			By("Inserting data into tenant 1")
@@ -242,7 +265,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(5).
 				Build()
-			err = tenant1Writer.Send(barTimeSeries)
+			err = tenant1Writer.Send(ctx, barTimeSeries)
 			require.NoError(t, err)
 
 			By("Verifying data can be retrieved via multitenant URL")
@@ -252,13 +275,8 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, multitenantProm, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
-
-			_, value, err = tests.RetryVectorScan(ctx, t, namespace, multitenantProm, "bar_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			multitenantProm.CheckEventually(ctx, t, "foo_2", promquery.ValueEquals(1), promquery.PollOptions{})
+			multitenantProm.CheckEventually(ctx, t, "bar_2", promquery.ValueEquals(5), promquery.PollOptions{})
 		})
 	})
 
@@ -310,7 +328,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(1).
 				Build()
-			err = vmagentWriter.Send(fooTimeSeries)
+			err = vmagentWriter.Send(ctx, fooTimeSeries)
 			require.NoError(t, err)
 
 			By("Inserting bar data (should be dropped)")
@@ -318,7 +336,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(10).
 				WithValue(5).
 				Build()
-			err = vmagentWriter.Send(barTimeSeries)
+			err = vmagentWriter.Send(ctx, barTimeSeries)
 			require.NoError(t, err)
 
 			By("foo has cluster=dev label")
@@ -328,16 +346,18 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			labels, value, err := tests.RetryVectorScan(ctx, t, namespace, tenantProm, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
-			require.Contains(t, labels, model.LabelName("cluster"))
-			require.Equal(t, labels["cluster"], model.LabelValue("dev"))
+			tenantProm.CheckEventually(ctx, t, "foo_2",
+				promquery.All(promquery.ValueEquals(1), promquery.HasLabels(model.Metric{"cluster": "dev"})), promquery.PollOptions{})
+
+			By("All foo series have cluster=dev label")
+			tenantProm.CheckLabelValues(ctx, t, "cluster", `{__name__=~"foo_.*"}`, "dev")
+			tenantProm.CheckSeriesCount(ctx, t, `{__name__=~"foo_.*",cluster="dev"}`, 10)
 
 			By("bar_2 was removed")
-			_, value, err = tenantProm.VectorScan(ctx, "bar_2")
+			_, value, err := tenantProm.VectorScan(ctx, "bar_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
+			tenantProm.CheckSeriesCount(ctx, t, `{__name__=~"bar_.*"}`, 0)
 		})
 	})
 
@@ -388,7 +408,7 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithCount(3).
 					WithValue(1).
 					Build()
-				err = vmagentWriter.Send(aggrTimeSeries)
+				err = vmagentWriter.Send(ctx, aggrTimeSeries)
 				require.NoError(t, err)
 				time.Sleep(2 * time.Second)
 			}
@@ -398,12 +418,9 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithCount(3).
 				WithValue(100).
 				Build()
-			err = vmagentWriter.Send(nonAggrTimeSeries)
+			err = vmagentWriter.Send(ctx, nonAggrTimeSeries)
 			require.NoError(t, err)
 
-			By("Waiting for aggregation interval to pass")
-			tests.WaitForAggregation()
-
 			By("Verifying aggregated metrics exist with correct naming")
 			prom := tests.NewPromClientBuilder().
 				WithNamespace(namespace).
@@ -411,17 +428,20 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "sum_over_time(cluster_aggr_test_0:30s_without_bar_baz_foo_sum_samples[5m])", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			prom.CheckEventually(ctx, t, "sum_over_time(cluster_aggr_test_0:30s_without_bar_baz_foo_sum_samples[5m])",
+				promquery.ValueEquals(5), promquery.PollOptions{Timeout: 3 * consts.AggregationWaitTime})
 
 			By("Verifying non-matching metrics are written as-is")
-			_, value, err = prom.VectorScan(ctx, "cluster_nonaggr_0")
+			vec, err := prom.VectorScanAll(ctx, `{__name__=~"cluster_nonaggr_.*"}`)
 			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(100))
+			require.Len(t, vec, 3)
+			for i, sample := range vec {
+				require.Equal(t, model.LabelValue(fmt.Sprintf("cluster_nonaggr_%d", i)), sample.Metric[model.MetricNameLabel])
+				require.Equal(t, model.SampleValue(100), sample.Value)
+			}
 
 			By("Verifying original aggr metrics are dropped")
-			_, value, err = prom.VectorScan(ctx, "cluster_aggr_test_0")
+			_, value, err := prom.VectorScan(ctx, "cluster_aggr_test_0")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
 		})
@@ -455,12 +475,16 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				install.ExposeVMAgentAsIngress(ctx, t, kubeOpts, namespace)
 
 				By("Inserting data via InfluxDB protocol")
-				influxURL := fmt.Sprintf("http://%s/write", consts.VMAgentNamespacedHost(namespace))
-				data := "influx_test,foo=bar value=123"
-				resp, err := c.Post(influxURL, "", strings.NewReader(data))
+				point := influx.Point{
+					Measurement: "influx_test",
+					Tags:        map[string]string{"foo": "bar"},
+					Fields:      map[string]any{"value": 123.0},
+				}
+				influxClient := influx.NewClient(c, influx.VMAgentURL(consts.VMAgentNamespacedHost(namespace)), influx.Options{})
+				status, err := influxClient.Write(ctx, point)
 				require.NoError(t, err)
-				require.Equal(t, http.StatusNoContent, resp.StatusCode)
-				_ = resp.Body.Close()
+				require.Equal(t, http.StatusNoContent, status)
+				expected := influx.ExpectedSeries([]influx.Point{point}, influx.Options{})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -469,10 +493,8 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "influx_test_value", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(model.Metric{"foo": "bar"})), promquery.PollOptions{})
 			})
 
 			It("should ingest data via influxdb protocol to vminsert", Label("id=11223344-5566-7788-9900-aabbccddeeff"), func(ctx context.Context) {
@@ -480,12 +502,16 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
 
 				By("Inserting data via InfluxDB protocol")
-				influxURL := fmt.Sprintf("http://%s/insert/0/influx/write", consts.VMInsertHost(namespace))
-				data := "influx_vminsert_test,foo=bar value=123"
-				resp, err := c.Post(influxURL, "", strings.NewReader(data))
+				point := influx.Point{
+					Measurement: "influx_vminsert_test",
+					Tags:        map[string]string{"foo": "bar"},
+					Fields:      map[string]any{"value": 123.0},
+				}
+				influxClient := influx.NewClient(c, influx.VMInsertURL(consts.VMInsertHost(namespace), 0), influx.Options{})
+				status, err := influxClient.Write(ctx, point)
 				require.NoError(t, err)
-				require.Equal(t, http.StatusNoContent, resp.StatusCode)
-				_ = resp.Body.Close()
+				require.Equal(t, http.StatusNoContent, status)
+				expected := influx.ExpectedSeries([]influx.Point{point}, influx.Options{})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -494,10 +520,8 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "influx_vminsert_test_value", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(model.Metric{"foo": "bar"})), promquery.PollOptions{})
 			})
 		})
 
@@ -528,31 +552,18 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				install.ExposeVMAgentAsIngress(ctx, t, kubeOpts, namespace)
 
 				By("Inserting data via Datadog protocol")
-				datadogURL := fmt.Sprintf("http://%s/datadog/api/v1/series", consts.VMAgentNamespacedHost(namespace))
-				now := time.Now().Unix()
-				ddSeries := tests.DatadogSeries{
-					Series: []tests.DatadogMetric{
-						{
-							Metric: "datadog.test.metric",
-							Points: [][]interface{}{
-								{now, 123},
-							},
-							Tags: []string{
-								"env:test",
-								"foo:bar",
-							},
-							Host: "test-host",
-							Type: "gauge",
-						},
-					},
+				metric := datadog.Metric{
+					Name:   "datadog.test.metric",
+					Type:   datadog.MetricTypeGauge,
+					Points: []datadog.Point{{Time: time.Now(), Value: 123}},
+					Tags:   []string{"env:test", "foo:bar"},
+					Host:   "test-host",
 				}
-				data, err := json.Marshal(ddSeries)
-				require.NoError(t, err)
-
-				resp, err := c.Post(datadogURL, "application/json", bytes.NewReader(data))
+				ddClient := datadog.NewClient(c, datadog.VMAgentURL(consts.VMAgentNamespacedHost(namespace)), datadog.Options{})
+				status, err := ddClient.SubmitSeriesV1(ctx, metric)
 				require.NoError(t, err)
-				require.Equal(t, resp.StatusCode, http.StatusAccepted)
-				_ = resp.Body.Close()
+				require.Equal(t, http.StatusAccepted, status)
+				expected := datadog.ExpectedSeries([]datadog.Metric{metric})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -561,12 +572,8 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "datadog.test.metric", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["env"], model.LabelValue("test"))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
-				require.Equal(t, labels["host"], model.LabelValue("test-host"))
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(expected.Metric())), promquery.PollOptions{})
 			})
 
 			It("should ingest data via datadog protocol to vminsert", Label("id=aabbccdd-1122-3344-5566-77889900aabb"), func(ctx context.Context) {
@@ -574,31 +581,47 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
 
 				By("Inserting data via Datadog protocol")
-				datadogURL := fmt.Sprintf("http://%s/insert/0/datadog/api/v1/series", consts.VMInsertHost(namespace))
-				now := time.Now().Unix()
-				ddSeries := tests.DatadogSeries{
-					Series: []tests.DatadogMetric{
-						{
-							Metric: "datadog.vminsert.test.metric",
-							Points: [][]interface{}{
-								{now, 123},
-							},
-							Tags: []string{
-								"env:test",
-								"foo:bar",
-							},
-							Host: "test-host",
-							Type: "gauge",
-						},
-					},
+				metric := datadog.Metric{
+					Name:   "datadog.vminsert.test.metric",
+					Type:   datadog.MetricTypeGauge,
+					Points: []datadog.Point{{Time: time.Now(), Value: 123}},
+					Tags:   []string{"env:test", "foo:bar"},
+					Host:   "test-host",
 				}
-				data, err := json.Marshal(ddSeries)
+				ddClient := datadog.NewClient(c, datadog.VMInsertURL(consts.VMInsertHost(namespace), 0), datadog.Options{})
+				status, err := ddClient.SubmitSeriesV1(ctx, metric)
 				require.NoError(t, err)
+				require.Equal(t, http.StatusAccepted, status)
+				expected := datadog.ExpectedSeries([]datadog.Metric{metric})[0]
+
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					WithNamespace(namespace).
+					WithTenant(0).
+					WithStartTime(overwatch.Start).
+					MustBuild()
+
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(expected.Metric())), promquery.PollOptions{})
+			})
 
-				resp, err := c.Post(datadogURL, "application/json", bytes.NewReader(data))
+			It("should ingest data via datadog v2 protocol with zstd compression to vminsert", Label("id=7bf4ae1b-be2d-4ce1-83f2-f601cebf4047"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+				By("Inserting data via Datadog protocol")
+				metric := datadog.Metric{
+					Name:   "datadog.vminsert.v2.test.metric",
+					Type:   datadog.MetricTypeGauge,
+					Points: []datadog.Point{{Time: time.Now(), Value: 123}},
+					Tags:   []string{"env:test", "foo:bar"},
+					Host:   "test-host",
+				}
+				ddClient := datadog.NewClient(c, datadog.VMInsertURL(consts.VMInsertHost(namespace), 0), datadog.Options{Compression: datadog.CompressionZstd})
+				status, err := ddClient.SubmitSeriesV2(ctx, metric)
 				require.NoError(t, err)
-				require.Equal(t, http.StatusAccepted, resp.StatusCode)
-				_ = resp.Body.Close()
+				require.Equal(t, http.StatusAccepted, status)
+				expected := datadog.ExpectedSeries([]datadog.Metric{metric})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -607,71 +630,75 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "datadog.vminsert.test.metric", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["env"], model.LabelValue("test"))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
-				require.Equal(t, labels["host"], model.LabelValue("test-host"))
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(expected.Metric())), promquery.PollOptions{})
 			})
 		})
 
-		Context("OpenTelemetry", func() {
-			It("should ingest data via opentelemetry protocol to vminsert", Label("id=4e7c8581-2c93-4796-9817-219586111111"), func(ctx context.Context) {
+		Context("Graphite", func() {
+			It("should ingest data via graphite plaintext protocol to vminsert", Label("id=0c6f3b2e-8d41-4a57-b9e2-5f7a1c3d9e84"), func(ctx context.Context) {
 				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
 				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+				vmclient := install.GetVMClient(t, kubeOpts)
 
-				By("Inserting data via OpenTelemetry protocol")
-				otelURL := fmt.Sprintf("http://%s/insert/0/opentelemetry/v1/metrics", consts.VMInsertHost(namespace))
+				By("Enabling Graphite listener on vminsert")
+				patch := tests.NewJSONPatchBuilder().
+					WithVMInsertListeners(install.GraphiteListener).
+					MustBuild()
+				install.InstallVMCluster(ctx, t, kubeOpts, namespace, vmclient, []jsonpatch.Patch{patch})
+				k8s.RunKubectl(t, kubeOpts, "rollout", "status", "deployment/vminsert-"+consts.DefaultVMClusterName, fmt.Sprintf("--timeout=%s", consts.ResourceWaitTimeout))
+				svc := install.ExposeVMInsertListeners(t, kubeOpts, namespace, install.GraphiteListener)
+				tunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.GraphiteListener)
+				defer tunnel.Close()
+
+				By("Inserting plain and tagged series via Graphite protocol")
+				now := time.Now()
+				metrics := []graphite.Metric{
+					{Path: "graphite.vminsert.plain", Value: 1, Time: now},
+					{Path: "graphite.vminsert.tagged", Tags: map[string]string{"env": "test", "foo": "bar"}, Value: 2, Time: now},
+				}
+				err := graphite.NewClient("tcp", tunnel.Endpoint()).Send(ctx, metrics...)
+				require.NoError(t, err)
 
-				timestamp := time.Now().UnixNano()
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					WithNamespace(namespace).
+					WithTenant(0).
+					WithStartTime(overwatch.Start).
+					MustBuild()
 
-				// Construct OTLP Protobuf payload
-				req := &colmetricspb.ExportMetricsServiceRequest{
-					ResourceMetrics: []*metricspb.ResourceMetrics{
-						{
-							ScopeMetrics: []*metricspb.ScopeMetrics{
-								{
-									Metrics: []*metricspb.Metric{
-										{
-											Name: "otel_test_metric",
-											Data: &metricspb.Metric_Gauge{
-												Gauge: &metricspb.Gauge{
-													DataPoints: []*metricspb.NumberDataPoint{
-														{
-															TimeUnixNano: uint64(timestamp),
-															Value: &metricspb.NumberDataPoint_AsInt{
-																AsInt: 123,
-															},
-															Attributes: []*commonpb.KeyValue{
-																{
-																	Key: "foo",
-																	Value: &commonpb.AnyValue{
-																		Value: &commonpb.AnyValue_StringValue{
-																			StringValue: "bar",
-																		},
-																	},
-																},
-															},
-														},
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
+				for _, expected := range graphite.ExpectedSeries(metrics) {
+					prom.CheckEventually(ctx, t, expected.Selector(), promquery.ValueEquals(expected.Value), promquery.PollOptions{})
 				}
+			})
+		})
 
-				data, err := proto.Marshal(req)
-				require.NoError(t, err)
+		Context("OpenTSDB", func() {
+			It("should ingest data via opentsdb telnet and http protocols to vminsert", Label("id=6a9d2f47-3b8c-4e15-a0d6-c2e8b7f41935"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+				vmclient := install.GetVMClient(t, kubeOpts)
 
-				resp, err := c.Post(otelURL, "application/x-protobuf", bytes.NewReader(data))
+				By("Enabling OpenTSDB listeners on vminsert")
+				patch := tests.NewJSONPatchBuilder().
+					WithVMInsertListeners(install.OpenTSDBListener, install.OpenTSDBHTTPListener).
+					MustBuild()
+				install.InstallVMCluster(ctx, t, kubeOpts, namespace, vmclient, []jsonpatch.Patch{patch})
+				k8s.RunKubectl(t, kubeOpts, "rollout", "status", "deployment/vminsert-"+consts.DefaultVMClusterName, fmt.Sprintf("--timeout=%s", consts.ResourceWaitTimeout))
+				svc := install.ExposeVMInsertListeners(t, kubeOpts, namespace, install.OpenTSDBListener, install.OpenTSDBHTTPListener)
+				telnetTunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.OpenTSDBListener)
+				defer telnetTunnel.Close()
+				httpTunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.OpenTSDBHTTPListener)
+				defer httpTunnel.Close()
+
+				By("Inserting data via OpenTSDB telnet and HTTP protocols")
+				now := time.Now()
+				telnetPoint := opentsdb.Point{Metric: "opentsdb.vminsert.telnet", Tags: map[string]string{"foo": "bar"}, Value: 1, Time: now}
+				err := opentsdb.NewTelnetClient(telnetTunnel.Endpoint()).Put(ctx, telnetPoint)
+				require.NoError(t, err)
+				httpPoint := opentsdb.Point{Metric: "opentsdb.vminsert.http", Tags: map[string]string{"foo": "bar"}, Value: 2, Time: now}
+				err = opentsdb.NewHTTPClient(c, opentsdb.VMInsertHTTPURL(httpTunnel.Endpoint(), 0)).Put(ctx, httpPoint)
 				require.NoError(t, err)
-				require.Equal(t, http.StatusOK, resp.StatusCode)
-				_ = resp.Body.Close()
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -680,10 +707,34 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "otel_test_metric", 5)
+				for _, expected := range opentsdb.ExpectedSeries([]opentsdb.Point{telnetPoint, httpPoint}) {
+					prom.CheckEventually(ctx, t, expected.Selector(), promquery.ValueEquals(expected.Value), promquery.PollOptions{})
+				}
+			})
+		})
+
+		Context("OpenTelemetry", func() {
+			It("should ingest data via opentelemetry protocol to vminsert", Label("id=4e7c8581-2c93-4796-9817-219586111111"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+				By("Inserting data via OpenTelemetry protocol")
+				otelReq := otlp.NewBuilder().
+					AddGauge("otel_test_metric", "", 123, map[string]string{"foo": "bar"})
+				otelClient := otlp.NewClient(c, otlp.VMInsertURL(consts.VMInsertHost(namespace), 0), otlp.Options{})
+				err := otelClient.Send(ctx, otelReq.Build())
 				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
+				expected := otelReq.ExpectedSeries(otlp.NamingOptions{})[0]
+
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					WithNamespace(namespace).
+					WithTenant(0).
+					WithStartTime(overwatch.Start).
+					MustBuild()
+
+				prom.CheckEventually(ctx, t, expected.Selector(),
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.MetricEquals(expected.Metric())), promquery.PollOptions{})
 			})
 
 			It("should ingest data via opentelemetry protocol to vmagent", Label("id=55667788-9900-aabb-ccdd-eeff11223344"), func(ctx context.Context) {
@@ -712,56 +763,12 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 				install.ExposeVMAgentAsIngress(ctx, t, kubeOpts, namespace)
 
 				By("Inserting data via OpenTelemetry protocol")
-				otelURL := fmt.Sprintf("http://%s/opentelemetry/v1/metrics", consts.VMAgentNamespacedHost(namespace))
-
-				timestamp := time.Now().UnixNano()
-
-				// Construct OTLP Protobuf payload
-				req := &colmetricspb.ExportMetricsServiceRequest{
-					ResourceMetrics: []*metricspb.ResourceMetrics{
-						{
-							ScopeMetrics: []*metricspb.ScopeMetrics{
-								{
-									Metrics: []*metricspb.Metric{
-										{
-											Name: "otel_vmagent_test_metric",
-											Data: &metricspb.Metric_Gauge{
-												Gauge: &metricspb.Gauge{
-													DataPoints: []*metricspb.NumberDataPoint{
-														{
-															TimeUnixNano: uint64(timestamp),
-															Value: &metricspb.NumberDataPoint_AsInt{
-																AsInt: 456,
-															},
-															Attributes: []*commonpb.KeyValue{
-																{
-																	Key: "foo",
-																	Value: &commonpb.AnyValue{
-																		Value: &commonpb.AnyValue_StringValue{
-																			StringValue: "baz",
-																		},
-																	},
-																},
-															},
-														},
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				}
-
-				data, err := proto.Marshal(req)
-				require.NoError(t, err)
-
-				resp, err := c.Post(otelURL, "application/x-protobuf", bytes.NewReader(data))
+				otelReq := otlp.NewBuilder().
+					AddGauge("otel_vmagent_test_metric", "", 456, map[string]string{"foo": "baz"})
+				otelClient := otlp.NewClient(c, otlp.VMAgentURL(consts.VMAgentNamespacedHost(namespace)), otlp.Options{})
+				err = otelClient.Send(ctx, otelReq.Build())
 				require.NoError(t, err)
-				require.Equal(t, http.StatusOK, resp.StatusCode)
-				_ = resp.Body.Close()
+				expected := otelReq.ExpectedSeries(otlp.NamingOptions{})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -770,10 +777,8 @@ var _ = Describe("VMCluster test", Label("vmcluster"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "otel_vmagent_test_metric", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(456))
-				require.Equal(t, labels["foo"], model.LabelValue("baz"))
+				prom.CheckEventually(ctx, t, expected.Selector(),
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.MetricEquals(expected.Metric())), promquery.PollOptions{})
 			})
 		})
 	})
@@ -784,11 +789,13 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 		var err error
 		overwatch, err = tests.SetupOverwatchClient(ctx, t)
 		require.NoError(t, err)
+		alertRecorder = tests.StartAlertRecorder(overwatch, namespace)
 
 		c = tests.NewHTTPClient()
 	})
 
 	AfterEach(func(ctx context.Context) {
+		tests.AttachAlertTimeline(t, alertRecorder)
 		kubeOpts := k8s.NewKubectlOptions("", "", namespace)
 		tests.GatherOnFailure(ctx, t, kubeOpts, namespace, consts.DefaultReleaseName)
 		install.DeleteVMSingle(t, kubeOpts, namespace)
@@ -833,7 +840,7 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithCount(10).
 				WithValue(1).
 				Build()
-			err = remoteWriter.Send(fooTimeSeries)
+			err = remoteWriter.Send(ctx, fooTimeSeries)
 			require.NoError(t, err)
 
 			By("Inserting bar data (should be dropped)")
@@ -841,7 +848,7 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithCount(10).
 				WithValue(5).
 				Build()
-			err = remoteWriter.Send(barTimeSeries)
+			err = remoteWriter.Send(ctx, barTimeSeries)
 			require.NoError(t, err)
 
 			By("foo has cluster=dev label")
@@ -850,14 +857,11 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "foo_2", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(1))
-			require.Contains(t, labels, model.LabelName("cluster"))
-			require.Equal(t, labels["cluster"], model.LabelValue("dev"))
+			prom.CheckEventually(ctx, t, "foo_2",
+				promquery.All(promquery.ValueEquals(1), promquery.HasLabels(model.Metric{"cluster": "dev"})), promquery.PollOptions{})
 
 			By("bar_2 was removed")
-			_, value, err = prom.VectorScan(ctx, "bar_2")
+			_, value, err := prom.VectorScan(ctx, "bar_2")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
 		})
@@ -902,7 +906,7 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 					WithCount(3).
 					WithValue(1).
 					Build()
-				err = remoteWriter.Send(aggrTimeSeries)
+				err = remoteWriter.Send(ctx, aggrTimeSeries)
 				require.NoError(t, err)
 				time.Sleep(2 * time.Second)
 			}
@@ -912,29 +916,29 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithCount(3).
 				WithValue(100).
 				Build()
-			err = remoteWriter.Send(nonAggrTimeSeries)
+			err = remoteWriter.Send(ctx, nonAggrTimeSeries)
 			require.NoError(t, err)
 
-			By("Waiting for aggregation interval to pass")
-			tests.WaitForAggregation()
-
 			By("Verifying aggregated metrics exist with correct naming")
 			prom := tests.NewPromClientBuilder().
 				ForVMSingle(namespace).
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "sum_over_time(aggr_test_0:30s_without_bar_baz_foo_sum_samples[5m])", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(5))
+			prom.CheckEventually(ctx, t, "sum_over_time(aggr_test_0:30s_without_bar_baz_foo_sum_samples[5m])",
+				promquery.ValueEquals(5), promquery.PollOptions{Timeout: 3 * consts.AggregationWaitTime})
 
 			By("Verifying non-matching metrics are written as-is")
-			_, value, err = prom.VectorScan(ctx, "nonaggr_0")
+			vec, err := prom.VectorScanAll(ctx, `{__name__=~"nonaggr_.*"}`)
 			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(100))
+			require.Len(t, vec, 3)
+			for i, sample := range vec {
+				require.Equal(t, model.LabelValue(fmt.Sprintf("nonaggr_%d", i)), sample.Metric[model.MetricNameLabel])
+				require.Equal(t, model.SampleValue(100), sample.Value)
+			}
 
 			By("Verifying original aggr metrics are dropped")
-			_, value, err = prom.VectorScan(ctx, "aggr_test_0")
+			_, value, err := prom.VectorScan(ctx, "aggr_test_0")
 			require.EqualError(t, err, consts.ErrNoDataReturned)
 			require.Equal(t, value, model.SampleValue(0))
 		})
@@ -950,12 +954,16 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
 
 				By("Inserting data via InfluxDB protocol")
-				influxURL := fmt.Sprintf("http://%s/write", consts.VMSingleNamespacedHost(namespace))
-				data := "influx_test,foo=bar value=123"
-				resp, err := c.Post(influxURL, "", strings.NewReader(data))
+				point := influx.Point{
+					Measurement: "influx_test",
+					Tags:        map[string]string{"foo": "bar"},
+					Fields:      map[string]any{"value": 123.0},
+				}
+				influxClient := influx.NewClient(c, influx.VMSingleURL(consts.VMSingleNamespacedHost(namespace)), influx.Options{})
+				status, err := influxClient.Write(ctx, point)
 				require.NoError(t, err)
-				require.Equal(t, http.StatusNoContent, resp.StatusCode)
-				_ = resp.Body.Close()
+				require.Equal(t, http.StatusNoContent, status)
+				expected := influx.ExpectedSeries([]influx.Point{point}, influx.Options{})[0]
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -963,10 +971,8 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "influx_test_value", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(model.Metric{"foo": "bar"})), promquery.PollOptions{})
 			})
 		})
 
@@ -979,31 +985,88 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
 
 				By("Inserting data via Datadog protocol")
-				datadogURL := fmt.Sprintf("http://%s/datadog/api/v1/series", consts.VMSingleNamespacedHost(namespace))
-				now := time.Now().Unix()
-				ddSeries := tests.DatadogSeries{
-					Series: []tests.DatadogMetric{
-						{
-							Metric: "datadog.test.metric",
-							Points: [][]interface{}{
-								{now, 123},
-							},
-							Tags: []string{
-								"env:test",
-								"foo:bar",
-							},
-							Host: "test-host",
-							Type: "gauge",
-						},
-					},
+				metric := datadog.Metric{
+					Name:   "datadog.test.metric",
+					Type:   datadog.MetricTypeGauge,
+					Points: []datadog.Point{{Time: time.Now(), Value: 123}},
+					Tags:   []string{"env:test", "foo:bar"},
+					Host:   "test-host",
+				}
+				ddClient := datadog.NewClient(c, datadog.VMSingleURL(consts.VMSingleNamespacedHost(namespace)), datadog.Options{Compression: datadog.CompressionDeflate})
+				status, err := ddClient.SubmitSeriesV1(ctx, metric)
+				require.NoError(t, err)
+				require.Equal(t, http.StatusAccepted, status)
+				expected := datadog.ExpectedSeries([]datadog.Metric{metric})[0]
+
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					ForVMSingle(namespace).
+					WithStartTime(overwatch.Start).
+					MustBuild()
+
+				prom.CheckEventually(ctx, t, expected.Name,
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.HasLabels(expected.Metric())), promquery.PollOptions{})
+			})
+
+			It("should ingest datadog sketches", Label("id=2d7f0b8e-6a41-4c3e-9f5d-1b8a7c2e4d60"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+				vmclient := install.GetVMClient(t, kubeOpts)
+				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+				By("Inserting sketches via Datadog protocol")
+				sketch := datadog.Sketch{
+					Name:   "datadog.test.latency",
+					Host:   "test-host",
+					Tags:   []string{"env:test"},
+					Time:   time.Now(),
+					Values: []float64{0.1, 0.2, 0.2, 0.5, 1.5},
 				}
-				data, err := json.Marshal(ddSeries)
+				ddClient := datadog.NewClient(c, datadog.VMSingleURL(consts.VMSingleNamespacedHost(namespace)), datadog.Options{Compression: datadog.CompressionZstd})
+				status, err := ddClient.SubmitSketches(ctx, sketch)
 				require.NoError(t, err)
+				require.Equal(t, http.StatusAccepted, status)
+
+				By("Verifying sketch series via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					ForVMSingle(namespace).
+					WithStartTime(overwatch.Start).
+					MustBuild()
 
-				resp, err := c.Post(datadogURL, "application/json", bytes.NewReader(data))
+				for _, expected := range datadog.ExpectedSketchSeries([]datadog.Sketch{sketch}) {
+					pred := promquery.Present()
+					if !math.IsNaN(expected.Value) {
+						pred = promquery.ValueInDelta(expected.Value, 1e-9)
+					}
+					prom.CheckEventually(ctx, t, expected.Selector(), pred, promquery.PollOptions{})
+				}
+			})
+		})
+
+		Context("Graphite", func() {
+			It("should ingest data via graphite plaintext protocol", Label("id=e1b74c93-2a6d-4f08-8c35-9d0f6a2b7e51"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+				By("Installing VMSingle with Graphite listener")
+				patch := tests.NewJSONPatchBuilder().
+					WithListeners(install.GraphiteListener).
+					MustBuild()
+				vmclient := install.GetVMClient(t, kubeOpts)
+				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, []jsonpatch.Patch{patch})
+				svc := install.ExposeVMSingleListeners(t, kubeOpts, namespace, install.GraphiteListener)
+				tunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.GraphiteListener)
+				defer tunnel.Close()
+
+				By("Inserting plain and tagged series via Graphite protocol")
+				now := time.Now()
+				metrics := []graphite.Metric{
+					{Path: "graphite.test.plain", Value: 1, Time: now},
+					{Path: "graphite.test.tagged", Tags: map[string]string{"env": "test", "foo": "bar"}, Value: 2, Time: now},
+				}
+				err := graphite.NewClient("tcp", tunnel.Endpoint()).Send(ctx, metrics...)
 				require.NoError(t, err)
-				require.Equal(t, resp.StatusCode, http.StatusAccepted)
-				_ = resp.Body.Close()
 
 				By("Verifying data via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
@@ -1011,12 +1074,47 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "datadog.test.metric", 5)
+				for _, expected := range graphite.ExpectedSeries(metrics) {
+					prom.CheckEventually(ctx, t, expected.Selector(), promquery.ValueEquals(expected.Value), promquery.PollOptions{})
+				}
+			})
+		})
+
+		Context("OpenTSDB", func() {
+			It("should ingest data via opentsdb telnet and http protocols", Label("id=93f5a0d8-7c2e-4b61-ae49-1d8c6b3f2a70"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+				By("Installing VMSingle with OpenTSDB listeners")
+				patch := tests.NewJSONPatchBuilder().
+					WithListeners(install.OpenTSDBListener, install.OpenTSDBHTTPListener).
+					MustBuild()
+				vmclient := install.GetVMClient(t, kubeOpts)
+				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, []jsonpatch.Patch{patch})
+				svc := install.ExposeVMSingleListeners(t, kubeOpts, namespace, install.OpenTSDBListener, install.OpenTSDBHTTPListener)
+				telnetTunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.OpenTSDBListener)
+				defer telnetTunnel.Close()
+				httpTunnel := install.OpenListenerTunnel(t, kubeOpts, svc, install.OpenTSDBHTTPListener)
+				defer httpTunnel.Close()
+
+				By("Inserting data via OpenTSDB telnet and HTTP protocols")
+				now := time.Now()
+				telnetPoint := opentsdb.Point{Metric: "opentsdb.test.telnet", Tags: map[string]string{"foo": "bar"}, Value: 1, Time: now}
+				err := opentsdb.NewTelnetClient(telnetTunnel.Endpoint()).Put(ctx, telnetPoint)
 				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["env"], model.LabelValue("test"))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
-				require.Equal(t, labels["host"], model.LabelValue("test-host"))
+				httpPoint := opentsdb.Point{Metric: "opentsdb.test.http", Tags: map[string]string{"foo": "bar"}, Value: 2, Time: now}
+				err = opentsdb.NewHTTPClient(c, opentsdb.HTTPURL(httpTunnel.Endpoint())).Put(ctx, httpPoint)
+				require.NoError(t, err)
+
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					ForVMSingle(namespace).
+					WithStartTime(overwatch.Start).
+					MustBuild()
+
+				for _, expected := range opentsdb.ExpectedSeries([]opentsdb.Point{telnetPoint, httpPoint}) {
+					prom.CheckEventually(ctx, t, expected.Selector(), promquery.ValueEquals(expected.Value), promquery.PollOptions{})
+				}
 			})
 		})
 
@@ -1029,71 +1127,82 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
 
 				By("Inserting data via OpenTelemetry protocol")
-				otelURL := fmt.Sprintf("http://%s/opentelemetry/v1/metrics", consts.VMSingleNamespacedHost(namespace))
+				otelReq := otlp.NewBuilder().
+					AddGauge("otel_test_metric", "", 123, map[string]string{"foo": "bar"})
+				otelClient := otlp.NewClient(c, otlp.VMSingleURL(consts.VMSingleNamespacedHost(namespace)), otlp.Options{})
+				err := otelClient.Send(ctx, otelReq.Build())
+				require.NoError(t, err)
+				expected := otelReq.ExpectedSeries(otlp.NamingOptions{})[0]
 
-				timestamp := time.Now().UnixNano()
+				By("Verifying data via Prometheus protocol")
+				prom := tests.NewPromClientBuilder().
+					ForVMSingle(namespace).
+					WithStartTime(overwatch.Start).
+					MustBuild()
 
-				// Construct OTLP Protobuf payload
-				req := &colmetricspb.ExportMetricsServiceRequest{
-					ResourceMetrics: []*metricspb.ResourceMetrics{
-						{
-							ScopeMetrics: []*metricspb.ScopeMetrics{
-								{
-									Metrics: []*metricspb.Metric{
-										{
-											Name: "otel_test_metric",
-											Data: &metricspb.Metric_Gauge{
-												Gauge: &metricspb.Gauge{
-													DataPoints: []*metricspb.NumberDataPoint{
-														{
-															TimeUnixNano: uint64(timestamp),
-															Value: &metricspb.NumberDataPoint_AsInt{
-																AsInt: 123,
-															},
-															Attributes: []*commonpb.KeyValue{
-																{
-																	Key: "foo",
-																	Value: &commonpb.AnyValue{
-																		Value: &commonpb.AnyValue_StringValue{
-																			StringValue: "bar",
-																		},
-																	},
-																},
-															},
-														},
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				}
+				prom.CheckEventually(ctx, t, expected.Selector(),
+					promquery.All(promquery.ValueEquals(expected.Value), promquery.MetricEquals(expected.Metric())), promquery.PollOptions{})
+			})
 
-				data, err := proto.Marshal(req)
-				require.NoError(t, err)
+			It("should ingest all opentelemetry metric types", Label("id=b7e3c2a9-4f15-4d8e-a6c1-93e0d5f7b218"), func(ctx context.Context) {
+				kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+				tests.EnsureNamespaceExists(t, kubeOpts, namespace)
 
-				resp, err := c.Post(otelURL, "application/x-protobuf", bytes.NewReader(data))
+				vmclient := install.GetVMClient(t, kubeOpts)
+				install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+				By("Inserting gauges, sums, histograms and summaries via OpenTelemetry protocol")
+				d := remotewrite.Distribution{
+					Buckets:      []float64{0.1, 0.5, 1},
+					Observations: []float64{0.05, 0.2, 0.3, 0.7, 2},
+				}
+				attrs := map[string]string{"foo": "bar"}
+				otelReq := otlp.NewBuilder().
+					WithResourceAttribute("service.name", "e2e").
+					WithScope("end-to-end-tests", "1.0.0").
+					AddGauge("otel_types_gauge", "", 42, attrs).
+					AddSum("otel_types_counter", "", 10, true, attrs).
+					AddSum("otel_types_updown", "", -3, false, attrs).
+					AddHistogram("otel_types_histogram", "", d, attrs).
+					AddExponentialHistogram("otel_types_exp_histogram", "", 2, d, attrs).
+					AddSummary("otel_types_summary", "", []float64{0.5, 0.9}, d, attrs)
+				otelClient := otlp.NewClient(c, otlp.VMSingleURL(consts.VMSingleNamespacedHost(namespace)), otlp.Options{Gzip: true})
+				err := otelClient.Send(ctx, otelReq.Build())
 				require.NoError(t, err)
-				require.Equal(t, http.StatusOK, resp.StatusCode)
-				_ = resp.Body.Close()
 
-				By("Verifying data via Prometheus protocol")
+				By("Verifying every expected series via Prometheus protocol")
 				prom := tests.NewPromClientBuilder().
 					ForVMSingle(namespace).
 					WithStartTime(overwatch.Start).
 					MustBuild()
 
-				labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "otel_test_metric", 5)
-				require.NoError(t, err)
-				require.Equal(t, value, model.SampleValue(123))
-				require.Equal(t, labels["foo"], model.LabelValue("bar"))
+				for _, expected := range otelReq.ExpectedSeries(otlp.NamingOptions{}) {
+					prom.CheckEventually(ctx, t, expected.Selector(),
+						promquery.All(promquery.ValueInDelta(expected.Value, 1e-9), promquery.MetricEquals(expected.Metric())), promquery.PollOptions{})
+				}
 			})
 		})
 	})
 
+	Describe("Import and export", func() {
+		It("should export exactly the imported samples in every format", Label("id=d8b2f6c1-5e47-4a90-b3d8-0f6e9a2c7b15"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			vmclient := install.GetVMClient(t, kubeOpts)
+			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+			client := vmimport.NewClient(c, vmimport.VMSingleEndpoints(consts.VMSingleNamespacedHost(namespace)))
+			end := time.Now().Add(-time.Minute)
+			for _, format := range []vmimport.Format{vmimport.FormatJSONLines, vmimport.FormatCSV, vmimport.FormatPrometheus, vmimport.FormatNative} {
+				By(fmt.Sprintf("Round-tripping data in %s format", format))
+				series := vmimport.GenSeries("import_"+string(format), 3, 20, end, 15*time.Second)
+				err := client.RoundTrip(ctx, format, series)
+				require.NoError(t, err)
+			}
+		})
+	})
+
 	Describe("Backup and Restore", func() {
 		It("should backup and restore data via PVC", Label("id=8576d108-7357-4555-b4fa-7e8649186c07"), func(ctx context.Context) {
 			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
@@ -1117,7 +1226,7 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithCount(100).
 				WithValue(10).
 				Build()
-			err := remoteWriter.Send(ts)
+			err := remoteWriter.Send(ctx, ts)
 			require.NoError(t, err)
 
 			By("Verifying data before backup")
@@ -1126,9 +1235,7 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "backup_test_10", 5)
-			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(10))
+			prom.CheckEventually(ctx, t, "backup_test_10", promquery.ValueEquals(10), promquery.PollOptions{})
 
 			By("Reconfiguring VMSingle with backup sidecar")
 			vmBackupImage := "victoriametrics/vmbackup:latest"
@@ -1261,11 +1368,160 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, []jsonpatch.Patch{restorePatch})
 
 			By("Verifying restored data")
-			time.Sleep(consts.DataPropagationDelay)
+			prom.CheckEventually(ctx, t, "backup_test_10", promquery.ValueEquals(10), promquery.PollOptions{})
+		})
+	})
+
+	Describe("Rollups", func() {
+		It("should calculate rollups over counter resets", Label("id=3f9c1d52-7a4e-4b8e-9c61-d2a0b5e7f813"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			vmclient := install.GetVMClient(t, kubeOpts)
+
+			By("Installing VMSingle")
+			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+			By("Backfilling a counter with resets")
+			remoteWriter := tests.NewRemoteWriteBuilder().
+				WithHTTPClient(c).
+				ForVMSingle(namespace)
 
-			_, value, err = prom.VectorScan(ctx, "backup_test_10")
+			end := time.Now().Truncate(time.Minute)
+			builder := tests.NewTimeSeriesBuilder("rollup_counter").
+				WithCount(1).
+				WithTimeRange(end.Add(-10*time.Minute), end, 30*time.Second).
+				WithValueGenerator(remotewrite.Counter{Increment: 5, ResetEvery: 7})
+			err := remoteWriter.Send(ctx, builder.Build())
 			require.NoError(t, err)
-			require.Equal(t, value, model.SampleValue(10))
+
+			By("Comparing rollups with locally computed values")
+			prom := tests.NewPromClientBuilder().
+				ForVMSingle(namespace).
+				WithStartTime(overwatch.Start).
+				MustBuild()
+
+			window := 5 * time.Minute
+			expected := builder.ExpectedRollups(end, window)
+			for fn, want := range map[string]float64{
+				"increase":      expected.Increase,
+				"rate":          expected.Rate,
+				"avg_over_time": expected.AvgOverTime,
+			} {
+				query := fmt.Sprintf("%s(rollup_counter_0[5m] @ %d)", fn, end.Unix())
+				prom.CheckEventually(ctx, t, query, promquery.ValueInDelta(want, 1e-9), promquery.PollOptions{})
+			}
+		})
+	})
+
+	Describe("Golden results", func() {
+		It("should return recorded results of the query corpus", Label("id=5a7d3e91-c4b2-4f08-9e6a-1b8f2d0c7e43"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			vmclient := install.GetVMClient(t, kubeOpts)
+
+			By("Installing VMSingle")
+			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+			By("Inserting data")
+			remoteWriter := tests.NewRemoteWriteBuilder().
+				WithHTTPClient(c).
+				ForVMSingle(namespace)
+			err := remoteWriter.Send(ctx, tests.NewTimeSeriesBuilder("golden").
+				WithCount(3).
+				WithValue(10).
+				Build())
+			require.NoError(t, err)
+
+			prom := tests.NewPromClientBuilder().
+				ForVMSingle(namespace).
+				WithStartTime(overwatch.Start).
+				MustBuild()
+			prom.CheckEventually(ctx, t, `{__name__=~"golden_.*"}`, promquery.SeriesCountEquals(3), promquery.PollOptions{})
+
+			By("Comparing query results with the golden file")
+			prom.CheckGolden(ctx, t, "testdata/golden/vmsingle_queries.yaml", promquery.GoldenOptions{
+				IgnoreTimestamps: true,
+				Tolerance:        1e-9,
+			})
+		})
+	})
+
+	Describe("Histograms", func() {
+		It("should calculate histogram_quantile over classic histograms", Label("id=8b1e4c7a-2d93-4f60-a5b8-61c0e9d3f247"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			vmclient := install.GetVMClient(t, kubeOpts)
+
+			By("Installing VMSingle")
+			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+			By("Sending a histogram with metadata and exemplars")
+			builder := tests.NewTimeSeriesBuilder("histogram_test").
+				WithCount(3).
+				WithHistogram(
+					[]float64{0.05, 0.1, 0.25, 0.5, 1, 2.5},
+					[]float64{0.01, 0.04, 0.07, 0.09, 0.12, 0.2, 0.3, 0.45, 0.6, 0.8, 1.7, 3},
+				).
+				WithExemplar(0.3, map[string]string{"trace_id": "4bf92f3577b34da6"}).
+				WithMetadata(prompb.MetricTypeHistogram, "Request latency", "seconds")
+			ts, opts := builder.BuildWithOptions()
+
+			remoteWriter := tests.NewRemoteWriteBuilder().
+				WithHTTPClient(c).
+				ForVMSingle(namespace).
+				WithMetadata(opts.Metadata...).
+				WithExemplars(opts.Exemplars)
+			err := remoteWriter.Send(ctx, ts)
+			require.NoError(t, err)
+
+			By("Comparing quantiles with the generated distribution")
+			prom := tests.NewPromClientBuilder().
+				ForVMSingle(namespace).
+				WithStartTime(overwatch.Start).
+				MustBuild()
+
+			buckets := len(builder.Distribution().BucketCounts())
+			prom.CheckEventually(ctx, t, `{__name__=~"histogram_test_.*_bucket"}`, promquery.SeriesCountEquals(3*buckets), promquery.PollOptions{})
+			prom.CheckHistogramQuantiles(ctx, t, `{__name__=~"histogram_test_.*_bucket"}`, builder.Distribution(), 0.5, 0.9, 0.99)
+		})
+	})
+
+	Describe("Staleness", func() {
+		It("should hide series after a staleness marker", Label("id=c6d2a8f1-5e47-4b39-8f0a-93b7e1d4c265"), func(ctx context.Context) {
+			kubeOpts := k8s.NewKubectlOptions("", "", namespace)
+			tests.EnsureNamespaceExists(t, kubeOpts, namespace)
+
+			vmclient := install.GetVMClient(t, kubeOpts)
+
+			By("Installing VMSingle")
+			install.InstallVMSingle(ctx, t, kubeOpts, namespace, vmclient, nil)
+
+			By("Sending series ending with a staleness marker")
+			remoteWriter := tests.NewRemoteWriteBuilder().
+				WithHTTPClient(c).
+				ForVMSingle(namespace)
+
+			end := time.Now().Truncate(time.Second).Add(-time.Minute)
+			staleAt := end.Add(15 * time.Second)
+			ts := tests.NewTimeSeriesBuilder("stale_test").
+				WithCount(2).
+				WithTimeRange(end.Add(-2*time.Minute), end, 15*time.Second).
+				WithStaleMarker(staleAt).
+				Build()
+			err := remoteWriter.Send(ctx, ts)
+			require.NoError(t, err)
+
+			By("Verifying series disappear after the stale point")
+			prom := tests.NewPromClientBuilder().
+				ForVMSingle(namespace).
+				WithStartTime(overwatch.Start).
+				MustBuild()
+
+			prom.CheckEventually(ctx, t, `{__name__=~"stale_test_.*"}`, promquery.SeriesCountEquals(2), promquery.PollOptions{At: end})
+			prom.CheckSeriesStale(ctx, t, `{__name__=~"stale_test_.*"}`, end, staleAt)
 		})
 	})
 
@@ -1289,16 +1545,14 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithHTTPClient(c).
 				ForVMSingle(namespace)
 
-			// Write 5 samples for the same series
-			for i := 0; i < 5; i++ {
-				ts := tests.NewTimeSeriesBuilder("downsample_test").
-					WithCount(1).
-					WithValue(float64(i)).
-					Build()
-				err := remoteWriter.Send(ts)
-				require.NoError(t, err)
-				time.Sleep(time.Second)
-			}
+			// Write 5 samples for the same series within one minute
+			start := time.Now().Truncate(time.Minute).Add(-time.Minute)
+			ts := tests.NewTimeSeriesBuilder("downsample_test").
+				WithCount(1).
+				WithTimeRange(start, start.Add(4*time.Second), time.Second).
+				Build()
+			err := remoteWriter.Send(ctx, ts)
+			require.NoError(t, err)
 
 			// Wait a bit for merge to complete
 			time.Sleep(1 * time.Minute)
@@ -1309,10 +1563,8 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			labels, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "count_over_time(downsample_test_0[5m])", 5)
-			require.NoError(t, err)
-			require.Equal(t, model.SampleValue(1), value, "Expected one sample after downsampling")
-			_ = labels
+			// Downsampling leaves one sample in the window.
+			prom.CheckEventually(ctx, t, "count_over_time(downsample_test_0[5m])", promquery.ValueEquals(1), promquery.PollOptions{})
 		})
 	})
 
@@ -1351,30 +1603,21 @@ var _ = Describe("VMSingle test", Label("vmsingle"), func() {
 				WithLabel("drop", "false").
 				Build()
 
-			err := remoteWriter.Send(tsDrop)
+			err := remoteWriter.Send(ctx, tsDrop)
 			require.NoError(t, err)
 
-			err = remoteWriter.Send(tsKeep)
+			err = remoteWriter.Send(ctx, tsKeep)
 			require.NoError(t, err)
 
-			By("Wait for time to pass and trigger retention")
-			time.Sleep(1 * time.Minute)
-
 			By("Verifying data")
 			prom := tests.NewPromClientBuilder().
 				ForVMSingle(namespace).
 				WithStartTime(overwatch.Start).
 				MustBuild()
 
-			// Check dropped data
-			_, value, err := tests.RetryVectorScan(ctx, t, namespace, prom, "retention_drop_0", 5)
-			require.EqualError(t, err, consts.ErrNoDataReturned)
-			require.Equal(t, model.SampleValue(0), value)
-
-			// Check kept data
-			_, value, err = prom.VectorScan(ctx, "retention_keep_0")
-			require.NoError(t, err)
-			require.Equal(t, model.SampleValue(1), value)
+			// Kept data becoming searchable means dropped data was ingested as well
+			prom.CheckEventually(ctx, t, "retention_keep_0", promquery.ValueEquals(1), promquery.PollOptions{})
+			prom.CheckEventually(ctx, t, "retention_drop_0", promquery.Absent(), promquery.PollOptions{})
 		})
 	})
 })
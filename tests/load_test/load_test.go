@@ -32,6 +32,7 @@ var _ = Describe("Load tests", Ordered, ContinueOnFailure, Label("load-test"), f
 	t := tests.GetT()
 
 	var overwatch promquery.PrometheusClient
+	var alertRecorder *promquery.AlertRecorder
 
 	BeforeAll(func() {
 		install.DiscoverIngressHost(ctx, t)
@@ -82,7 +83,12 @@ var _ = Describe("Load tests", Ordered, ContinueOnFailure, Label("load-test"), f
 		k8s.CreateNamespace(t, kubeOpts, consts.K6TestsNamespace)
 	})
 
+	BeforeEach(func() {
+		alertRecorder = tests.StartAlertRecorder(overwatch, consts.DefaultVMNamespace)
+	})
+
 	AfterEach(func() {
+		tests.AttachAlertTimeline(t, alertRecorder)
 		defer func() {
 			kubeOpts := k8s.NewKubectlOptions("", "", consts.K6TestsNamespace)
 			k8s.DeleteNamespace(t, kubeOpts, consts.K6TestsNamespace)
@@ -108,12 +114,19 @@ var _ = Describe("Load tests", Ordered, ContinueOnFailure, Label("load-test"), f
 			By("Waiting for K6 jobs to complete")
 			install.WaitForK6JobsToComplete(ctx, t, consts.K6TestsNamespace, scenario, 3)
 
-			// FIXME: TooHighCPUUsage intermittently fires
-			// By("No alerts are firing")
-			// overwatch.CheckNoAlertsFiring(ctx, t, consts.DefaultVMNamespace, nil)
-
-			// lookbackWindow := time.Since(overwatch.Start)
-			// overwatch.CheckAlertWasFiringSince(ctx, t, consts.K6TestsNamespace, "TooHighCPUUsage", lookbackWindow.String())
+			By("No alerts are firing")
+			// TooHighCPUUsage may fire under load, it is only required to recover afterwards
+			overwatch.CheckNoAlertsFiring(ctx, t, consts.DefaultVMNamespace, []string{"TooHighCPUUsage"})
+
+			By("TooHighCPUUsage is resolved after the load")
+			require.Eventually(t, func() bool {
+				history, err := overwatch.AlertHistory(ctx, consts.DefaultVMNamespace, "TooHighCPUUsage", overwatch.Start)
+				if err != nil || history.Firing() {
+					return false
+				}
+				logger.Default.Logf(t, "TooHighCPUUsage history: %s", history)
+				return true
+			}, consts.ResourceWaitTimeout, consts.PollingInterval, "TooHighCPUUsage is still firing after the load")
 
 			By("At least 24k rows were inserted")
 			_, value, err := overwatch.VectorScan(ctx, "sum (vm_rows_inserted_total)")
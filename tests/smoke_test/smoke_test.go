@@ -30,6 +30,7 @@ var _ = Describe("Smoke test", Ordered, ContinueOnFailure, Label("smoke"), func(
 	ctx := context.Background()
 	t := tests.GetT()
 	var overwatch promquery.PrometheusClient
+	var alertRecorder *promquery.AlertRecorder
 
 	BeforeAll(func() {
 		install.DiscoverIngressHost(ctx, t)
@@ -49,7 +50,13 @@ var _ = Describe("Smoke test", Ordered, ContinueOnFailure, Label("smoke"), func(
 		require.NoError(t, err)
 	})
 
+	BeforeEach(func() {
+		alertRecorder = tests.StartAlertRecorder(overwatch, consts.DefaultVMNamespace)
+	})
+
 	AfterEach(func() {
+		tests.ExpireSilences(ctx, t, overwatch)
+		tests.AttachAlertTimeline(t, alertRecorder)
 		kubeOpts := k8s.NewKubectlOptions("", "", consts.DefaultVMNamespace)
 		gather.K8sAfterAll(ctx, t, kubeOpts, consts.ResourceWaitTimeout)
 		gather.VMAfterAll(ctx, t, consts.ResourceWaitTimeout, consts.DefaultReleaseName)
@@ -88,5 +95,56 @@ var _ = Describe("Smoke test", Ordered, ContinueOnFailure, Label("smoke"), func(
 			// require.NoError(t, err)
 			// require.GreaterOrEqual(t, value, float64(1_000))
 		})
+
+		It("VMAlert rules should be healthy", Label("kind", "id=e9358e27-bd54-4206-8212-a308064d63d8"), func() {
+			By("Add custom alert rules")
+			install.AddCustomAlertRules(ctx, t, consts.DefaultVMNamespace)
+
+			By("Custom and default rules are evaluated without errors")
+			tests.CheckVMAlertRulesHealthy(ctx, t, consts.DefaultVMNamespace)
+		})
+
+		It("Alertmanager should silence alerts", Label("kind", "id=cb29b0d5-83c7-4a65-a425-f2c3f5d0d011"), func() {
+			By("Watchdog is firing")
+			overwatch.CheckAlertIsFiring(ctx, t, "", "Watchdog")
+
+			By("Watchdog is suppressed by a silence")
+			ids := tests.SilenceAlerts(ctx, t, overwatch, "", "Watchdog")
+			overwatch.CheckAlertSilenced(ctx, t, "", "Watchdog", ids[0])
+
+			By("Watchdog is active once the silence is expired")
+			require.NoError(t, overwatch.ExpireSilence(ctx, ids[0]))
+			alerts, err := overwatch.AlertmanagerAlerts(ctx, "", "Watchdog")
+			require.NoError(t, err)
+			require.NotEmpty(t, alerts)
+			for _, a := range alerts {
+				require.NotContains(t, a.SilencedBy, ids[0], "Alert %v should not be silenced", a.Labels)
+			}
+		})
+
+		It("Alertmanager should inhibit alerts", Label("kind", "id=4e8b2f61-93ad-4c57-b0e2-7d1a6c9f3e58"), func() {
+			By("A critical and a warning alert with the same name are firing")
+			probe := func(severity string) map[string]string {
+				return map[string]string{"alertname": "InhibitionProbe", "namespace": consts.DefaultVMNamespace, "severity": severity}
+			}
+			require.NoError(t, overwatch.PostAlerts(ctx, time.Now().Add(10*time.Minute), probe("critical"), probe("warning")))
+			defer func() {
+				require.NoError(t, overwatch.PostAlerts(ctx, time.Now().Add(-time.Minute), probe("critical"), probe("warning")))
+			}()
+
+			By("The warning alert is inhibited by the critical one")
+			warning := "alertname=InhibitionProbe,severity=warning"
+			require.Eventually(t, func() bool {
+				alerts, err := overwatch.AlertmanagerAlerts(ctx, consts.DefaultVMNamespace, warning)
+				return err == nil && len(alerts) > 0 && len(alerts[0].InhibitedBy) > 0
+			}, consts.ResourceWaitTimeout, consts.PollingInterval)
+			overwatch.CheckAlertInhibited(ctx, t, consts.DefaultVMNamespace, warning)
+
+			By("The critical alert is not inhibited")
+			alerts, err := overwatch.AlertmanagerAlerts(ctx, consts.DefaultVMNamespace, "alertname=InhibitionProbe,severity=critical")
+			require.NoError(t, err)
+			require.Len(t, alerts, 1)
+			require.Empty(t, alerts[0].InhibitedBy)
+		})
 	})
 })